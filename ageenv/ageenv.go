@@ -0,0 +1,56 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package ageenv decrypts fromenv fields holding values encrypted with age
+// (https://age-encryption.org), so that encrypted values can be kept in
+// otherwise plaintext environment files.
+//
+//	type Config struct {
+//		Password string `env:"PASSWORD" envopt:"encrypted,secret"`
+//	}
+//
+//	id, err := age.ParseX25519Identity(key)
+//	...
+//	err = fromenv.Unmarshal(&cfg, ageenv.Identities(id))
+package ageenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/alfred-landrum/fromenv"
+)
+
+// Identities returns an Option configuring fromenv to decrypt encrypted
+// fields with a Decrypter using ids.
+func Identities(ids ...age.Identity) fromenv.Option {
+	return fromenv.Decrypt(&Decrypter{ids})
+}
+
+// A Decrypter is a fromenv.Decrypter for age encrypted values, either in
+// age's armored form, or base64 encoded.
+type Decrypter struct {
+	Identities []age.Identity
+}
+
+// Decrypt decrypts ciphertext with the Decrypter's identities.
+func (d *Decrypter) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	var src io.Reader
+	trimmed := bytes.TrimSpace(ciphertext)
+	if bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(trimmed))
+	} else {
+		src = base64.NewDecoder(base64.StdEncoding, strings.NewReader(string(trimmed)))
+	}
+	r, err := age.Decrypt(src, d.Identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
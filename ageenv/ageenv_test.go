@@ -0,0 +1,75 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package ageenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func encrypt(t *testing.T, r age.Recipient, plaintext string, armored bool) string {
+	var b bytes.Buffer
+	var dst io.Writer = &b
+	var a io.WriteCloser
+	if armored {
+		a = armor.NewWriter(&b)
+		dst = a
+	}
+	w, err := age.Encrypt(dst, r)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	if armored {
+		require.NoError(t, a.Close())
+		return b.String()
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+func TestIdentities(t *testing.T) {
+	t.Parallel()
+
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	type S struct {
+		Password string `env:"PASSWORD" envopt:"encrypted,secret"`
+		Port     int    `env:"PORT=80" envopt:"encrypted"`
+		Plain    string `env:"PLAIN"`
+	}
+
+	env := map[string]string{
+		"PASSWORD": encrypt(t, id.Recipient(), "hunter2", true),
+		"PORT":     encrypt(t, id.Recipient(), "8080", false),
+		"PLAIN":    "plain",
+	}
+	var s S
+	err = fromenv.Unmarshal(&s, fromenv.Map(env), Identities(other, id))
+	require.NoError(t, err)
+	require.Equal(t, S{"hunter2", 8080, "plain"}, s)
+
+	// Tag defaults aren't decrypted.
+	delete(env, "PORT")
+	s = S{}
+	err = fromenv.Unmarshal(&s, fromenv.Map(env), Identities(id))
+	require.NoError(t, err)
+	require.Equal(t, 80, s.Port)
+
+	err = fromenv.Unmarshal(&S{}, fromenv.Map(env), Identities(other))
+	require.EqualError(t, err, "no identity matched any of the recipients: field Password (string) in struct S")
+
+	err = fromenv.Unmarshal(&S{}, fromenv.Map(env))
+	require.EqualError(t, err, "no decrypter for encrypted value: field Password (string) in struct S")
+}
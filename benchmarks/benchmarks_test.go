@@ -0,0 +1,136 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package benchmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+type server struct {
+	Host string `env:"BENCH_HOST=localhost"`
+	Port int    `env:"BENCH_PORT=80"`
+}
+
+type flat struct {
+	Name    string        `env:"BENCH_NAME=bench"`
+	Debug   bool          `env:"BENCH_DEBUG=true"`
+	Ratio   float64       `env:"BENCH_RATIO=0.5"`
+	Retries uint          `env:"BENCH_RETRIES=3"`
+	Token   string        `env:"BENCH_TOKEN" envopt:"secret"`
+	Timeout time.Duration `env:"BENCH_TIMEOUT=5s"`
+}
+
+type nested struct {
+	Name    string `env:"BENCH_NAME=bench"`
+	Primary server
+	Backup  *server
+	Flat    flat
+}
+
+type cyclic struct {
+	Name string `env:"BENCH_NAME=bench"`
+	Self *cyclic
+	Next *cyclic
+}
+
+var env = map[string]string{
+	"BENCH_HOST":  "example.com",
+	"BENCH_TOKEN": "token",
+}
+
+func BenchmarkFlat(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Map(env), fromenv.CommonTypes())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var c flat
+		if err := d.Decode(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNested(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Map(env), fromenv.CommonTypes())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := nested{Backup: &server{}}
+		if err := d.Decode(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCyclic(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Map(env))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var c1, c2 cyclic
+		c1.Self, c1.Next = &c1, &c2
+		c2.Self, c2.Next = &c2, &c1
+		if err := d.Decode(&c1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	options := []fromenv.Option{fromenv.Map(env), fromenv.CommonTypes()}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := nested{Backup: &server{}}
+		if err := fromenv.Unmarshal(&c, options...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Map(env), fromenv.CommonTypes())
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c := nested{Backup: &server{}}
+			if err := d.Decode(&c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// remote returns a lookup function that takes latency to look up each key,
+// like a request to a secrets manager.
+func remote(latency time.Duration) fromenv.LookupEnvFunc {
+	return func(key string) (*string, error) {
+		time.Sleep(latency)
+		if v, ok := env[key]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	}
+}
+
+func BenchmarkRemote(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Looker(remote(time.Millisecond)), fromenv.CommonTypes())
+	for i := 0; i < b.N; i++ {
+		c := nested{Backup: &server{}}
+		if err := d.Decode(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRemoteConcurrent(b *testing.B) {
+	d := fromenv.NewDecoder(fromenv.Looker(remote(time.Millisecond)), fromenv.CommonTypes(),
+		fromenv.ConcurrentLookups(8))
+	for i := 0; i < b.N; i++ {
+		c := nested{Backup: &server{}}
+		if err := d.Decode(&c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package benchmarks holds benchmarks of the fromenv package, covering
+// flat, nested, and cyclic structs, and lookups with simulated network
+// latency. Run them with:
+//
+//	go test -bench . ./benchmarks
+package benchmarks
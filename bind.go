@@ -0,0 +1,141 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// A Binder sets variables from the environment without struct tags, for
+// types that can't be annotated, such as those from other modules. Its
+// methods return the Binder, so that bindings may be chained:
+//
+//	err := fromenv.NewBinder().
+//		String(&cfg.Host, "HOST", fromenv.Default("localhost")).
+//		Int(&cfg.Port, "PORT", fromenv.Required()).
+//		Apply()
+type Binder struct {
+	bindings []binding
+}
+
+type binding struct {
+	value    reflect.Value
+	key      string
+	defval   *string
+	required bool
+	setFn    setFunc
+}
+
+// A BindOption configures a single binding of a Binder.
+type BindOption func(*binding)
+
+// Default sets the desired value used if the binding's key isn't in the
+// environment, like a default in an env tag.
+func Default(s string) BindOption {
+	return func(b *binding) {
+		b.defval = &s
+	}
+}
+
+// Required causes Apply to return an error if the binding's key isn't in
+// the environment, and the binding has no default.
+func Required() BindOption {
+	return func(b *binding) {
+		b.required = true
+	}
+}
+
+// NewBinder returns an empty Binder.
+func NewBinder() *Binder {
+	return &Binder{}
+}
+
+// Var binds the variable pointed to by ptr to key. The variable is set as a
+// struct field of the same type would be by Unmarshal. Var panics if ptr
+// isn't a non-nil pointer.
+func (b *Binder) Var(ptr interface{}, key string, options ...BindOption) *Binder {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic(errors.New("expected a non-nil pointer"))
+	}
+	bd := binding{value: v.Elem(), key: key}
+	for _, option := range options {
+		option(&bd)
+	}
+	b.bindings = append(b.bindings, bd)
+	return b
+}
+
+// String binds the string pointed to by p to key.
+func (b *Binder) String(p *string, key string, options ...BindOption) *Binder {
+	return b.Var(p, key, options...)
+}
+
+// Int binds the int pointed to by p to key.
+func (b *Binder) Int(p *int, key string, options ...BindOption) *Binder {
+	return b.Var(p, key, options...)
+}
+
+// Bool binds the bool pointed to by p to key.
+func (b *Binder) Bool(p *bool, key string, options ...BindOption) *Binder {
+	return b.Var(p, key, options...)
+}
+
+// Float64 binds the float64 pointed to by p to key.
+func (b *Binder) Float64(p *float64, key string, options ...BindOption) *Binder {
+	return b.Var(p, key, options...)
+}
+
+// Duration binds the time.Duration pointed to by p to key. Values are
+// parsed with time.ParseDuration, unless a function for time.Duration is
+// configured via SetFunc.
+func (b *Binder) Duration(p *time.Duration, key string, options ...BindOption) *Binder {
+	setDuration := func(b *binding) {
+		b.setFn = func(val reflect.Value, key, s string) error {
+			d, err := time.ParseDuration(s)
+			val.SetInt(int64(d))
+			return err
+		}
+	}
+	return b.Var(p, key, append(options, setDuration)...)
+}
+
+// Apply sets each bound variable to its desired value, using the lookup
+// and setting functions configured by options, as Unmarshal would. Bound
+// variables whose key isn't in the environment, and that have no default,
+// are left unchanged.
+func (b *Binder) Apply(options ...Option) error {
+	config := newConfig(options)
+	for _, bd := range b.bindings {
+		if err := bd.apply(config); err != nil {
+			return fmt.Errorf("%s: key %v (%v)", err.Error(), bd.key, bd.value.Kind().String())
+		}
+	}
+	return nil
+}
+
+func (b *binding) apply(config *config) error {
+	val, _, err := config.lookup(b.key)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		if b.defval == nil {
+			if b.required {
+				return errMissingRequired
+			}
+			return nil
+		}
+		val = b.defval
+	}
+
+	if _, ok := config.setFuncs[b.value.Type()]; !ok && b.setFn != nil {
+		return b.setFn(b.value, b.key, *val)
+	}
+	return setValue(config, b.value, b.key, *val, nil)
+}
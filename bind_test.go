@@ -0,0 +1,64 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinder(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Host    string
+		Port    int
+		Debug   bool
+		Ratio   float64
+		Timeout time.Duration
+		Limit   uint16
+	}
+	cfg.Limit = 7
+
+	env := map[string]string{
+		"PORT":    "8080",
+		"DEBUG":   "true",
+		"TIMEOUT": "5s",
+	}
+	err := NewBinder().
+		String(&cfg.Host, "HOST", Default("localhost")).
+		Int(&cfg.Port, "PORT", Required()).
+		Bool(&cfg.Debug, "DEBUG").
+		Float64(&cfg.Ratio, "RATIO", Default("0.5")).
+		Duration(&cfg.Timeout, "TIMEOUT").
+		Var(&cfg.Limit, "LIMIT").
+		Apply(Map(env))
+	require.NoError(t, err)
+	require.Equal(t, "localhost", cfg.Host)
+	require.Equal(t, 8080, cfg.Port)
+	require.True(t, cfg.Debug)
+	require.Equal(t, 0.5, cfg.Ratio)
+	require.Equal(t, 5*time.Second, cfg.Timeout)
+	require.Equal(t, uint16(7), cfg.Limit)
+
+	err = NewBinder().Int(&cfg.Port, "PORT", Required()).Apply(Map(nil))
+	require.EqualError(t, err, "missing required key: key PORT (int)")
+
+	err = NewBinder().Int(&cfg.Port, "PORT").Apply(Map(map[string]string{"PORT": "eighty"}))
+	require.EqualError(t, err, "strconv.ParseInt: parsing \"eighty\": invalid syntax: key PORT (int)")
+
+	// A configured SetFunc takes precedence over the built-in parser.
+	minutes := func(d *time.Duration, s string) error {
+		*d = 3 * time.Minute
+		return nil
+	}
+	err = NewBinder().Duration(&cfg.Timeout, "TIMEOUT").Apply(Map(env), SetFunc(minutes))
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Minute, cfg.Timeout)
+
+	require.Panics(t, func() { NewBinder().Var(cfg, "X") })
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// A fieldInfo holds the parsed tags of a struct field.
+type fieldInfo struct {
+	field  reflect.StructField
+	key    string
+	defval *string
+	mods   map[string]string
+	modErr error
+	secret bool
+	kinds  setterKinds
+}
+
+// A structInfo holds the parsed tags of a struct type's fields. It doesn't
+// depend on any options, so one is shared by every decode of its type, and
+// it's never modified after it's stored in structCache, so it's read
+// without locking.
+type structInfo struct {
+	fields []fieldInfo
+	// flat is true if none of the fields can lead to a nested struct.
+	flat bool
+}
+
+// structCache maps struct types to their *structInfo. Once a type is
+// stored, loading it doesn't take a lock.
+var structCache sync.Map
+
+// cachedStruct returns the parsed tags of the fields of struct type t,
+// parsing them only the first time t is seen. The result must not be
+// modified.
+func cachedStruct(t reflect.Type) *structInfo {
+	if info, ok := structCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+	info := &structInfo{fields: make([]fieldInfo, t.NumField()), flat: true}
+	for i := range info.fields {
+		f := &info.fields[i]
+		f.field = t.Field(i)
+		f.key, f.defval = parseTag(f.field)
+		f.mods, f.modErr = parseModifiers(f.field)
+		_, secret := f.mods["secret"]
+		_, encrypted := f.mods["encrypted"]
+		f.secret = secret || encrypted || isSecreter(f.field.Type)
+		f.kinds = typeSetterKinds(f.field.Type)
+		switch f.field.Type.Kind() {
+		case reflect.Struct, reflect.Ptr, reflect.Interface:
+			info.flat = false
+		}
+	}
+	actual, _ := structCache.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// parseTag returns the environment key and possible default value
+// encoded in the field struct tag.
+func parseTag(field reflect.StructField) (string, *string) {
+	tag := field.Tag.Get(tagName)
+	s := strings.SplitN(tag, tagSep, 2)
+	if len(s) == 1 {
+		return s[0], nil
+	}
+	return s[0], &s[1]
+}
+
+var secreterType = reflect.TypeOf((*Secreter)(nil)).Elem()
+
+// isSecreter returns true if t, or the type t points to, implements
+// Secreter with a SecretEnv method that returns true.
+func isSecreter(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pt := reflect.PtrTo(t)
+	if !pt.Implements(secreterType) {
+		return false
+	}
+	return reflect.New(t).Interface().(Secreter).SecretEnv()
+}
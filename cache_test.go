@@ -0,0 +1,70 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedFields(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		A string `env:"A=a-default" envopt:"secret"`
+		B int    `env:"B" envopt:"bogus"`
+		C bool
+	}
+
+	typ := reflect.TypeOf(S{})
+	info := cachedStruct(typ)
+	require.True(t, info.flat)
+	fields := info.fields
+	require.Len(t, fields, 3)
+	require.Equal(t, "A", fields[0].key)
+	require.Equal(t, "a-default", *fields[0].defval)
+	require.True(t, fields[0].secret)
+	require.EqualError(t, fields[1].modErr, `unknown modifier: "bogus"`)
+	require.Equal(t, "", fields[2].key)
+	require.Nil(t, fields[2].defval)
+
+	// Later calls return the cached fields.
+	require.Same(t, info, cachedStruct(typ))
+
+	type N struct {
+		A string `env:"A"`
+		S *S
+	}
+	require.False(t, cachedStruct(reflect.TypeOf(N{})).flat)
+}
+
+func TestSetterKinds(t *testing.T) {
+	// AllocsPerRun can't be used in parallel tests.
+
+	type S struct {
+		N  int
+		V  testSetIface
+		PV *testSetIface
+		C  testCtxSetter
+	}
+
+	fields := cachedStruct(reflect.TypeOf(S{})).fields
+	require.Equal(t, setterKinds{}, fields[0].kinds)
+	require.Equal(t, setterKinds{setter: true}, fields[1].kinds)
+	require.Equal(t, setterKinds{setter: true}, fields[2].kinds)
+	require.Equal(t, setterKinds{setter: true, contextSetter: true}, fields[3].kinds)
+
+	// Setting a plain field doesn't allocate.
+	var s S
+	v := reflect.ValueOf(&s).Elem().Field(0)
+	config := newConfig(nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = setValue(config, v, "N", "1", &fields[0].kinds)
+	})
+	require.Equal(t, 0.0, allocs)
+	require.Equal(t, 1, s.N)
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Check reports problems with the tags of the struct pointed to by in,
+// without looking up any keys: invalid modifiers, and combinations of
+// tags that contradict each other, such as:
+//
+// * A required field with a tag default, which is never used.
+//
+// * A field with a source modifier that doesn't allow the tag default,
+// and a tag default.
+//
+// * A required field that's also deprecated.
+//
+// * Fields sharing a key, but with different tag defaults.
+//
+// * A modifier that requires a slice field, such as shellwords, on a field
+// that isn't one, or the csv modifier on a field that isn't a struct, or
+// whose envcsv tags are invalid, or the layouts modifier on a field that
+// isn't a time.Time, or without layouts, or the envmap modifier on a field
+// that isn't a map, or with a tag default, which is never used, or the
+// oneof modifier on a field that isn't a struct with variants, or an if
+// modifier without a key, or a from modifier naming a looker that options
+// don't configure, or given with a source modifier.
+//
+// * A secret field, when options include IncludeSecrets, so that Marshal
+// would output its value.
+//
+// Check returns an ErrorList, holding a FieldError with code
+// CodeInvalidTag for each problem, or nil if there are none.
+// FieldErrors don't have source positions, as reflection doesn't provide
+// them; the Analyzer in the tagcheck package reports the problems that
+// don't depend on options at their positions, when run by go vet.
+func Check(in interface{}, options ...Option) error {
+	if !isStructPtr(in) {
+		return errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(options)
+
+	var errs ErrorList
+	fail := func(c *cursor, format string, args ...interface{}) {
+		errs = append(errs, config.fieldError(CodeInvalidTag, fmt.Errorf(format, args...), c))
+	}
+	defaults := make(map[string]*string)
+	_ = visit(in, "", func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if c.info.modErr != nil {
+			fail(c, "%v", c.info.modErr)
+			return nil
+		}
+		if err := config.rekey(c); err != nil {
+			fail(c, "%v", err)
+			return nil
+		}
+		key := c.info.key
+		if key == "" {
+			return nil
+		}
+
+		_, required := c.info.mods["required"]
+		if required && c.info.defval != nil {
+			fail(c, "required field has a default, which is never used")
+		}
+		if sources, ok := c.info.mods["source"]; ok {
+			names, useDefault, err := parseSources(sources)
+			if err == nil {
+				err = config.checkSources(names)
+			}
+			if err != nil {
+				fail(c, "%v", err)
+			} else if !useDefault && c.info.defval != nil && !required {
+				fail(c, "source modifier doesn't allow the default, which is never used")
+			}
+		}
+		if _, ok := c.info.mods["deprecated"]; ok && required {
+			fail(c, "required field is deprecated")
+		}
+		if _, ok := c.info.mods["shellwords"]; ok && !isSliceType(c.field.Type) {
+			fail(c, "shellwords modifier requires a slice field")
+		}
+		if _, ok := c.info.mods["csv"]; ok {
+			t := c.field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Struct {
+				fail(c, "csv modifier requires a struct field")
+			} else if _, _, err := csvColumns(t); err != nil {
+				fail(c, "%v", err)
+			}
+		}
+		if layouts, ok := c.info.mods["layouts"]; ok {
+			t := c.field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t != timeType {
+				fail(c, "layouts modifier requires a time.Time field")
+			} else if _, err := parseLayouts(layouts); err != nil {
+				fail(c, "%v", err)
+			}
+		}
+		if _, ok := c.info.mods["envmap"]; ok {
+			if _, _, err := envMapType(c.field.Type); err != nil {
+				fail(c, "%v", err)
+			} else if c.info.defval != nil {
+				fail(c, "envmap field has a default, which is never used")
+			}
+		}
+		if _, ok := c.info.mods["oneof"]; ok {
+			if _, err := unionVariants(c.field.Type); err != nil {
+				fail(c, "%v", err)
+			}
+		}
+		if cond, ok := c.info.mods["if"]; ok && cond == "" {
+			fail(c, "if modifier has no key")
+		}
+		if from, ok := c.info.mods["from"]; ok {
+			if _, ok := c.info.mods["source"]; ok {
+				fail(c, "from and source modifiers both given")
+			} else if !config.hasLooker(from) {
+				fail(c, "unknown looker %q in from modifier", from)
+			}
+		}
+		if c.info.secret && config.includeSecrets {
+			fail(c, "secret field is included in Marshal output")
+		}
+
+		if prev, ok := defaults[key]; ok {
+			if (prev == nil) != (c.info.defval == nil) || (prev != nil && *prev != *c.info.defval) {
+				fail(c, "key %v has a different default than another field", key)
+			}
+		} else {
+			defaults[key] = c.info.defval
+		}
+		return nil
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
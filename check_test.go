@@ -0,0 +1,60 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	type Good struct {
+		Host  string `env:"HOST=localhost"`
+		Token string `env:"TOKEN" envopt:"secret,required"`
+		Level string `env:"LEVEL=info" envopt:"source=env;default"`
+	}
+	require.NoError(t, Check(&Good{}))
+
+	type Inner struct {
+		Host string `env:"HOST=other"`
+	}
+	type Bad struct {
+		Host  string `env:"HOST=localhost"`
+		Name  string `env:"NAME=n" envopt:"required"`
+		Level string `env:"LEVEL=info" envopt:"source=env"`
+		Old   string `env:"OLD" envopt:"required,deprecated"`
+		Token string `env:"TOKEN" envopt:"secret"`
+		Bogus string `env:"BOGUS" envopt:"bogus"`
+		Args  string `env:"ARGS" envopt:"shellwords"`
+		Row   string `env:"ROW" envopt:"csv"`
+		Inner Inner
+	}
+
+	err := Check(&Bad{}, IncludeSecrets())
+	var list ErrorList
+	require.True(t, errors.As(err, &list))
+	var msgs []string
+	for _, e := range list {
+		require.Equal(t, CodeInvalidTag, e.Code)
+		msgs = append(msgs, e.Error())
+	}
+	require.Equal(t, []string{
+		"required field has a default, which is never used: field Name (string) in struct Bad",
+		"source modifier doesn't allow the default, which is never used: field Level (string) in struct Bad",
+		"required field is deprecated: field Old (string) in struct Bad",
+		"secret field is included in Marshal output: field Token (string) in struct Bad",
+		`unknown modifier: "bogus": field Bogus (string) in struct Bad`,
+		"shellwords modifier requires a slice field: field Args (string) in struct Bad",
+		"csv modifier requires a struct field: field Row (string) in struct Bad",
+		"key HOST has a different default than another field: field Host (string) in struct Inner",
+	}, msgs)
+	require.Equal(t, "Inner.Host", list[7].Path)
+
+	require.Error(t, Check(Bad{}))
+}
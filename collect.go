@@ -0,0 +1,196 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// An ErrorList is returned by Unmarshal with the CollectErrors option when
+// any fields fail, holding an error for each, in field order.
+type ErrorList []*FieldError
+
+// Error returns the errors' messages, one per line, grouped by section, as
+// by Sections, with a count of the errors in each section.
+func (l ErrorList) Error() string {
+	var b strings.Builder
+	if len(l) == 1 {
+		b.WriteString("1 error")
+	} else {
+		fmt.Fprintf(&b, "%d errors", len(l))
+	}
+	for _, s := range l.Sections() {
+		name := s.Name
+		if name == "" {
+			name = "top level"
+		}
+		fmt.Fprintf(&b, "\n%v (%d):", name, len(s.Errors))
+		for _, e := range s.Errors {
+			b.WriteString("\n    " + e.Error())
+		}
+	}
+	return b.String()
+}
+
+// An ErrorSection holds the errors for the fields within a top-level
+// struct field.
+type ErrorSection struct {
+	// Name is the name of the top-level field, such as "Redis" for the
+	// field at "Redis.Addr", or empty for fields that aren't within a
+	// struct field.
+	Name string
+	// Errors are the section's errors, in field order.
+	Errors ErrorList
+}
+
+// Sections returns the errors in l grouped by the top-level field they're
+// within, in the order of each section's first error.
+func (l ErrorList) Sections() []ErrorSection {
+	var sections []ErrorSection
+	index := make(map[string]int)
+	for _, e := range l {
+		name := ""
+		if i := strings.IndexByte(e.Path, '.'); i >= 0 {
+			name = e.Path[:i]
+		}
+		j, ok := index[name]
+		if !ok {
+			j = len(sections)
+			index[name] = j
+			sections = append(sections, ErrorSection{Name: name})
+		}
+		sections[j].Errors = append(sections[j].Errors, e)
+	}
+	return sections
+}
+
+// Unwrap returns the errors in l.
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Is returns true if one of the errors in l matches target, as by
+// errors.Is. Before Go 1.20, errors.Is doesn't use Unwrap to match the
+// errors in l, but does use Is.
+func (l ErrorList) Is(target error) bool {
+	for _, e := range l {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As sets target to the first of the errors in l that matches it, as by
+// errors.As, and returns true, or returns false if none match.
+func (l ErrorList) As(target interface{}) bool {
+	for _, e := range l {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectErrors configures Unmarshal to keep setting fields after a field
+// fails, and then to return an ErrorList holding the error for each field
+// that failed.
+func CollectErrors() Option {
+	return func(c *config) {
+		c.collect = true
+	}
+}
+
+// collecting returns visitor wrapped to add FieldErrors to the config's
+// collected errors, rather than stopping the visit.
+func (c *config) collecting(visitor func(*cursor) error) func(*cursor) error {
+	return func(cur *cursor) error {
+		err := visitor(cur)
+		var fe *FieldError
+		if errors.As(err, &fe) {
+			c.errs = append(c.errs, fe)
+			return errSkipField
+		}
+		return err
+	}
+}
+
+// collected returns the collected errors, or nil if there are none.
+func (c *config) collected() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return append(ErrorList(nil), c.errs...)
+}
+
+// MustUnmarshal is like Unmarshal with the CollectErrors option, but if
+// any fields fail, it writes a report of every problem to standard error,
+// and exits the program with status 1. The report lists missing required
+// keys first, and then values that failed to parse, followed by any
+// other errors.
+func MustUnmarshal(in interface{}, options ...Option) {
+	err := Unmarshal(in, append(options, CollectErrors())...)
+	if err == nil {
+		return
+	}
+	writeReport(os.Stderr, err)
+	os.Exit(1)
+}
+
+// writeReport writes a report of err, as by MustUnmarshal, to w.
+func writeReport(w io.Writer, err error) {
+	var list ErrorList
+	if !errors.As(err, &list) {
+		fmt.Fprintf(w, "configuration error: %v\n", err)
+		return
+	}
+
+	groups := []struct {
+		title string
+		match func(*FieldError) bool
+	}{
+		{"missing required keys", func(e *FieldError) bool { return e.Code == CodeMissingRequired }},
+		{"invalid values", func(e *FieldError) bool { return e.Code == CodeParseFailure }},
+		{"other errors", func(e *FieldError) bool {
+			return e.Code != CodeMissingRequired && e.Code != CodeParseFailure
+		}},
+	}
+
+	fmt.Fprintf(w, "configuration errors (%d):\n", len(list))
+	for _, g := range groups {
+		var errs []*FieldError
+		for _, e := range list {
+			if g.match(e) {
+				errs = append(errs, e)
+			}
+		}
+		if len(errs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", g.title)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, e := range errs {
+			detail := e.Err.Error()
+			if e.Code == CodeMissingRequired {
+				detail = strings.TrimPrefix(strings.TrimPrefix(detail, errMissingRequired.Error()), " ")
+			}
+			line := "  " + e.Key + "\t" + e.Path
+			if detail != "" {
+				line += "\t" + detail
+			}
+			fmt.Fprintln(tw, line)
+		}
+		tw.Flush()
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectErrors(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		URL  string `env:"DB_URL" envopt:"required"`
+		Pool int    `env:"DB_POOL"`
+	}
+	type S struct {
+		Port  int    `env:"PORT"`
+		Token string `env:"TOKEN" envopt:"required"`
+		Name  string `env:"NAME"`
+		DB    DB
+	}
+
+	env := Map(map[string]string{"PORT": "eighty", "DB_POOL": "x", "NAME": "n", "TOKNE": "t"})
+
+	var s S
+	err := Unmarshal(&s, env)
+	require.EqualError(t, err, `strconv.ParseInt: parsing "eighty": invalid syntax: field Port (int) in struct S`)
+
+	s = S{}
+	err = Unmarshal(&s, env, CollectErrors())
+	var list ErrorList
+	require.True(t, errors.As(err, &list))
+	require.Len(t, list, 4)
+	require.Equal(t, "n", s.Name)
+	require.Equal(t, []ErrorCode{CodeParseFailure, CodeMissingRequired, CodeMissingRequired, CodeParseFailure},
+		[]ErrorCode{list[0].Code, list[1].Code, list[2].Code, list[3].Code})
+	require.Equal(t, `4 errors
+top level (2):
+    strconv.ParseInt: parsing "eighty": invalid syntax: field Port (int) in struct S
+    missing required key (did you mean TOKNE?): field Token (string) in struct S
+DB (2):
+    missing required key: field URL (string) in struct DB
+    strconv.ParseInt: parsing "x": invalid syntax: field Pool (int) in struct DB`, err.Error())
+	sections := list.Sections()
+	require.Len(t, sections, 2)
+	require.Equal(t, "", sections[0].Name)
+	require.Equal(t, "DB", sections[1].Name)
+	require.Equal(t, ErrorList{list[2], list[3]}, sections[1].Errors)
+	require.Len(t, list.Unwrap(), 4)
+	require.True(t, list.Is(errMissingRequired))
+	require.False(t, list.Is(errors.New("other")))
+	var fe *FieldError
+	require.True(t, list.As(&fe))
+	require.Same(t, list[0], fe)
+	require.True(t, errors.Is(err, errMissingRequired))
+
+	s = S{}
+	require.NoError(t, Unmarshal(&s, Map(map[string]string{"TOKEN": "t", "DB_URL": "u"}), CollectErrors()))
+
+	var buf bytes.Buffer
+	writeReport(&buf, err)
+	require.Equal(t, `configuration errors (4):
+
+missing required keys:
+  TOKEN   Token  (did you mean TOKNE?)
+  DB_URL  DB.URL
+
+invalid values:
+  PORT     Port     strconv.ParseInt: parsing "eighty": invalid syntax
+  DB_POOL  DB.Pool  strconv.ParseInt: parsing "x": invalid syntax
+`, buf.String())
+
+	buf.Reset()
+	writeReport(&buf, errors.New("passed non-pointer or nil pointer"))
+	require.Equal(t, "configuration error: passed non-pointer or nil pointer\n", buf.String())
+
+	// MustUnmarshal returns when there are no errors.
+	s = S{}
+	MustUnmarshal(&s, Map(map[string]string{"TOKEN": "t", "DB_URL": "u"}))
+	require.Equal(t, "u", s.DB.URL)
+}
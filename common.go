@@ -0,0 +1,157 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// CommonTypes configures Unmarshal with setting functions for common
+// standard library types:
+//
+// * time.Duration, parsed with time.ParseDuration.
+//
+// * time.Time, parsed with time.Parse and the time.RFC3339 layout.
+//
+// * url.URL, parsed with url.Parse.
+//
+// * net.IP, parsed with net.ParseIP.
+//
+// * regexp.Regexp, compiled with regexp.Compile.
+//
+// * net.TCPAddr and net.UDPAddr, resolved with net.ResolveTCPAddr and
+// net.ResolveUDPAddr, which may look up host names; see NumericAddrs.
+//
+// Functions configured by later SetFunc options take precedence.
+func CommonTypes() Option {
+	fns := []interface{}{
+		setDuration,
+		setTime,
+		setURL,
+		setIP,
+		setRegexp,
+		setTCPAddr,
+		setUDPAddr,
+	}
+	return func(c *config) {
+		for _, fn := range fns {
+			SetFunc(fn)(c)
+		}
+	}
+}
+
+func setDuration(d *time.Duration, s string) error {
+	x, err := time.ParseDuration(s)
+	*d = x
+	return err
+}
+
+func setTime(t *time.Time, s string) error {
+	x, err := time.Parse(time.RFC3339, s)
+	*t = x
+	return err
+}
+
+func setURL(u *url.URL, s string) error {
+	x, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	*u = *x
+	return nil
+}
+
+func setIP(ip *net.IP, s string) error {
+	x := net.ParseIP(s)
+	if x == nil {
+		return fmt.Errorf("invalid IP address: %q", s)
+	}
+	*ip = x
+	return nil
+}
+
+func setRegexp(r *regexp.Regexp, s string) error {
+	x, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	*r = *x
+	return nil
+}
+
+func setTCPAddr(a *net.TCPAddr, s string) error {
+	x, err := net.ResolveTCPAddr("tcp", s)
+	if err != nil {
+		return err
+	}
+	*a = *x
+	return nil
+}
+
+func setUDPAddr(a *net.UDPAddr, s string) error {
+	x, err := net.ResolveUDPAddr("udp", s)
+	if err != nil {
+		return err
+	}
+	*a = *x
+	return nil
+}
+
+// NumericAddrs configures Unmarshal to set net.TCPAddr and net.UDPAddr
+// fields only from addresses with a literal IP address, such as
+// "10.0.0.1:80" or "[::1]:53", or an empty host, as in ":8080", so that
+// decoding never looks up host names. It overrides CommonTypes, if given
+// before it.
+func NumericAddrs() Option {
+	fns := []interface{}{
+		func(a *net.TCPAddr, s string) error {
+			ip, port, zone, err := parseNumericAddr("tcp", s)
+			if err != nil {
+				return err
+			}
+			*a = net.TCPAddr{IP: ip, Port: port, Zone: zone}
+			return nil
+		},
+		func(a *net.UDPAddr, s string) error {
+			ip, port, zone, err := parseNumericAddr("udp", s)
+			if err != nil {
+				return err
+			}
+			*a = net.UDPAddr{IP: ip, Port: port, Zone: zone}
+			return nil
+		},
+	}
+	return func(c *config) {
+		for _, fn := range fns {
+			SetFunc(fn)(c)
+		}
+	}
+}
+
+// parseNumericAddr parses a host and port, where the host must be empty or
+// a literal IP address, and a port name is looked up for the network.
+func parseNumericAddr(network, s string) (net.IP, int, string, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	p, err := net.LookupPort(network, port)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if host == "" {
+		return nil, p, "", nil
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("invalid IP address: %q", host)
+	}
+	return net.IP(addr.AsSlice()), p, addr.Zone(), nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommonTypes(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		D   time.Duration  `env:"D"`
+		T   time.Time      `env:"T"`
+		U   *url.URL       `env:"U"`
+		IP  net.IP         `env:"IP"`
+		Re  *regexp.Regexp `env:"RE"`
+		Opt *time.Duration `env:"OPT"`
+	}
+
+	env := map[string]string{
+		"D":  "1m",
+		"T":  "2021-03-23T10:00:00Z",
+		"U":  "https://example.com/path",
+		"IP": "192.168.1.1",
+		"RE": "^a+$",
+	}
+	var s S
+	err := Unmarshal(&s, Map(env), CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, s.D)
+	require.Equal(t, time.Date(2021, 3, 23, 10, 0, 0, 0, time.UTC), s.T)
+	require.Equal(t, "example.com", s.U.Host)
+	require.Equal(t, "192.168.1.1", s.IP.String())
+	require.True(t, s.Re.MatchString("aaa"))
+	require.Nil(t, s.Opt)
+
+	bad := map[string]string{
+		"D":  "1m",
+		"IP": "not-an-ip",
+	}
+	err = Unmarshal(&s, Map(bad), CommonTypes())
+	require.EqualError(t, err, "invalid IP address: \"not-an-ip\": field IP (slice) in struct S")
+
+	err = Unmarshal(&s, Map(map[string]string{"RE": "("}), CommonTypes())
+	require.Error(t, err)
+
+	// Later SetFunc options override the common setters.
+	hours := func(d *time.Duration, s string) error {
+		*d = time.Hour
+		return nil
+	}
+	err = Unmarshal(&s, Map(env), CommonTypes(), SetFunc(hours))
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, s.D)
+}
+
+func TestAddrs(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Listen *net.TCPAddr `env:"LISTEN"`
+		Peer   net.UDPAddr  `env:"PEER"`
+	}
+
+	env := map[string]string{"LISTEN": ":8080", "PEER": "[::1]:53"}
+	var s S
+	err := Unmarshal(&s, Map(env), CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, 8080, s.Listen.Port)
+	require.Equal(t, "[::1]:53", s.Peer.String())
+
+	out, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"LISTEN": ":8080", "PEER": "[::1]:53"}, out)
+
+	s = S{}
+	err = Unmarshal(&s, Map(map[string]string{"LISTEN": "10.0.0.1:http"}), CommonTypes(), NumericAddrs())
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1:80", s.Listen.String())
+
+	err = Unmarshal(&s, Map(map[string]string{"LISTEN": "localhost:80"}), CommonTypes(), NumericAddrs())
+	require.EqualError(t, err, `invalid IP address: "localhost": field Listen (ptr) in struct S`)
+
+	s.Peer = net.UDPAddr{IP: net.ParseIP("::1"), Port: 53}
+	err = Unmarshal(&s, Map(map[string]string{"PEER": "localhost:53"}), CommonTypes(), NumericAddrs())
+	require.Error(t, err)
+	require.Equal(t, "[::1]:53", s.Peer.String())
+
+	// Port names are looked up for the address's network.
+	if port, err := net.LookupPort("udp", "ntp"); err == nil {
+		err = Unmarshal(&s, Map(map[string]string{"PEER": "[::1]:ntp"}), CommonTypes(), NumericAddrs())
+		require.NoError(t, err)
+		require.Equal(t, port, s.Peer.Port)
+	}
+
+	err = Unmarshal(&s, Map(map[string]string{"PEER": "no-port"}), CommonTypes())
+	require.Error(t, err)
+}
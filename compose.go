@@ -0,0 +1,116 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A Composite is a configuration assembled at runtime from a parent struct
+// and other structs mounted under key prefixes, for plugin systems whose
+// configuration structs aren't known when the parent struct is defined.
+type Composite struct {
+	parent interface{}
+	mounts []mount
+}
+
+// A mount is a struct pointer whose keys are prefixed.
+type mount struct {
+	prefix string
+	in     interface{}
+}
+
+// Compose returns a Composite of the struct pointed to by parent, and the
+// struct pointed to by in, mounted so that each of its keys is prefixed by
+// prefix, as if its fields were tagged with the prefixed keys:
+//
+//	cfg := fromenv.Compose(&appConfig, "CACHE_", &cacheConfig)
+//	err := cfg.Unmarshal()
+//
+// The parent may be nil, for a Composite of only mounted structs.
+func Compose(parent interface{}, prefix string, in interface{}) *Composite {
+	return (&Composite{parent: parent}).Mount(prefix, in)
+}
+
+// Mount mounts the struct pointed to by in under prefix, and returns the
+// Composite, so that calls may be chained. A struct may be mounted more
+// than once, under different prefixes, such as for several instances of a
+// plugin.
+func (c *Composite) Mount(prefix string, in interface{}) *Composite {
+	c.mounts = append(c.mounts, mount{prefix, in})
+	return c
+}
+
+// structs calls fn for the parent, if any, and each mounted struct, with
+// the options to use for it, stopping at the first error, unless all
+// errors are collected, as with CollectErrors, in which case the returned
+// ErrorList holds the errors from every struct.
+func (c *Composite) structs(options []Option, fn func(in interface{}, options []Option) error) error {
+	collect := newConfig(options).collect
+	var errs ErrorList
+	visit := func(in interface{}, options []Option) error {
+		err := fn(in, options)
+		var list ErrorList
+		if collect && errors.As(err, &list) {
+			errs = append(errs, list...)
+			return nil
+		}
+		return err
+	}
+	if c.parent != nil {
+		if err := visit(c.parent, options); err != nil {
+			return err
+		}
+	}
+	for _, m := range c.mounts {
+		if err := visit(m.in, append(options[:len(options):len(options)], prefixKeys(m.prefix))); err != nil {
+			return fmt.Errorf("prefix %v: %w", m.prefix, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// prefixKeys configures the keys of fields to be prefixed by prefix.
+func prefixKeys(prefix string) Option {
+	return KeyFunc(func(key string, _ FieldInfo) string {
+		return prefix + key
+	})
+}
+
+// Unmarshal calls Unmarshal, with options, for the parent and each mounted
+// struct in turn.
+func (c *Composite) Unmarshal(options ...Option) error {
+	return c.structs(options, func(in interface{}, options []Option) error {
+		return Unmarshal(in, options...)
+	})
+}
+
+// Marshal calls Marshal, with options, for the parent and each mounted
+// struct, and returns the merged values. It returns an error if two fields
+// with the same key have different values.
+func (c *Composite) Marshal(options ...Option) (map[string]string, error) {
+	env := make(map[string]string)
+	err := c.structs(options, func(in interface{}, options []Option) error {
+		m, err := Marshal(in, options...)
+		if err != nil {
+			return err
+		}
+		for k, v := range m {
+			if prev, ok := env[k]; ok && prev != v {
+				return fmt.Errorf("conflicting values for key %v", k)
+			}
+			env[k] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
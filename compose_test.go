@@ -0,0 +1,70 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	t.Parallel()
+
+	type App struct {
+		Name string `env:"NAME"`
+	}
+	type Cache struct {
+		Addr string `env:"ADDR" envopt:"required"`
+		TTL  int    `env:"TTL=60"`
+	}
+
+	env := map[string]string{
+		"NAME":          "app",
+		"CACHE_ADDR":    "redis:6379",
+		"SESSIONS_ADDR": "memcache:11211",
+		"SESSIONS_TTL":  "5",
+		"ADDR":          "unprefixed",
+	}
+	var app App
+	var cache, sessions Cache
+	cfg := Compose(&app, "CACHE_", &cache).Mount("SESSIONS_", &sessions)
+	require.NoError(t, cfg.Unmarshal(Map(env)))
+	require.Equal(t, App{"app"}, app)
+	require.Equal(t, Cache{"redis:6379", 60}, cache)
+	require.Equal(t, Cache{"memcache:11211", 5}, sessions)
+
+	out, err := cfg.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"NAME":          "app",
+		"CACHE_ADDR":    "redis:6379",
+		"CACHE_TTL":     "60",
+		"SESSIONS_ADDR": "memcache:11211",
+		"SESSIONS_TTL":  "5",
+	}, out)
+
+	err = cfg.Unmarshal(Map(map[string]string{"CACHE_ADDR": "x"}))
+	require.EqualError(t, err, "prefix SESSIONS_: missing required key: field Addr (string) in struct Cache")
+	var ferr *FieldError
+	require.True(t, errors.As(err, &ferr))
+	require.Equal(t, "SESSIONS_ADDR", ferr.Key)
+
+	err = cfg.Unmarshal(Map(nil), CollectErrors())
+	var list ErrorList
+	require.True(t, errors.As(err, &list))
+	require.Len(t, list, 2)
+	require.Equal(t, "CACHE_ADDR", list[0].Key)
+	require.Equal(t, "SESSIONS_ADDR", list[1].Key)
+
+	var only Cache
+	require.NoError(t, Compose(nil, "X_", &only).Unmarshal(Map(map[string]string{"X_ADDR": "a"})))
+	require.Equal(t, "a", only.Addr)
+
+	conflict := Compose(&App{"a"}, "", &App{"b"})
+	_, err = conflict.Marshal()
+	require.EqualError(t, err, "prefix : conflicting values for key NAME")
+}
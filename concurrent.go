@@ -0,0 +1,103 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"sync"
+)
+
+// ConcurrentLookups configures Unmarshal to look up the keys of the tagged
+// fields with up to n concurrent calls of the lookup function configured
+// via Looker, before setting any fields. It's intended for lookup
+// functions that make network requests, such as to a secrets manager,
+// which must then be safe for concurrent use. Keys only used by fields in
+// structs allocated by AllocateNested, fields with the "if", "source", or
+// "from" modifiers, and the variants of oneof fields, are looked up as
+// usual, so that they're only looked up if and where Unmarshal would.
+func ConcurrentLookups(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
+
+// prefetch looks up the keys of the tagged fields reachable from in
+// concurrently, and replaces the config's lookup function with one that
+// returns the results.
+func (c *config) prefetch(in interface{}) {
+	var keys []string
+	seen := make(map[string]struct{})
+	_ = visit(in, "", func(cur *cursor) error {
+		if c.skipped(cur.field.Type) || c.scope(cur.path) == outOfScope {
+			return errSkipField
+		}
+		if c.rekey(cur) != nil {
+			return nil
+		}
+		if _, ok := cur.info.mods["if"]; ok {
+			return errSkipField
+		}
+		key := cur.info.key
+		if key == "" || c.checkKey(key) != nil || c.scope(cur.path) == aboveScope {
+			return nil
+		}
+		if _, ok := isLazy(cur.value); ok {
+			return errSkipField
+		}
+		if _, ok := cur.info.mods["from"]; ok {
+			return nil
+		}
+		if _, ok := cur.info.mods["source"]; ok {
+			return nil
+		}
+		if c.onlyKeys != nil {
+			if _, ok := c.onlyKeys[key]; !ok {
+				return nil
+			}
+		}
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+		if _, ok := cur.info.mods["oneof"]; ok {
+			return errSkipField
+		}
+		return nil
+	})
+
+	type result struct {
+		val *string
+		err error
+	}
+	results := make([]result, len(keys))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				v, err := c.looker(keys[j])
+				results[j] = result{v, err}
+			}
+		}()
+	}
+	for i := range keys {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	fetched := make(map[string]result, len(keys))
+	for i, key := range keys {
+		fetched[key] = results[i]
+	}
+	looker := c.looker
+	c.looker = func(key string) (*string, error) {
+		if r, ok := fetched[key]; ok {
+			return r.val, r.err
+		}
+		return looker(key)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		D string `env:"D"`
+	}
+	type Other struct {
+		F string `env:"F"`
+	}
+	type S struct {
+		A  string `env:"A"`
+		B  string `env:"B"`
+		C  string `env:"C=c-default"`
+		A2 string `env:"A"`
+		In Inner
+		P  *Inner
+		O  *Other
+	}
+
+	env := map[string]string{"A": "a", "B": "b", "D": "d", "F": "f"}
+	var mu sync.Mutex
+	var active, peak int
+	calls := make(map[string]int)
+	slow := func(key string) (*string, error) {
+		mu.Lock()
+		calls[key]++
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		if key == "E" {
+			return nil, errors.New("lookup failed")
+		}
+		return lookupMap(env)(key)
+	}
+
+	var s S
+	err := Unmarshal(&s, Looker(slow), ConcurrentLookups(2), AllocateNested())
+	require.NoError(t, err)
+	require.Equal(t, S{"a", "b", "c-default", "a", Inner{"d"}, &Inner{"d"}, &Other{"f"}}, s)
+	require.Equal(t, 2, peak)
+	// Each key is looked up once; F, only found in an allocated struct,
+	// isn't prefetched.
+	require.Equal(t, map[string]int{"A": 1, "B": 1, "C": 1, "D": 1, "F": 1}, calls)
+
+	type E struct {
+		A string `env:"A"`
+		E string `env:"E"`
+	}
+	var e E
+	err = Unmarshal(&e, Looker(slow), ConcurrentLookups(4))
+	require.EqualError(t, err, "lookup failed: field E (string) in struct E")
+}
+
+func TestConcurrentLookupsSkipped(t *testing.T) {
+	t.Parallel()
+
+	type S3 struct {
+		Bucket string `env:"S3_BUCKET"`
+	}
+	type Disk struct {
+		Path string `env:"DISK_PATH"`
+	}
+	type Storage struct {
+		S3   *S3   `envopt:"variant=s3"`
+		Disk *Disk `envopt:"variant=disk"`
+	}
+	type S struct {
+		A       string  `env:"A"`
+		Feature string  `env:"FEATURE_X" envopt:"if=FEAT"`
+		File    string  `env:"FILE_ONLY" envopt:"source=file"`
+		Storage Storage `env:"STORAGE=disk" envopt:"oneof"`
+	}
+
+	var mu sync.Mutex
+	var envKeys []string
+	env := func(key string) (*string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		envKeys = append(envKeys, key)
+		return lookupMap(map[string]string{"A": "a", "STORAGE": "s3", "S3_BUCKET": "b"})(key)
+	}
+	file := lookupMap(map[string]string{"FILE_ONLY": "f"})
+
+	var s S
+	err := Unmarshal(&s, Looker(env), Layers(Layer{Name: EnvLayer}, Layer{Name: FileLayer, Lookup: file}), ConcurrentLookups(1))
+	require.NoError(t, err)
+	require.Equal(t, "f", s.File)
+	require.Equal(t, &S3{"b"}, s.Storage.S3)
+	require.ElementsMatch(t, []string{"A", "FEAT", "STORAGE", "S3_BUCKET"}, envKeys)
+}
@@ -0,0 +1,33 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// enabled returns whether the field at the cursor should be decoded: true
+// unless it has an "if" modifier whose key isn't found, or whose value
+// isn't true, as parsed by strconv.ParseBool.
+func (config *config) enabled(c *cursor) (bool, error) {
+	key, ok := c.info.mods["if"]
+	if !ok {
+		return true, nil
+	}
+	if key == "" {
+		return false, errors.New("if modifier has no key")
+	}
+	val, _, err := config.lookup(key)
+	if err != nil || val == nil {
+		return false, err
+	}
+	on, err := strconv.ParseBool(*val)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean for key %v in if modifier", key)
+	}
+	return on, nil
+}
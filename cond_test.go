@@ -0,0 +1,55 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfModifier(t *testing.T) {
+	t.Parallel()
+
+	type Billing struct {
+		Key string `env:"BILLING_KEY" envopt:"required"`
+	}
+	type S struct {
+		Enabled bool    `env:"FEATURE_X"`
+		Token   string  `env:"X_TOKEN" envopt:"required,if=FEATURE_X"`
+		Level   string  `env:"X_LEVEL=info" envopt:"if=FEATURE_X"`
+		Billing Billing `envopt:"if=BILLING"`
+	}
+
+	var s S
+	require.NoError(t, Unmarshal(&s, Map(nil)))
+	require.Equal(t, S{}, s)
+
+	missing, err := MissingRequired(&s, lookupMap(map[string]string{"FEATURE_X": "false"}))
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	err = Unmarshal(&s, Map(map[string]string{"FEATURE_X": "true"}))
+	require.EqualError(t, err, "missing required key: field Token (string) in struct S")
+
+	missing, err = MissingRequired(&s, lookupMap(map[string]string{"FEATURE_X": "1", "BILLING": "t"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"X_TOKEN", "BILLING_KEY"}, missing)
+
+	s = S{}
+	err = Unmarshal(&s, Map(map[string]string{"FEATURE_X": "1", "X_TOKEN": "t", "BILLING": "yes"}))
+	require.EqualError(t, err, "invalid boolean for key BILLING in if modifier: field Billing (struct) in struct S")
+
+	s = S{}
+	err = Unmarshal(&s, Map(map[string]string{"FEATURE_X": "1", "X_TOKEN": "t", "BILLING": "true", "BILLING_KEY": "k"}))
+	require.NoError(t, err)
+	require.Equal(t, S{true, "t", "info", Billing{"k"}}, s)
+
+	type Bad struct {
+		N int `env:"N" envopt:"if"`
+	}
+	require.EqualError(t, Unmarshal(&Bad{}, Map(nil)), "if modifier has no key: field N (int) in struct Bad")
+	require.Error(t, Check(&Bad{}))
+}
@@ -0,0 +1,169 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvTagName is the tag giving the column of a field of a struct set by
+// the csv modifier.
+const csvTagName = "envcsv"
+
+// A csvColumn is the column of a row that sets a struct field.
+type csvColumn struct {
+	column int
+	field  int
+}
+
+// csvColumns returns the columns of the exported fields of struct type t,
+// in field order, and the number of columns in a row for t. A field's
+// column is given by its envcsv tag, or is its position among the
+// exported fields; fields tagged "-" are omitted, but their positions are
+// still counted, so that a row may have a column for them.
+func csvColumns(t reflect.Type) ([]csvColumn, int, error) {
+	var cols []csvColumn
+	names := make(map[int]string)
+	pos := 0
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		col := pos
+		pos++
+		if tag, ok := f.Tag.Lookup(csvTagName); ok {
+			if tag == "-" {
+				continue
+			}
+			n, err := strconv.Atoi(tag)
+			if err != nil || n < 0 {
+				return nil, 0, fmt.Errorf("invalid column %q for field %v", tag, f.Name)
+			}
+			col = n
+		}
+		if prev, ok := names[col]; ok {
+			return nil, 0, fmt.Errorf("column %d used by fields %v and %v", col, prev, f.Name)
+		}
+		names[col] = f.Name
+		cols = append(cols, csvColumn{col, i})
+	}
+	width := pos
+	for _, col := range cols {
+		if col.column >= width {
+			width = col.column + 1
+		}
+	}
+	return cols, width, nil
+}
+
+// csvStruct returns value, a struct or a pointer to one, as a struct,
+// allocating it if it's a nil pointer and alloc is true.
+func csvStruct(value reflect.Value, alloc bool) (reflect.Value, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if !alloc {
+				return reflect.Value{}, nil
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("csv modifier requires a struct field")
+	}
+	return value, nil
+}
+
+// setCSV sets the fields of the struct value from the columns of str, a
+// row of comma separated values, quoted as by encoding/csv. Each column is
+// set as setValue would set a field of its field's type. A row with more
+// columns than the struct has is an error. The fields are only set if
+// every column is valid.
+func setCSV(cfg *config, value reflect.Value, key, str string) error {
+	t := value.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return errors.New("csv modifier requires a struct field")
+	}
+	cols, width, err := csvColumns(t)
+	if err != nil {
+		return err
+	}
+	r := csv.NewReader(strings.NewReader(str))
+	r.FieldsPerRecord = -1
+	row, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("invalid csv row: %w", err)
+	}
+	need := 0
+	for _, col := range cols {
+		if col.column >= need {
+			need = col.column + 1
+		}
+	}
+	if len(row) < need {
+		return fmt.Errorf("row has %d columns, need %d", len(row), need)
+	}
+	if len(row) > width {
+		return fmt.Errorf("row has %d columns, want at most %d", len(row), width)
+	}
+
+	// Set the fields of a new struct, and copy them once all are set.
+	parsed := reflect.New(t).Elem()
+	for _, col := range cols {
+		f := parsed.Field(col.field)
+		if err := setValue(cfg, f, key, row[col.column], nil); err != nil {
+			return fmt.Errorf("column %d (%v): %w", col.column, t.Field(col.field).Name, err)
+		}
+	}
+	value, err = csvStruct(value, true)
+	if err != nil {
+		return err
+	}
+	for _, col := range cols {
+		value.Field(col.field).Set(parsed.Field(col.field))
+	}
+	return nil
+}
+
+// formatCSV formats the fields of the struct value as a row of comma
+// separated values, each formatted by formatValue. Columns that no field
+// sets are empty.
+func formatCSV(value reflect.Value) (string, error) {
+	value, err := csvStruct(value, false)
+	if err != nil || !value.IsValid() {
+		return "", err
+	}
+	cols, _, err := csvColumns(value.Type())
+	if err != nil {
+		return "", err
+	}
+	var row []string
+	for _, col := range cols {
+		s, err := formatValue(value.Field(col.field))
+		if err != nil {
+			return "", err
+		}
+		for len(row) <= col.column {
+			row = append(row, "")
+		}
+		row[col.column] = s
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(row); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n"), w.Error()
+}
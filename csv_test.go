@@ -0,0 +1,86 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVModifier(t *testing.T) {
+	t.Parallel()
+
+	type Endpoint struct {
+		Host string
+		Port int
+		TLS  bool
+	}
+	type Indexed struct {
+		Name  string `envcsv:"2"`
+		ID    int    `envcsv:"0"`
+		Notes string `envcsv:"-"`
+	}
+	type S struct {
+		Endpoint Endpoint  `env:"ENDPOINT=localhost,80,false" envopt:"csv"`
+		Backup   *Endpoint `env:"BACKUP" envopt:"csv"`
+		Indexed  Indexed   `env:"INDEXED" envopt:"csv"`
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{
+		"BACKUP":  `"backup.example.com",443,true`,
+		"INDEXED": `7,ignored,"Smith, J"`,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, Endpoint{"localhost", 80, false}, s.Endpoint)
+	require.Equal(t, &Endpoint{"backup.example.com", 443, true}, s.Backup)
+	require.Equal(t, Indexed{Name: "Smith, J", ID: 7}, s.Indexed)
+
+	env, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"ENDPOINT": "localhost,80,false",
+		"BACKUP":   "backup.example.com,443,true",
+		"INDEXED":  `7,,"Smith, J"`,
+	}, env)
+
+	for row, msg := range map[string]string{
+		"host,80":        "row has 2 columns, need 3",
+		"host,80,true,x": "row has 4 columns, want at most 3",
+		"host,http,no":   `column 1 (Port): strconv.ParseInt: parsing "http": invalid syntax`,
+		"other,81,no":    `column 2 (TLS): strconv.ParseBool: parsing "no": invalid syntax`,
+		`"host,80,true`:  `invalid csv row: parse error on line 1, column 14: extraneous or missing " in quoted-field`,
+	} {
+		err = Unmarshal(&s, Map(map[string]string{"ENDPOINT": row}))
+		require.EqualError(t, err, msg+": field Endpoint (struct) in struct S", row)
+	}
+	require.Equal(t, Endpoint{"localhost", 80, false}, s.Endpoint)
+
+	// A row may have a column for a field tagged "-".
+	err = Unmarshal(&s, Map(map[string]string{"INDEXED": "8,x,Jones,y"}))
+	require.EqualError(t, err, "row has 4 columns, want at most 3: field Indexed (struct) in struct S")
+	err = Unmarshal(&s, Map(map[string]string{"INDEXED": "8,x,Jones"}))
+	require.NoError(t, err)
+	require.Equal(t, Indexed{Name: "Jones", ID: 8}, s.Indexed)
+
+	// A nil pointer is left nil if the row is invalid.
+	s.Backup = nil
+	err = Unmarshal(&s, Map(map[string]string{"BACKUP": "h,x,true"}))
+	require.Error(t, err)
+	require.Nil(t, s.Backup)
+
+	type Dup struct {
+		A string
+		B string `envcsv:"0"`
+	}
+	type Bad struct {
+		Dup Dup `env:"DUP" envopt:"csv"`
+	}
+	err = Unmarshal(&Bad{}, Map(map[string]string{"DUP": "a,b"}))
+	require.EqualError(t, err, "column 0 used by fields A and B: field Dup (struct) in struct Bad")
+	require.EqualError(t, Check(&Bad{}), "1 error\ntop level (1):\n"+
+		"    column 0 used by fields A and B: field Dup (struct) in struct Bad")
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+// A Snapshotter provides a decoded struct, and the Result of decoding it.
+// Watcher and Value are Snapshotters.
+type Snapshotter interface {
+	Snapshot() (cfg interface{}, result *Result)
+}
+
+// The SnapshotFunc type is an adapter to allow the use of ordinary
+// functions as Snapshotters.
+type SnapshotFunc func() (interface{}, *Result)
+
+// Snapshot returns f().
+func (f SnapshotFunc) Snapshot() (interface{}, *Result) {
+	return f()
+}
+
+// A debugField is the JSON form of a tagged field used by DebugHandler.
+type debugField struct {
+	Path   string      `json:"path"`
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source,omitempty"`
+	Secret bool        `json:"secret,omitempty"`
+}
+
+// DebugHandler returns an http.Handler that responds with a JSON list of
+// the tagged fields of the struct provided by s, for mounting at a path
+// such as /debug/config. Each field is annotated with its key, and, if the
+// Result is known, where its value came from. The values of secret fields
+// are redacted by the Redactor given in options, if any.
+func DebugHandler(s Snapshotter, options ...Option) http.Handler {
+	config := newConfig(options)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg, result := s.Snapshot()
+		fields := snapshotFields(config, cfg, result)
+
+		b, err := json.MarshalIndent(struct {
+			Fields []debugField `json:"fields"`
+		}{fields}, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(append(b, '\n'))
+	})
+}
+
+// snapshotFields returns the tagged fields of the struct pointer cfg, with
+// sources from result, which may be nil.
+func snapshotFields(config *config, cfg interface{}, result *Result) []debugField {
+	values, paths := taggedValues(reflect.ValueOf(cfg))
+	fields := make([]debugField, 0, len(paths))
+	for _, path := range paths {
+		v := values[path]
+		f := debugField{Path: path, Key: v.key, Value: v.value, Secret: v.secret}
+		if f.Secret {
+			f.Value = config.redactValue(v.key, v.value)
+		}
+		if result != nil {
+			if r := result.Field(path); r != nil {
+				f.Source = r.Source.String()
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
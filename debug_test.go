@@ -0,0 +1,49 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host     string `env:"HOST=localhost"`
+		Port     int    `env:"PORT"`
+		Password string `env:"PASSWORD" envopt:"secret"`
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	env.set("PASSWORD", "hunter2")
+	v, err := NewValue[S](env.looker())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	DebugHandler(v).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config", nil))
+	require.Equal(t, 200, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"fields": [
+		{"path": "Host", "key": "HOST", "value": "localhost", "source": "default"},
+		{"path": "Port", "key": "PORT", "value": 80, "source": "env"},
+		{"path": "Password", "key": "PASSWORD", "value": "[REDACTED]", "source": "env", "secret": true}
+	]}`, rec.Body.String())
+	require.NotContains(t, rec.Body.String(), "hunter2")
+
+	s := S{Host: "h1"}
+	rec = httptest.NewRecorder()
+	static := SnapshotFunc(func() (interface{}, *Result) { return &s, nil })
+	DebugHandler(static).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config", nil))
+	require.JSONEq(t, `{"fields": [
+		{"path": "Host", "key": "HOST", "value": "h1"},
+		{"path": "Port", "key": "PORT", "value": 0},
+		{"path": "Password", "key": "PASSWORD", "value": "[REDACTED]", "secret": true}
+	]}`, rec.Body.String())
+}
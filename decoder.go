@@ -0,0 +1,140 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Decoder sets the tagged fields of structs using a fixed set of options.
+// It's intended for programs that decode many structs, such as per-request
+// configuration, with the same options. NewDecoder applies the options
+// once, and the first decode of each struct type builds a plan of its
+// fields' keys, which is reused by later decodes of the type, including
+// those by other Decoders with the same KeyVars; the functions configured
+// by KeyFunc are only called while building a plan. The scratch space used
+// while decoding is reused between calls.
+//
+// A Decoder is safe for concurrent use by multiple goroutines, provided
+// the functions configured by its options are, and Decode takes no locks
+// of its own. A Decoder configured with Record shouldn't be used
+// concurrently, as each call records into the same Result. It uses the
+// options set by SetDefaultOptions at the time NewDecoder was called,
+// followed by the options passed to it.
+type Decoder struct {
+	config *config
+	// plans holds the Decoder's plans, by struct type and the fingerprint
+	// of its options. It's sharedPlans, unless the Decoder is configured
+	// with KeyFunc, whose functions can't be compared to other Decoders'.
+	plans       *sync.Map
+	fingerprint string
+}
+
+// NewDecoder returns a Decoder using the given options.
+func NewDecoder(options ...Option) *Decoder {
+	d := &Decoder{config: newConfig(options), plans: &sharedPlans}
+	d.fingerprint = d.config.fingerprint()
+	if len(d.config.keyFuncs) > 0 {
+		d.plans = new(sync.Map)
+	}
+	return d
+}
+
+// Decode sets the tagged fields of in, as by Unmarshal.
+func (d *Decoder) Decode(in interface{}) error {
+	if !isStructPtr(in) {
+		return errors.New("passed non-pointer or nil pointer")
+	}
+	config := d.config.fork()
+	config.plan = d.plan(reflect.TypeOf(in).Elem())
+	return unmarshal(config, in)
+}
+
+// A plan holds the fields reachable from a struct type that have keys,
+// by path, with their keys rewritten as configured by the options it was
+// built with. It's never modified after it's stored, so it's read without
+// locking.
+type plan struct {
+	fields map[string]planField
+}
+
+// A planField holds a field's info, as cached by cachedStruct, and the
+// info with its key rewritten, or the error from rewriting it.
+type planField struct {
+	tagged *fieldInfo
+	info   *fieldInfo
+	err    error
+}
+
+// A planKey identifies a plan by its struct type and the fingerprint of
+// the options it was built with.
+type planKey struct {
+	typ         reflect.Type
+	fingerprint string
+}
+
+// sharedPlans maps planKeys to their *plan, for every Decoder that isn't
+// configured with KeyFunc. Once a plan is stored, loading it doesn't take
+// a lock.
+var sharedPlans sync.Map
+
+// plan returns the plan for the struct type t, building it the first time
+// t is decoded with options of the Decoder's fingerprint. As for SchemaOf,
+// every struct pointer is treated as allocated.
+func (d *Decoder) plan(t reflect.Type) *plan {
+	k := planKey{t, d.fingerprint}
+	if p, ok := d.plans.Load(k); ok {
+		return p.(*plan)
+	}
+	p := &plan{fields: make(map[string]planField)}
+	full := reflect.New(t)
+	allocateAll(full.Elem(), make(map[reflect.Type]bool))
+	_ = visit(full.Interface(), "", func(c *cursor) error {
+		if c.info.key == "" {
+			return nil
+		}
+		tagged := c.info
+		err := d.config.rekey(c)
+		p.fields[c.path] = planField{tagged, c.info, err}
+		return nil
+	})
+	actual, _ := d.plans.LoadOrStore(k, p)
+	return actual.(*plan)
+}
+
+// fingerprint returns a string identifying the options that plans depend
+// on, other than KeyFunc: the values supplied by KeyVars.
+func (c *config) fingerprint() string {
+	names := make([]string, 0, len(c.keyVars))
+	for name := range c.keyVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%q=%q;", name, c.keyVars[name])
+	}
+	return b.String()
+}
+
+// fork returns a copy of c for a single decode, without the state that
+// the decodes made with c have changed.
+func (c *config) fork() *config {
+	f := *c
+	f.lazyMu = new(sync.Mutex)
+	f.errs, f.traceLines = nil, nil
+	if c.relaxed != nil {
+		f.relaxed = make(map[string][]string)
+	}
+	if c.used != nil {
+		f.used = make(map[string]struct{})
+	}
+	return &f
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		B int `env:"B"`
+	}
+	type S struct {
+		A  string `env:"A=a-default"`
+		In Inner
+		P  *S
+	}
+
+	d := NewDecoder(Map(map[string]string{"B": "1"}))
+	for i := 0; i < 3; i++ {
+		var s S
+		s.P = &s
+		require.NoError(t, d.Decode(&s))
+		require.Equal(t, "a-default", s.A)
+		require.Equal(t, 1, s.In.B)
+	}
+
+	// A failed decode doesn't affect later ones.
+	bad := NewDecoder(Map(map[string]string{"B": "x"}))
+	var s S
+	require.Error(t, bad.Decode(&s))
+	s = S{}
+	require.NoError(t, d.Decode(&s))
+	require.Equal(t, 1, s.In.B)
+}
+
+func TestDecoderConcurrent(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		A string `env:"A"`
+		B int    `env:"B=2"`
+		P *S
+	}
+
+	d := NewDecoder(Map(map[string]string{"A": "a"}), Relaxed())
+	results := make(chan S, 8*100)
+	errs := make(chan error, 8*100)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var s S
+				s.P = &s
+				if err := d.Decode(&s); err != nil {
+					errs <- err
+					continue
+				}
+				results <- S{A: s.A, B: s.B}
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for s := range results {
+		require.Equal(t, S{A: "a", B: 2}, s)
+	}
+}
+
+func TestDecoderPlan(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		B int `env:"B_${N}"`
+	}
+	type S struct {
+		A  string `env:"A"`
+		In *Inner
+	}
+	typ := reflect.TypeOf(S{})
+	env := Map(map[string]string{"A": "a", "B_1": "1", "B_2": "2"})
+
+	d1 := NewDecoder(env, KeyVars(map[string]string{"N": "1"}), AllocateNested())
+	d2 := NewDecoder(env, KeyVars(map[string]string{"N": "1"}))
+	d3 := NewDecoder(env, KeyVars(map[string]string{"N": "2"}), AllocateNested())
+	require.Same(t, d1.plan(typ), d2.plan(typ))
+	require.NotSame(t, d1.plan(typ), d3.plan(typ))
+
+	var s S
+	require.NoError(t, d1.Decode(&s))
+	require.Equal(t, S{A: "a", In: &Inner{B: 1}}, s)
+	s = S{}
+	require.NoError(t, d3.Decode(&s))
+	require.Equal(t, 2, s.In.B)
+
+	// KeyFunc is only called while building the plan.
+	var calls int32
+	d := NewDecoder(env, AllocateNested(), KeyVars(map[string]string{"N": "2"}), KeyFunc(func(key string, f FieldInfo) string {
+		atomic.AddInt32(&calls, 1)
+		return key
+	}))
+	for i := 0; i < 3; i++ {
+		s = S{}
+		require.NoError(t, d.Decode(&s))
+		require.Equal(t, S{A: "a", In: &Inner{B: 2}}, s)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.NotSame(t, d.plan(typ), NewDecoder(env, KeyFunc(func(key string, f FieldInfo) string { return key })).plan(typ))
+
+	// Keys that can't be rewritten fail every decode.
+	bad := NewDecoder(env)
+	require.EqualError(t, bad.Decode(&S{In: &Inner{}}), "no value for N in key: field B (int) in struct Inner")
+	require.Error(t, bad.Decode(&S{In: &Inner{}}))
+}
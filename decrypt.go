@@ -0,0 +1,56 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"context"
+	"errors"
+)
+
+// A Decrypter decrypts the values of fields with the "encrypted" modifier.
+type Decrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// A DecrypterFunc is a function that's a Decrypter.
+type DecrypterFunc func(ctx context.Context, ciphertext []byte) ([]byte, error)
+
+// Decrypt returns f(ctx, ciphertext).
+func (f DecrypterFunc) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	return f(ctx, ciphertext)
+}
+
+// DecryptWith is like Decrypt, configuring Unmarshal to decrypt values
+// with fn. It's intended for calls to a cloud key management service,
+// such as AWS KMS, Google Cloud KMS, or Azure Key Vault; fn is passed the
+// value as found, so it should decode values that are text encodings of
+// binary ciphertext, such as base64. The context is that given to
+// UnmarshalContext.
+func DecryptWith(fn func(ctx context.Context, ciphertext []byte) ([]byte, error)) Option {
+	return Decrypt(DecrypterFunc(fn))
+}
+
+// Decrypt configures Unmarshal to decrypt the values found for fields with
+// the "encrypted" modifier in their envopt tag using d, before setting the
+// fields from the plaintext. Tag defaults are not decrypted. Unmarshal
+// returns an error for encrypted fields whose keys are found if no
+// Decrypter is configured.
+func Decrypt(d Decrypter) Option {
+	return func(c *config) {
+		c.decrypter = d
+	}
+}
+
+// decrypt returns the plaintext of the value s of an encrypted field.
+func (c *config) decrypt(s string) (string, error) {
+	if c.decrypter == nil {
+		return "", errors.New("no decrypter for encrypted value")
+	}
+	b, err := c.decrypter.Decrypt(c.ctx, []byte(s))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
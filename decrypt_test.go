@@ -0,0 +1,74 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rot13 is a Decrypter for tests.
+type rot13 struct{}
+
+func (rot13) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, errors.New("empty ciphertext")
+	}
+	b := make([]byte, len(ciphertext))
+	for i, c := range ciphertext {
+		switch {
+		case c >= 'a' && c <= 'z':
+			c = 'a' + (c-'a'+13)%26
+		case c >= 'A' && c <= 'Z':
+			c = 'A' + (c-'A'+13)%26
+		}
+		b[i] = c
+	}
+	return b, nil
+}
+
+func TestDecrypt(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password string `env:"PASSWORD" envopt:"encrypted"`
+		Name     string `env:"NAME=abc" envopt:"encrypted"`
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"PASSWORD": "uhagre"}), Decrypt(rot13{}))
+	require.NoError(t, err)
+	require.Equal(t, S{"hunter", "abc"}, s)
+
+	err = Unmarshal(&s, Map(map[string]string{"PASSWORD": ""}), Decrypt(rot13{}))
+	require.EqualError(t, err, "empty ciphertext: field Password (string) in struct S")
+}
+
+func TestDecryptSecret(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password string `env:"PASSWORD" envopt:"encrypted"`
+		Pin      int    `env:"PIN" envopt:"encrypted"`
+	}
+
+	var s S
+	var trace strings.Builder
+	env := Map(map[string]string{"PASSWORD": "uhagre", "PIN": "frpergcva"})
+	err := Unmarshal(&s, env, Decrypt(rot13{}), Trace(&trace), ErrorValues())
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secretpin")
+	require.Equal(t, "hunter", s.Password)
+	require.NotContains(t, trace.String(), "hunter")
+	require.NotContains(t, trace.String(), "secretpin")
+
+	m, err := Marshal(&s, IncludeSecrets())
+	require.NoError(t, err)
+	require.Empty(t, m)
+}
@@ -1,9 +1,12 @@
 package fromenv
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -44,25 +47,53 @@ func Example_inner() {
 }
 
 func ExampleSetFunc() {
-	durSetter := func(t *time.Duration, s string) error {
-		x, err := time.ParseDuration(s)
-		*t = x
-		return err
+	listSetter := func(l *[]string, s string) error {
+		*l = strings.Split(s, ",")
+		return nil
 	}
 
-	urlSetter := func(u *url.URL, s string) error {
-		x, err := url.Parse(s)
-		*u = *x
-		return err
+	type config struct {
+		Hosts []string `env:"HOSTS=a.example.com,b.example.com"`
 	}
 
+	var c config
+	_ = Unmarshal(&c, SetFunc(listSetter))
+	fmt.Println(len(c.Hosts), c.Hosts[1])
+	// Output: 2 b.example.com
+}
+
+func ExampleCommonTypes() {
 	type config struct {
 		Timeout time.Duration `env:"GAP=1000ms"`
 		Server  *url.URL      `env:"PLACE=http://www.github.com"`
 	}
 
 	var c config
-	_ = Unmarshal(&c, SetFunc(durSetter), SetFunc(urlSetter))
+	_ = Unmarshal(&c, CommonTypes())
 	fmt.Println(c.Timeout, c.Server.Hostname())
 	// Output: 1s www.github.com
 }
+
+func ExampleDecryptWith() {
+	type config struct {
+		Password string `env:"DB_PASSWORD" envopt:"encrypted,secret"`
+	}
+
+	// kmsDecrypt stands in for a call to a key management service.
+	kmsDecrypt := func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+		return []byte(strings.TrimPrefix(string(ciphertext), "kms:")), nil
+	}
+	decrypt := func(ctx context.Context, value []byte) ([]byte, error) {
+		ciphertext, err := base64.StdEncoding.DecodeString(string(value))
+		if err != nil {
+			return nil, err
+		}
+		return kmsDecrypt(ctx, ciphertext)
+	}
+
+	var c config
+	env := Map(map[string]string{"DB_PASSWORD": base64.StdEncoding.EncodeToString([]byte("kms:hunter2"))})
+	_ = Unmarshal(&c, env, DecryptWith(decrypt))
+	fmt.Println(c.Password)
+	// Output: hunter2
+}
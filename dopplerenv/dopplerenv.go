@@ -0,0 +1,104 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package dopplerenv looks up fromenv keys in the secrets of a Doppler
+// (https://doppler.com) config.
+//
+//	c := &dopplerenv.Client{Token: os.Getenv("DOPPLER_TOKEN")}
+//	opt, err := c.Option(ctx)
+//	...
+//	err = fromenv.Unmarshal(&cfg, opt)
+package dopplerenv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// DefaultBaseURL is the URL of Doppler's API.
+const DefaultBaseURL = "https://api.doppler.com"
+
+// A Client downloads the secrets of a Doppler config.
+type Client struct {
+	// Token is a Doppler service token, which is scoped to a single
+	// config, or another token with access to Project and Config.
+	Token string
+	// Project and Config name the config to download, and are only
+	// needed for tokens not scoped to a config.
+	Project, Config string
+	// BaseURL is the URL of Doppler's API; if empty, DefaultBaseURL.
+	BaseURL string
+	// HTTPClient makes requests; if nil, http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Secrets downloads the config's secrets, keyed by name.
+func (c *Client) Secrets(ctx context.Context) (map[string]string, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{"format": {"json"}}
+	if c.Project != "" {
+		q.Set("project", c.Project)
+	}
+	if c.Config != "" {
+		q.Set("config", c.Config)
+	}
+	u := strings.TrimSuffix(base, "/") + "/v3/configs/config/secrets/download?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Messages []string `json:"messages"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		if len(body.Messages) > 0 {
+			return nil, fmt.Errorf("doppler: %s: %s", resp.Status, strings.Join(body.Messages, "; "))
+		}
+		return nil, fmt.Errorf("doppler: %s", resp.Status)
+	}
+
+	var secrets map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&secrets); err != nil {
+		return nil, fmt.Errorf("doppler: %v", err)
+	}
+	if secrets == nil {
+		return nil, errors.New("doppler: empty response")
+	}
+	return secrets, nil
+}
+
+// Option downloads the config's secrets, and returns an Option configuring
+// fromenv to look up keys in them, as by fromenv.Map.
+func (c *Client) Option(ctx context.Context) (fromenv.Option, error) {
+	secrets, err := c.Secrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromenv.Map(secrets), nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package dopplerenv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer dp.st.good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"messages":["Invalid Service token"],"success":false}`))
+			return
+		}
+		if r.URL.Path != "/v3/configs/config/secrets/download" || r.URL.Query().Get("format") != "json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("project") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"DB_URL":"postgres://db","PORT":"8080"}`))
+	}))
+	defer srv.Close()
+
+	type S struct {
+		DB   string `env:"DB_URL" envopt:"secret"`
+		Port int    `env:"PORT"`
+		Host string `env:"HOST=localhost"`
+	}
+
+	c := &Client{Token: "dp.st.good", BaseURL: srv.URL}
+	opt, err := c.Option(context.Background())
+	require.NoError(t, err)
+	var s S
+	require.NoError(t, fromenv.Unmarshal(&s, opt))
+	require.Equal(t, S{"postgres://db", 8080, "localhost"}, s)
+
+	bad := &Client{Token: "dp.st.bad", BaseURL: srv.URL}
+	_, err = bad.Secrets(context.Background())
+	require.EqualError(t, err, "doppler: 401 Unauthorized: Invalid Service token")
+
+	missing := &Client{Token: "dp.st.good", Project: "missing", Config: "dev", BaseURL: srv.URL}
+	_, err = missing.Secrets(context.Background())
+	require.EqualError(t, err, "doppler: 404 Not Found")
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Drift decodes two new structs of the type pointed to by in, one with
+// lookup function from and one with to, and otherwise the given options.
+// It returns the tagged fields whose effective values differ, as by Diff,
+// with Old holding the value found via from. It's intended for comparing
+// environments, such as staging and production.
+//
+// The values of secret fields are compared by hash: in the returned
+// changes, they are replaced by an "hmac-sha256:" prefixed string holding
+// the start of an HMAC of the value as formatted by Marshal. The HMAC's key
+// is random for each call, so that the hashes can't be reversed by hashing
+// guesses, and are only comparable within the returned changes.
+func Drift(in interface{}, from, to LookupEnvFunc, options ...Option) ([]FieldChange, error) {
+	if !isStructPtr(in) {
+		return nil, errors.New("passed non-pointer or nil pointer")
+	}
+	t := reflect.TypeOf(in).Elem()
+
+	decoded := make([]reflect.Value, 2)
+	for i, f := range []LookupEnvFunc{from, to} {
+		decoded[i] = reflect.New(t)
+		opts := append(append([]Option(nil), options...), Looker(f))
+		if err := Unmarshal(decoded[i].Interface(), opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	fromValues, fromPaths := taggedValues(decoded[0])
+	toValues, toPaths := taggedValues(decoded[1])
+	for _, values := range []map[string]taggedValue{fromValues, toValues} {
+		for path, v := range values {
+			if v.secret {
+				v.value = hmacValue(key, v.value)
+				values[path] = v
+			}
+		}
+	}
+
+	changes := diffValues(newConfig(options), fromValues, fromPaths, toValues, toPaths)
+	for i, c := range changes {
+		if !c.Secret {
+			continue
+		}
+		if v, ok := fromValues[c.Path]; ok {
+			changes[i].Old = v.value
+		}
+		if v, ok := toValues[c.Path]; ok {
+			changes[i].New = v.value
+		}
+	}
+	return changes, nil
+}
+
+// hmacValue returns the HMAC, with key, of v as formatted by formatValue,
+// or of its default format if formatValue can't format it.
+func hmacValue(key []byte, v interface{}) string {
+	s, err := formatValue(reflect.ValueOf(v))
+	if err != nil {
+		s = fmt.Sprint(v)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// hashValue returns the hash of the default format of v.
+func hashValue(v interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
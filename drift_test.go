@@ -0,0 +1,68 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host     string `env:"HOST=localhost"`
+		Port     int    `env:"PORT=80"`
+		Password string `env:"PASSWORD" envopt:"secret"`
+		Token    string `env:"TOKEN" envopt:"secret"`
+	}
+
+	staging := lookupMap(map[string]string{"HOST": "staging", "PASSWORD": "p1", "TOKEN": "t"})
+	production := lookupMap(map[string]string{"HOST": "production", "PASSWORD": "p2", "TOKEN": "t"})
+
+	changes, err := Drift(&S{}, staging, production)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, FieldChange{Path: "Host", Key: "HOST", Old: "staging", New: "production"}, changes[0])
+	require.Equal(t, "Password", changes[1].Path)
+	require.True(t, changes[1].Secret)
+	require.Regexp(t, "^hmac-sha256:[0-9a-f]{16}$", changes[1].Old)
+	require.Regexp(t, "^hmac-sha256:[0-9a-f]{16}$", changes[1].New)
+	require.NotEqual(t, changes[1].Old, changes[1].New)
+
+	// Hashes are keyed for each call.
+	again, err := Drift(&S{}, staging, production)
+	require.NoError(t, err)
+	require.NotEqual(t, changes[1].Old, again[1].Old)
+
+	// Secreters are compared by their formatted values, not placeholders.
+	type P struct {
+		P driftSecret `env:"P"`
+	}
+	changes, err = Drift(&P{}, lookupMap(map[string]string{"P": "one"}), lookupMap(map[string]string{"P": "two"}))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.True(t, changes[0].Secret)
+
+	changes, err = Drift(&S{}, staging, staging)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	bad := lookupMap(map[string]string{"PORT": "x"})
+	_, err = Drift(&S{}, staging, bad)
+	require.EqualError(t, err, `strconv.ParseInt: parsing "x": invalid syntax: field Port (int) in struct S`)
+
+	_, err = Drift(S{}, staging, bad)
+	require.EqualError(t, err, "passed non-pointer or nil pointer")
+}
+
+// driftSecret is a Secreter whose String method returns a placeholder.
+type driftSecret struct{ s string }
+
+func (d *driftSecret) Set(s string) error         { d.s = s; return nil }
+func (d driftSecret) String() string              { return "[REDACTED]" }
+func (d driftSecret) MarshalEnv() (string, error) { return d.s, nil }
+func (d driftSecret) SecretEnv() bool             { return true }
@@ -0,0 +1,157 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A DumpFormat is an output format for Dump.
+type DumpFormat int
+
+const (
+	// DumpJSON formats each field as a JSON object holding its key,
+	// value, and source.
+	DumpJSON DumpFormat = iota
+	// DumpYAML formats each field as a YAML value, with a comment holding
+	// its key and source.
+	DumpYAML
+)
+
+// Dump renders the tagged fields of the struct pointed to by in, such as
+// for attaching to a support ticket. Fields are nested as in the struct,
+// and annotated with their keys and, if result isn't nil, where their
+// values came from: "default", "none", or the name of the layer that
+// supplied them, such as "env" or "file". Values are formatted as by
+// Marshal, and the values of secret fields are redacted by the Redactor
+// given in options, if any.
+func Dump(in interface{}, result *Result, format DumpFormat, options ...Option) ([]byte, error) {
+	if !isStructPtr(in) {
+		return nil, errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(options)
+
+	root := &dumpNode{}
+	values, paths := taggedValues(reflect.ValueOf(in))
+	for _, path := range paths {
+		v := values[path]
+		f := &dumpField{Key: v.key}
+		if s, err := formatValue(reflect.ValueOf(v.value)); err == nil {
+			f.Value = s
+		} else {
+			f.Value = fmt.Sprint(v.value)
+		}
+		if v.secret {
+			f.Value = config.redact(v.key, f.Value)
+		}
+		if result != nil {
+			if r := result.Field(path); r != nil {
+				f.Key, f.Source = r.Key, r.Source.String()
+				if r.Source == SourceEnv && r.Layer != "" {
+					f.Source = r.Layer
+				}
+			}
+		}
+		root.add(strings.Split(path, "."), f)
+	}
+
+	switch format {
+	case DumpJSON:
+		var buf bytes.Buffer
+		if err := root.writeJSON(&buf); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	case DumpYAML:
+		return yaml.Marshal(root.yamlNode())
+	}
+	return nil, fmt.Errorf("unknown dump format %d", format)
+}
+
+// A dumpField is a field's entry in the output of Dump.
+type dumpField struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source,omitempty"`
+}
+
+// A dumpNode is a struct, or a field, in the output of Dump.
+type dumpNode struct {
+	names    []string
+	children map[string]*dumpNode
+	field    *dumpField
+}
+
+// add adds the field at path within n.
+func (n *dumpNode) add(path []string, f *dumpField) {
+	if len(path) == 0 {
+		n.field = f
+		return
+	}
+	if n.children == nil {
+		n.children = make(map[string]*dumpNode)
+	}
+	child, ok := n.children[path[0]]
+	if !ok {
+		child = &dumpNode{}
+		n.children[path[0]] = child
+		n.names = append(n.names, path[0])
+	}
+	child.add(path[1:], f)
+}
+
+// writeJSON writes n as compact JSON, with fields in struct order.
+func (n *dumpNode) writeJSON(buf *bytes.Buffer) error {
+	if n.field != nil {
+		b, err := json.Marshal(n.field)
+		buf.Write(b)
+		return err
+	}
+	buf.WriteByte('{')
+	for i, name := range n.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		if err := n.children[name].writeJSON(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// yamlNode returns n as a YAML node, with fields in struct order.
+func (n *dumpNode) yamlNode() *yaml.Node {
+	if n.field != nil {
+		comment := n.field.Key
+		if n.field.Source != "" {
+			comment += ", " + n.field.Source
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.field.Value, LineComment: comment}
+	}
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range n.names {
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, n.children[name].yamlNode())
+	}
+	return m
+}
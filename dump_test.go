@@ -0,0 +1,80 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	t.Parallel()
+
+	type Redis struct {
+		Addr    string        `env:"REDIS_ADDR=localhost:6379"`
+		Timeout time.Duration `env:"REDIS_TIMEOUT=1s"`
+	}
+	type S struct {
+		Port     int    `env:"PORT"`
+		Password string `env:"PASSWORD" envopt:"secret"`
+		Name     string `env:"NAME"`
+		Redis    Redis
+	}
+
+	file := lookupMap(map[string]string{"PASSWORD": "hunter2"})
+	var s S
+	var r Result
+	err := Unmarshal(&s, Map(map[string]string{"PORT": "8080"}), Record(&r), CommonTypes(),
+		Layers(Layer{Name: EnvLayer}, Layer{FileLayer, file}))
+	require.NoError(t, err)
+
+	out, err := Dump(&s, &r, DumpJSON)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "Port": {
+    "key": "PORT",
+    "value": "8080",
+    "source": "env"
+  },
+  "Password": {
+    "key": "PASSWORD",
+    "value": "[REDACTED]",
+    "source": "file"
+  },
+  "Name": {
+    "key": "NAME",
+    "value": "",
+    "source": "none"
+  },
+  "Redis": {
+    "Addr": {
+      "key": "REDIS_ADDR",
+      "value": "localhost:6379",
+      "source": "default"
+    },
+    "Timeout": {
+      "key": "REDIS_TIMEOUT",
+      "value": "1s",
+      "source": "default"
+    }
+  }
+}
+`, string(out))
+
+	out, err = Dump(&s, nil, DumpYAML, Redaction(ShowLast(2)))
+	require.NoError(t, err)
+	require.Equal(t, `Port: "8080" # PORT
+Password: '*****r2' # PASSWORD
+Name: "" # NAME
+Redis:
+    Addr: localhost:6379 # REDIS_ADDR
+    Timeout: 1s # REDIS_TIMEOUT
+`, string(out))
+
+	_, err = Dump(s, nil, DumpJSON)
+	require.Error(t, err)
+}
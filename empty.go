@@ -0,0 +1,47 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import "errors"
+
+// An EmptyPolicy determines how Unmarshal treats keys that are found with
+// an empty value.
+type EmptyPolicy int
+
+const (
+	// EmptyIsValue sets fields to empty values, as to any other value.
+	// It's the default.
+	EmptyIsValue EmptyPolicy = iota
+	// EmptyIsUnset treats empty values as if their keys weren't found,
+	// so that fields are set to their defaults.
+	EmptyIsUnset
+	// EmptyIsError makes empty values an error.
+	EmptyIsError
+)
+
+// errEmptyValue is returned for an empty value under EmptyIsError.
+var errEmptyValue = errors.New("empty value")
+
+// EmptyValues configures how Unmarshal treats keys found with an empty
+// value.
+func EmptyValues(p EmptyPolicy) Option {
+	return func(c *config) {
+		c.emptyPolicy = p
+	}
+}
+
+// checkEmpty applies the configured EmptyPolicy to val, a looked up value.
+func (c *config) checkEmpty(val *string) (*string, error) {
+	if val == nil || *val != "" {
+		return val, nil
+	}
+	switch c.emptyPolicy {
+	case EmptyIsUnset:
+		return nil, nil
+	case EmptyIsError:
+		return nil, errEmptyValue
+	}
+	return val, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmptyValues(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host string `env:"HOST=localhost"`
+		Name string `env:"NAME=n"`
+	}
+	env := Map(map[string]string{"HOST": ""})
+
+	var s S
+	require.NoError(t, Unmarshal(&s, env))
+	require.Equal(t, S{"", "n"}, s)
+
+	s = S{}
+	require.NoError(t, Unmarshal(&s, env, EmptyValues(EmptyIsValue)))
+	require.Equal(t, S{"", "n"}, s)
+
+	s = S{}
+	var r Result
+	require.NoError(t, Unmarshal(&s, env, EmptyValues(EmptyIsUnset), Record(&r)))
+	require.Equal(t, S{"localhost", "n"}, s)
+	require.Equal(t, SourceDefault, r.Field("Host").Source)
+
+	err := Unmarshal(&s, env, EmptyValues(EmptyIsError))
+	require.EqualError(t, err, "empty value: field Host (string) in struct S")
+
+	type Req struct {
+		A string `env:"A" envopt:"required"`
+	}
+	err = Unmarshal(&Req{}, Map(map[string]string{"A": ""}), EmptyValues(EmptyIsUnset))
+	require.EqualError(t, err, "missing required key: field A (string) in struct Req")
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// defaultEnvMapSep separates the prefix and map keys of the keys collected
+// by the envmap modifier, unless the modifier gives another separator.
+const defaultEnvMapSep = "_"
+
+// envMapSep returns the separator given by the field's envmap modifier,
+// and whether it has one.
+func envMapSep(info *fieldInfo) (string, bool) {
+	sep, ok := info.mods["envmap"]
+	if ok && sep == "" {
+		sep = defaultEnvMapSep
+	}
+	return sep, ok
+}
+
+// envMapType returns the key types of the map type t, or of the type t
+// points to, and its innermost element type: one key type for a map of
+// values, and two for a map of maps of values.
+func envMapType(t reflect.Type) ([]reflect.Type, reflect.Type, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Map {
+		return nil, nil, errors.New("envmap modifier requires a map field")
+	}
+	keys := []reflect.Type{t.Key()}
+	if t.Elem().Kind() == reflect.Map {
+		t = t.Elem()
+		keys = append(keys, t.Key())
+	}
+	return keys, t.Elem(), nil
+}
+
+// decodeEnvMap sets the map field at the cursor, which has the envmap
+// modifier, from the keys that start with its key and the modifier's
+// separator. The rest of each key is the map key, or, for a map of maps,
+// the outer and inner map keys, split at the first separator, so that
+// LIMITS_api_burst sets m["api"]["burst"] for the key LIMITS. Keys are
+// listed as configured by ListKeys, and looked up as the field's key would
+// be. If no keys are found, the field is treated as not found; its tag
+// default isn't used.
+func (config *config) decodeEnvMap(c *cursor) (bool, error) {
+	sep, _ := envMapSep(c.info)
+	keyTypes, _, err := envMapType(c.field.Type)
+	if err != nil {
+		return false, config.fieldError(CodeInvalidTag, err, c)
+	}
+	if config.keys == nil {
+		return false, config.fieldError(CodeLookupFailure, errors.New("envmap modifier requires keys to be listed; see ListKeys"), c)
+	}
+
+	prefix := c.info.key + sep
+	var keys []string
+	for _, k := range config.keys() {
+		if strings.HasPrefix(k, prefix) && len(k) > len(prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	t := c.field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Each key is resolved like the field's key, but isn't required, and
+	// has no default.
+	info := *c.info
+	info.defval, info.mods = nil, make(map[string]string)
+	for name, v := range c.info.mods {
+		if name != "required" {
+			info.mods[name] = v
+		}
+	}
+
+	m := reflect.MakeMap(t)
+	found := false
+	for _, k := range keys {
+		info.key = k
+		val, source, layer, err := config.resolveField(c, &info)
+		if err != nil {
+			return false, config.fieldError(CodeLookupFailure, fmt.Errorf("key %v: %w", k, err), c)
+		}
+		if val == nil {
+			continue
+		}
+		names := []string{strings.TrimPrefix(k, prefix)}
+		if len(keyTypes) == 2 {
+			names = strings.SplitN(names[0], sep, 2)
+			if len(names) != 2 || names[0] == "" || names[1] == "" {
+				return false, config.fieldError(CodeParseFailure, fmt.Errorf("key %v has no inner map key", k), c)
+			}
+		}
+		if err := setEnvMapEntry(config, m, names, k, *val); err != nil {
+			return false, config.parseError(fmt.Errorf("key %v: %w", k, err), c, *val)
+		}
+		config.record(c, k, source, layer, *val)
+		found = true
+	}
+	if !found {
+		return false, nil
+	}
+
+	value := c.value
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(t))
+		}
+		value = value.Elem()
+	}
+	value.Set(m)
+	return true, nil
+}
+
+// setEnvMapEntry sets the entry of map m named by names, one key for each
+// level of map, to the value str.
+func setEnvMapEntry(cfg *config, m reflect.Value, names []string, key, str string) error {
+	k := reflect.New(m.Type().Key()).Elem()
+	if err := setValue(cfg, k, key, names[0], nil); err != nil {
+		return err
+	}
+	if len(names) == 1 {
+		v := reflect.New(m.Type().Elem()).Elem()
+		if err := setValue(cfg, v, key, str, nil); err != nil {
+			return err
+		}
+		m.SetMapIndex(k, v)
+		return nil
+	}
+	inner := m.MapIndex(k)
+	if !inner.IsValid() {
+		inner = reflect.MakeMap(m.Type().Elem())
+		m.SetMapIndex(k, inner)
+	}
+	return setEnvMapEntry(cfg, inner, names[1:], key, str)
+}
+
+// marshalEnvMap formats the entries of the map field at the cursor, which
+// has the envmap modifier, keyed by the keys that decodeEnvMap would set
+// them from.
+func (config *config) marshalEnvMap(c *cursor) (map[string]string, error) {
+	sep, _ := envMapSep(c.info)
+	if _, _, err := envMapType(c.field.Type); err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	var add func(prefix string, m reflect.Value) error
+	add = func(prefix string, m reflect.Value) error {
+		iter := reflect.Indirect(m).MapRange()
+		for iter.Next() {
+			name, err := formatValue(iter.Key())
+			if err != nil {
+				return err
+			}
+			if iter.Value().Kind() == reflect.Map {
+				if err := add(prefix+sep+name, iter.Value()); err != nil {
+					return err
+				}
+				continue
+			}
+			s, err := formatValue(iter.Value())
+			if err != nil {
+				return err
+			}
+			env[prefix+sep+name] = s
+		}
+		return nil
+	}
+	if err := add(c.info.key, c.value); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
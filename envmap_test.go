@@ -0,0 +1,82 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvMapModifier(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Limits   map[string]map[string]int `env:"LIMITS" envopt:"envmap"`
+		Labels   map[string]string         `env:"LABEL" envopt:"envmap=__"`
+		Weights  *map[int]float64          `env:"WEIGHT" envopt:"envmap"`
+		Optional map[string]string         `env:"OPT" envopt:"envmap"`
+	}
+	env := map[string]string{
+		"LIMITS_api_burst":  "10",
+		"LIMITS_api_rate":   "5",
+		"LIMITS_web_burst":  "20",
+		"LABEL__team":       "infra",
+		"LABEL__cost_class": "b",
+		"LABEL_ignored":     "x",
+		"WEIGHT_1":          "0.5",
+		"LIMITSX_a_b":       "1",
+	}
+	var s S
+	var result Result
+	require.NoError(t, Unmarshal(&s, Map(env), Record(&result)))
+	require.Equal(t, map[string]map[string]int{
+		"api": {"burst": 10, "rate": 5},
+		"web": {"burst": 20},
+	}, s.Limits)
+	require.Equal(t, map[string]string{"team": "infra", "cost_class": "b"}, s.Labels)
+	require.Equal(t, map[int]float64{1: 0.5}, *s.Weights)
+	require.Nil(t, s.Optional)
+	require.Equal(t, "LIMITS_api_burst", result.Fields[0].Key)
+	require.Equal(t, "Limits", result.Fields[0].Path)
+
+	out, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"LIMITS_api_burst":  "10",
+		"LIMITS_api_rate":   "5",
+		"LIMITS_web_burst":  "20",
+		"LABEL__team":       "infra",
+		"LABEL__cost_class": "b",
+		"WEIGHT_1":          "0.5",
+	}, out)
+
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, &S{}))
+	require.Contains(t, buf.String(), "LIMITS_*")
+	require.Contains(t, buf.String(), "LABEL__*")
+
+	type Required struct {
+		Opt map[string]string `env:"OPT" envopt:"envmap,required"`
+	}
+	err = Unmarshal(&Required{}, Map(env))
+	require.EqualError(t, err, "missing required key: field Opt (map) in struct Required")
+
+	err = Unmarshal(&s, Map(map[string]string{"LIMITS_api": "1"}))
+	require.EqualError(t, err, "key LIMITS_api has no inner map key: field Limits (map) in struct S")
+	err = Unmarshal(&s, Map(map[string]string{"LIMITS_api_burst": "lots"}))
+	require.EqualError(t, err, `key LIMITS_api_burst: strconv.ParseInt: parsing "lots": invalid syntax: field Limits (map) in struct S`)
+	err = Unmarshal(&s, Looker(lookupMap(env)))
+	require.EqualError(t, err, "envmap modifier requires keys to be listed; see ListKeys: field Limits (map) in struct S")
+
+	type Bad struct {
+		Name string            `env:"NAME" envopt:"envmap"`
+		Def  map[string]string `env:"DEF=a:b" envopt:"envmap"`
+	}
+	require.EqualError(t, Check(&Bad{}), "2 errors\ntop level (2):\n"+
+		"    envmap modifier requires a map field: field Name (string) in struct Bad\n"+
+		"    envmap field has a default, which is never used: field Def (map) in struct Bad")
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// An ErrorCode classifies a FieldError. Codes are stable, so that tools can
+// act on the class of a failure without parsing error messages.
+type ErrorCode string
+
+const (
+	// CodeMissingRequired means a required field's key wasn't found.
+	CodeMissingRequired ErrorCode = "missing_required"
+	// CodeParseFailure means a field couldn't be set from its value.
+	CodeParseFailure ErrorCode = "parse_failure"
+	// CodeUnsupportedType means a field's type can't be set or formatted.
+	CodeUnsupportedType ErrorCode = "unsupported_type"
+	// CodeLookupFailure means looking up or decrypting a field's value
+	// failed.
+	CodeLookupFailure ErrorCode = "lookup_failure"
+	// CodePolicyViolation means a field's key may not be looked up; see
+	// AllowKeys.
+	CodePolicyViolation ErrorCode = "policy_violation"
+	// CodeInvalidTag means a field's tags are invalid.
+	CodeInvalidTag ErrorCode = "invalid_tag"
+	// CodeFormatFailure means a field's value couldn't be formatted, or
+	// conflicts with another field's, as by Marshal.
+	CodeFormatFailure ErrorCode = "format_failure"
+)
+
+// A FieldError describes a failure to handle a tagged struct field.
+type FieldError struct {
+	// Code classifies the failure.
+	Code ErrorCode
+	// Path is the dot separated list of field names leading to the field,
+	// as used by UnmarshalPath.
+	Path string
+	// Key is the field's environment key, or empty if it has none.
+	Key string
+	// Field is the struct field.
+	Field reflect.StructField
+	// Kind is the kind of the field's value.
+	Kind reflect.Kind
+	// Struct is the type of the struct holding the field.
+	Struct reflect.Type
+	// Value is the value that failed to parse, for failures with code
+	// CodeParseFailure. It's truncated if long, and redacted if the field
+	// is secret.
+	Value string
+	// Err is the underlying error.
+	Err error
+
+	format    func(*FieldError) string
+	showValue bool
+}
+
+// Error returns the message from the function configured via
+// ErrorFormat, or by default, the underlying error's message followed by
+// a description of the field, as in "...: field Port (int) in struct
+// Config".
+func (e *FieldError) Error() string {
+	if e.format != nil {
+		return e.format(e)
+	}
+	return e.DefaultError()
+}
+
+// DefaultError returns the default message for e, for use by functions
+// configured via ErrorFormat.
+func (e *FieldError) DefaultError() string {
+	msg := fmt.Sprintf("%s: field %v (%v) in struct %v", e.Err.Error(),
+		e.Field.Name, e.Kind.String(), e.Struct.Name())
+	if e.showValue && e.Code == CodeParseFailure {
+		msg += fmt.Sprintf(" with value %q", e.Value)
+	}
+	return msg
+}
+
+// maxErrorValue is the length that values in FieldErrors are truncated
+// to.
+const maxErrorValue = 64
+
+// ErrorValues configures the messages of FieldErrors for values that fail
+// to parse to include the value, truncated if long, and redacted if the
+// field is secret.
+func ErrorValues() Option {
+	return func(c *config) {
+		c.errorValues = true
+	}
+}
+
+// parseError returns a FieldError for err, from setting the field at the
+// cursor to val.
+func (config *config) parseError(err error, c *cursor, val string) *FieldError {
+	if c.info.secret {
+		err = config.redactError(err, c.info.key, val)
+		val = config.redact(c.info.key, val)
+	} else if r := []rune(val); len(r) > maxErrorValue {
+		val = string(r[:maxErrorValue]) + "..."
+	}
+	fe := config.fieldError(CodeParseFailure, err, c)
+	fe.Value = val
+	return fe
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// errUnsupportedType is wrapped by errors for types that can't be set or
+// formatted.
+var errUnsupportedType = errors.New("unsupported type")
+
+// ErrorFormat configures the function used to format the messages of
+// FieldErrors, such as to localize them, or to match the style of other
+// messages.
+func ErrorFormat(fn func(e *FieldError) string) Option {
+	return func(c *config) {
+		c.errorFormat = fn
+	}
+}
+
+// fieldError returns a FieldError for err at the cursor, with the given
+// code, unless err is of a more specific class.
+func (config *config) fieldError(code ErrorCode, err error, c *cursor) *FieldError {
+	var perr *PolicyError
+	var terr *transformError
+	switch {
+	case errors.Is(err, errMissingRequired):
+		code = CodeMissingRequired
+	case errors.As(err, &terr):
+		code = CodeParseFailure
+	case errors.As(err, &perr):
+		code = CodePolicyViolation
+	case errors.Is(err, errUnsupportedType):
+		code = CodeUnsupportedType
+	}
+	key := ""
+	if c.info != nil {
+		key = c.info.key
+	}
+	return &FieldError{
+		Code:      code,
+		Path:      c.path,
+		Key:       key,
+		Field:     c.field,
+		Kind:      c.value.Kind(),
+		Struct:    c.structType,
+		Err:       err,
+		format:    config.errorFormat,
+		showValue: config.errorValues,
+	}
+}
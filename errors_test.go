@@ -0,0 +1,116 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Port int `env:"PORT"`
+	}
+	type S struct {
+		Name  string `env:"NAME" envopt:"required"`
+		Inner Inner
+	}
+
+	code := func(err error) ErrorCode {
+		var fe *FieldError
+		require.True(t, errors.As(err, &fe), "%v", err)
+		return fe.Code
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(nil))
+	require.Equal(t, CodeMissingRequired, code(err))
+
+	err = Unmarshal(&s, Map(map[string]string{"NAME": "n", "PORT": "x"}))
+	require.Equal(t, CodeParseFailure, code(err))
+	var fe *FieldError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, "Inner.Port", fe.Path)
+	require.Equal(t, "PORT", fe.Key)
+	require.Equal(t, "Port", fe.Field.Name)
+	require.Equal(t, reflect.Int, fe.Kind)
+	require.Equal(t, reflect.TypeOf(Inner{}), fe.Struct)
+
+	err = Unmarshal(&s, Map(map[string]string{"NAME": "n"}), DenyKeys("PORT"))
+	require.Equal(t, CodePolicyViolation, code(err))
+
+	failing := Looker(func(string) (*string, error) { return nil, errors.New("unavailable") })
+	err = Unmarshal(&s, failing)
+	require.Equal(t, CodeLookupFailure, code(err))
+
+	type Unsupported struct {
+		C chan int `env:"C"`
+	}
+	err = Unmarshal(&Unsupported{}, Map(map[string]string{"C": "1"}))
+	require.Equal(t, CodeUnsupportedType, code(err))
+	require.EqualError(t, err, "unsupported type: chan int: field C (chan) in struct Unsupported")
+
+	type BadTag struct {
+		A string `env:"A" envopt:"bogus"`
+	}
+	err = Unmarshal(&BadTag{}, Map(nil))
+	require.Equal(t, CodeInvalidTag, code(err))
+
+	_, err = Marshal(&Unsupported{C: make(chan int)})
+	require.Equal(t, CodeUnsupportedType, code(err))
+}
+
+func TestErrorFormat(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port int `env:"PORT"`
+	}
+
+	format := ErrorFormat(func(e *FieldError) string {
+		return "configuration " + e.Key + " (" + string(e.Code) + "): " + e.Err.Error()
+	})
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"PORT": "x"}), format)
+	require.EqualError(t, err, `configuration PORT (parse_failure): strconv.ParseInt: parsing "x": invalid syntax`)
+
+	var fe *FieldError
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, `strconv.ParseInt: parsing "x": invalid syntax: field Port (int) in struct S`, fe.DefaultError())
+}
+
+func TestErrorValues(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port  int    `env:"PORT"`
+		PIN   int    `env:"PIN" envopt:"secret"`
+		Ratio uint16 `env:"RATIO"`
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"PORT": "eighty"}), ErrorValues())
+	require.EqualError(t, err, `strconv.ParseInt: parsing "eighty": invalid syntax: field Port (int) in struct S with value "eighty"`)
+
+	var fe *FieldError
+	err = Unmarshal(&s, Map(map[string]string{"PORT": "eighty"}))
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, "eighty", fe.Value)
+	require.NotContains(t, err.Error(), "with value")
+
+	err = Unmarshal(&s, Map(map[string]string{"PIN": "12x4"}), ErrorValues())
+	require.EqualError(t, err, `strconv.ParseInt: parsing "[REDACTED]": invalid syntax: field PIN (int) in struct S with value "[REDACTED]"`)
+
+	long := strings.Repeat("9", 100)
+	err = Unmarshal(&s, Map(map[string]string{"RATIO": long}))
+	require.True(t, errors.As(err, &fe))
+	require.Equal(t, strings.Repeat("9", 64)+"...", fe.Value)
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// expand returns the tag default def with each "${KEY}" reference
+// replaced by the value of KEY, or the empty string if KEY isn't found.
+func (c *config) expand(def string) (string, error) {
+	return substitute(def, "default", func(key string) (string, error) {
+		v, _, err := c.lookup(key)
+		if err != nil || v == nil {
+			return "", err
+		}
+		return *v, nil
+	})
+}
+
+// substitute returns s, a default or key, with each "${NAME}" reference
+// replaced by the result of fn.
+func substitute(s, what string, fn func(name string) (string, error)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			return "", errors.New("unterminated reference in " + what)
+		}
+		name := s[i+2 : i+j]
+		if name == "" {
+			return "", errors.New("empty reference in " + what)
+		}
+		v, err := fn(name)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[:i])
+		b.WriteString(v)
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return b.String(), nil
+}
+
+// KeyVars supplies values for "${NAME}" placeholders in tag keys; for
+// example, with KeyVars(map[string]string{"INDEX": "3"}), a field tagged
+// with `env:"WORKER_${INDEX}_QUEUE"` has the key WORKER_3_QUEUE. It's an
+// error for a key to hold a placeholder without a value. Repeated uses of
+// KeyVars are merged, with later values taking precedence.
+func KeyVars(vars map[string]string) Option {
+	return func(c *config) {
+		merged := make(map[string]string, len(c.keyVars)+len(vars))
+		for k, v := range c.keyVars {
+			merged[k] = v
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+		c.keyVars = merged
+	}
+}
+
+// A FieldInfo describes a tagged struct field.
+type FieldInfo struct {
+	// Path is the dot separated list of field names leading to the field,
+	// as used by UnmarshalPath.
+	Path string
+	// Key is the field's environment key. It's the key from the field's
+	// tag when passed to a KeyFunc, which rewrites it.
+	Key string
+	// Field is the struct field.
+	Field reflect.StructField
+	// Default is the field's tag default, if it has one.
+	Default *string
+	// Secret is true if the field is secret.
+	Secret bool
+	// Required is true if the field has the required modifier.
+	Required bool
+}
+
+// newFieldInfo returns the FieldInfo of the field at the cursor.
+func newFieldInfo(cur *cursor) FieldInfo {
+	f := FieldInfo{Path: cur.path, Key: cur.info.key, Field: cur.field, Secret: cur.info.secret}
+	if cur.info.defval != nil {
+		def := *cur.info.defval
+		f.Default = &def
+	}
+	_, f.Required = cur.info.mods["required"]
+	return f
+}
+
+// KeyFunc configures Unmarshal to look up each field's key as rewritten
+// by fn, which is passed the key from the field's tag, after any KeyVars
+// placeholders are replaced, and a description of the field. It allows
+// arbitrary naming conventions, such as adding a prefix or tenant ID.
+// Repeated uses of KeyFunc are applied in order.
+func KeyFunc(fn func(tagKey string, f FieldInfo) string) Option {
+	return func(c *config) {
+		c.keyFuncs = append(append([]func(string, FieldInfo) string(nil), c.keyFuncs...), fn)
+	}
+}
+
+// rekey replaces the field info at the cursor with a copy holding the key
+// that the config maps the field's tag key to, if it differs, using the
+// config's plan if it has the field.
+func (c *config) rekey(cur *cursor) error {
+	if c.plan != nil {
+		if f, ok := c.plan.fields[cur.path]; ok && f.tagged == cur.info {
+			cur.info = f.info
+			return f.err
+		}
+	}
+	key := cur.info.key
+	if key == "" || (len(c.keyFuncs) == 0 && !strings.Contains(key, "${")) {
+		return nil
+	}
+	key, err := substitute(key, "key", func(name string) (string, error) {
+		v, ok := c.keyVars[name]
+		if !ok {
+			return "", fmt.Errorf("no value for %v in key", name)
+		}
+		return v, nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(c.keyFuncs) > 0 {
+		f := newFieldInfo(cur)
+		for _, fn := range c.keyFuncs {
+			key = fn(key, f)
+		}
+		if key == "" {
+			return errors.New("empty key from KeyFunc")
+		}
+	}
+	info := *cur.info
+	info.key = key
+	cur.info = &info
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandDefaults(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Cache string `env:"CACHE_ADDR=${REDIS_HOST}:6379"`
+		URL   string `env:"URL=http://${HOST}:${PORT}/${MISSING}"`
+		Plain string `env:"PLAIN=$HOME and $"`
+	}
+
+	var s S
+	var r Result
+	err := Unmarshal(&s, Map(map[string]string{"REDIS_HOST": "redis", "HOST": "h", "PORT": "80"}), Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, S{"redis:6379", "http://h:80/", "$HOME and $"}, s)
+	require.Equal(t, "redis:6379", r.Field("Cache").Value)
+
+	// References aren't expanded when the key is found.
+	s = S{}
+	err = Unmarshal(&s, Map(map[string]string{"CACHE_ADDR": "${REDIS_HOST}"}))
+	require.NoError(t, err)
+	require.Equal(t, "${REDIS_HOST}", s.Cache)
+
+	type Bad1 struct {
+		A string `env:"A=${B"`
+	}
+	err = Unmarshal(&Bad1{}, Map(nil))
+	require.EqualError(t, err, "unterminated reference in default: field A (string) in struct Bad1")
+
+	type Bad2 struct {
+		A string `env:"A=${}"`
+	}
+	err = Unmarshal(&Bad2{}, Map(nil))
+	require.EqualError(t, err, "empty reference in default: field A (string) in struct Bad2")
+
+	err = Unmarshal(&s, Map(nil), DenyKeys("REDIS_*"))
+	require.EqualError(t, err, "key REDIS_HOST not allowed by lookup policy: field Cache (string) in struct S")
+}
+
+func TestKeyVars(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Queue string `env:"WORKER_${INDEX}_QUEUE=q-${INDEX}"`
+		Zone  string `env:"${REGION}_${INDEX}_ZONE"`
+		Host  string `env:"HOST"`
+	}
+
+	env := Map(map[string]string{"WORKER_3_QUEUE": "jobs", "EU_3_ZONE": "eu-3a", "HOST": "h"})
+	vars := KeyVars(map[string]string{"INDEX": "3", "REGION": "US"})
+
+	var s S
+	var r Result
+	require.NoError(t, Unmarshal(&s, env, vars, KeyVars(map[string]string{"REGION": "EU"}), Record(&r)))
+	require.Equal(t, S{"jobs", "eu-3a", "h"}, s)
+	require.Equal(t, "WORKER_3_QUEUE", r.Field("Queue").Key)
+
+	out, err := Marshal(&s, vars)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"WORKER_3_QUEUE": "jobs", "US_3_ZONE": "eu-3a", "HOST": "h"}, out)
+
+	err = Unmarshal(&s, env, KeyVars(map[string]string{"REGION": "EU"}))
+	require.EqualError(t, err, "no value for INDEX in key: field Queue (string) in struct S")
+
+	type Bad struct {
+		A string `env:"A_${"`
+	}
+	err = Unmarshal(&Bad{}, env)
+	require.EqualError(t, err, "unterminated reference in key: field A (string) in struct Bad")
+}
+
+func TestKeyFunc(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		URL string `env:"URL" envopt:"required"`
+	}
+	type S struct {
+		Port  int    `env:"PORT=80"`
+		Token string `env:"TOKEN" envopt:"secret"`
+		DB    DB
+	}
+
+	var infos []FieldInfo
+	prefix := KeyFunc(func(key string, f FieldInfo) string {
+		infos = append(infos, f)
+		return "APP_" + key
+	})
+	files := KeyFunc(func(key string, f FieldInfo) string {
+		if f.Secret {
+			return key + "_FILE"
+		}
+		return key
+	})
+
+	env := Map(map[string]string{"APP_PORT": "8080", "APP_TOKEN_FILE": "t", "APP_URL": "u"})
+	var s S
+	var r Result
+	require.NoError(t, Unmarshal(&s, env, prefix, files, Record(&r)))
+	require.Equal(t, S{8080, "t", DB{"u"}}, s)
+	require.Equal(t, "APP_TOKEN_FILE", r.Field("Token").Key)
+
+	require.Len(t, infos, 3)
+	require.Equal(t, "Port", infos[0].Path)
+	require.Equal(t, "80", *infos[0].Default)
+	require.True(t, infos[1].Secret)
+	require.Equal(t, "DB.URL", infos[2].Path)
+	require.True(t, infos[2].Required)
+	require.Equal(t, "URL", infos[2].Field.Name)
+
+	empty := KeyFunc(func(string, FieldInfo) string { return "" })
+	err := Unmarshal(&s, env, empty)
+	require.EqualError(t, err, "empty key from KeyFunc: field Port (int) in struct S")
+}
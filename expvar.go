@@ -0,0 +1,37 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"expvar"
+)
+
+// Publish publishes an expvar variable with the given name, whose value
+// holds the tagged fields of the struct provided by s, in the form used
+// by DebugHandler. If s has a "Stats() WatcherStats" method, as Watcher and
+// Value do, the value also holds those statistics. Secret values are
+// redacted by the Redactor given in options, if any. Like expvar.Publish,
+// Publish panics if the name is already in use.
+func Publish(name string, s Snapshotter, options ...Option) {
+	config := newConfig(options)
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return publishedVar(config, s)
+	}))
+}
+
+type publishedConfig struct {
+	Fields []debugField  `json:"fields"`
+	Stats  *WatcherStats `json:"stats,omitempty"`
+}
+
+func publishedVar(config *config, s Snapshotter) publishedConfig {
+	cfg, result := s.Snapshot()
+	v := publishedConfig{Fields: snapshotFields(config, cfg, result)}
+	if st, ok := s.(interface{ Stats() WatcherStats }); ok {
+		stats := st.Stats()
+		v.Stats = &stats
+	}
+	return v
+}
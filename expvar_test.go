@@ -0,0 +1,55 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// publishRuns numbers the runs of TestPublish, whose expvar names must be
+// unique within the process, such as with -count.
+var publishRuns atomic.Int64
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port  int    `env:"PORT"`
+		Token string `env:"TOKEN" envopt:"secret"`
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	env.set("TOKEN", "t1")
+	v, err := NewValue[S](env.looker())
+	require.NoError(t, err)
+	name := fmt.Sprintf("fromenv_test_publish_%d", publishRuns.Add(1))
+	Publish(name, v)
+
+	env.set("PORT", "8080")
+	require.NoError(t, v.Reload())
+	env.set("PORT", "eighty")
+	require.Error(t, v.Reload())
+
+	var got struct {
+		Fields []map[string]interface{}
+		Stats  WatcherStats
+	}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &got))
+	require.Len(t, got.Fields, 2)
+	require.Equal(t, float64(8080), got.Fields[0]["value"])
+	require.Equal(t, "[REDACTED]", got.Fields[1]["value"])
+	require.Equal(t, int64(2), got.Stats.Polls)
+	require.Equal(t, int64(1), got.Stats.Changes)
+	require.Equal(t, int64(1), got.Stats.Failures)
+	require.Contains(t, got.Stats.LastError, "eighty")
+	require.False(t, got.Stats.LastChange.IsZero())
+}
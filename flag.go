@@ -0,0 +1,160 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+)
+
+const (
+	flagTagName = "envflag"
+	docTagName  = "envdoc"
+)
+
+// A Flag describes a tagged struct field as a command-line flag.
+type Flag struct {
+	// Name is the flag's name, from the field's envflag tag, or derived
+	// from the field's key by lower casing it and replacing underscores
+	// with dashes; for example, "db-url" for the key DB_URL.
+	Name string
+	// Shorthand is the flag's one letter abbreviation, for flag packages
+	// that support them, from an envflag tag such as "db-url,d". It may be
+	// given without a name, as in ",d".
+	Shorthand string
+	// Key is the environment key from the field's tag.
+	Key string
+	// Usage describes the flag, from the field's envdoc tag, and notes
+	// the environment key.
+	Usage string
+	// Value sets the field.
+	Value *FlagValue
+}
+
+// A FlagValue is a flag.Value that sets a tagged struct field, as Unmarshal
+// would.
+type FlagValue struct {
+	config *config
+	value  reflect.Value
+	info   *fieldInfo
+}
+
+// String returns the field's current value formatted as by Marshal, or the
+// empty string for secret fields.
+func (v *FlagValue) String() string {
+	if v == nil || !v.value.IsValid() || v.info.secret {
+		return ""
+	}
+	s, err := v.config.marshalField(v.info, v.value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// Set sets the field to s.
+func (v *FlagValue) Set(s string) error {
+	err := v.config.setField(v.info, v.value, s, nil)
+	if err != nil && v.info.secret {
+		err = v.config.redactError(err, v.info.key, s)
+	}
+	return err
+}
+
+// IsBoolFlag returns true for boolean fields, so that they may be set
+// with a flag such as "-debug", without a value.
+func (v *FlagValue) IsBoolFlag() bool {
+	t := v.value.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// Type returns the name of the field's type.
+func (v *FlagValue) Type() string {
+	t := v.value.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// Flags calls Unmarshal with in and options, and then returns a Flag for
+// each tagged field reachable from in. Fields whose envflag tag is "-"
+// are omitted.
+func Flags(in interface{}, options ...Option) ([]*Flag, error) {
+	if err := Unmarshal(in, options...); err != nil {
+		return nil, err
+	}
+	return flagList(in, newConfig(options))
+}
+
+// flagList returns a Flag for each tagged field reachable from in.
+func flagList(in interface{}, config *config) ([]*Flag, error) {
+	var flags []*Flag
+	err := visit(in, "", func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		key := c.info.key
+		if len(key) == 0 {
+			return nil
+		}
+		name, shorthand := c.field.Tag.Get(flagTagName), ""
+		if name == "-" {
+			return nil
+		}
+		if i := strings.Index(name, optSep); i >= 0 {
+			name, shorthand = name[:i], name[i+1:]
+		}
+		if name == "" {
+			name = strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		}
+
+		usage := "environment variable " + key
+		if doc := c.field.Tag.Get(docTagName); doc != "" {
+			usage = doc + " (" + usage + ")"
+		}
+
+		flags = append(flags, &Flag{
+			Name:      name,
+			Shorthand: shorthand,
+			Key:       key,
+			Usage:     usage,
+			Value:     &FlagValue{config, c.value, c.info},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// BindFlags defines a flag in fs for each Flag returned by Flags, so that
+// parsing fs overrides the values that in's fields were set to from the
+// environment. The flags' defaults, as shown by fs.PrintDefaults, are the
+// values from the environment. BindFlags returns an error if a flag name
+// is already defined in fs.
+func BindFlags(fs *flag.FlagSet, in interface{}, options ...Option) error {
+	flags, err := Flags(in, options...)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range flags {
+		if prev := fs.Lookup(f.Name); prev != nil {
+			return errors.New("flag redefined: " + f.Name)
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	return nil
+}
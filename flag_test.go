@@ -0,0 +1,70 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindFlags(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		DBURL    string        `env:"DB_URL=postgres://localhost" envdoc:"database URL"`
+		Port     int           `env:"PORT"`
+		Debug    bool          `env:"DEBUG"`
+		Timeout  time.Duration `env:"TIMEOUT=5s" envflag:"wait"`
+		Password string        `env:"PASSWORD" envopt:"secret"`
+		Internal string        `env:"INTERNAL" envflag:"-"`
+	}
+
+	env := map[string]string{
+		"PORT":     "80",
+		"PASSWORD": "hunter2",
+		"INTERNAL": "i1",
+	}
+
+	var s S
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	err := BindFlags(fs, &s, Map(env), CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, 80, s.Port)
+	require.Nil(t, fs.Lookup("internal"))
+
+	fs.PrintDefaults()
+	require.Contains(t, out.String(), "-db-url value\n    \tdatabase URL (environment variable DB_URL) (default postgres://localhost)")
+	require.Contains(t, out.String(), "-wait value\n    \tenvironment variable TIMEOUT (default 5s)")
+	require.Contains(t, out.String(), "-debug\n")
+	require.NotContains(t, out.String(), "hunter2")
+
+	err = fs.Parse([]string{"-db-url", "postgres://db", "-debug", "-wait=1m"})
+	require.NoError(t, err)
+	require.Equal(t, S{
+		DBURL:    "postgres://db",
+		Port:     80,
+		Debug:    true,
+		Timeout:  time.Minute,
+		Password: "hunter2",
+		Internal: "i1",
+	}, s)
+
+	err = fs.Parse([]string{"-port", "eighty"})
+	require.Error(t, err)
+
+	type Dup struct {
+		A string `env:"A" envflag:"x"`
+		B string `env:"B" envflag:"x"`
+	}
+	var d Dup
+	err = BindFlags(flag.NewFlagSet("dup", flag.ContinueOnError), &d, Map(nil))
+	require.EqualError(t, err, "flag redefined: x")
+}
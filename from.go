@@ -0,0 +1,47 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+)
+
+// NamedLooker registers f under name, for fields whose "from" modifier
+// names it, as in `envopt:"from=vault"`, so that most fields come from the
+// environment, but a few come from another source, such as a secrets
+// backend. Unlike a layer configured via Layers, a named looker isn't used
+// for fields that don't name it. A "source" modifier may also name it.
+func NamedLooker(name string, f LookupEnvFunc) Option {
+	return func(c *config) {
+		lookers := make(map[string]LookupEnvFunc, len(c.lookers)+1)
+		for n, l := range c.lookers {
+			lookers[n] = l
+		}
+		lookers[name] = f
+		c.lookers = lookers
+	}
+}
+
+// hasLooker returns whether name is a configured layer or named looker.
+func (c *config) hasLooker(name string) bool {
+	if _, ok := c.lookers[name]; ok {
+		return true
+	}
+	for _, l := range c.layerList() {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFrom looks up key with the layer or named looker given by a field's
+// from modifier.
+func (c *config) lookupFrom(key, from string) (*string, string, error) {
+	if !c.hasLooker(from) {
+		return nil, "", fmt.Errorf("unknown looker %q in from modifier", from)
+	}
+	return c.lookupSources(key, []string{from})
+}
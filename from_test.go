@@ -0,0 +1,54 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromModifier(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host     string `env:"HOST"`
+		Password string `env:"DB_PASSWORD" envopt:"from=vault"`
+		APIKey   string `env:"API_KEY=none" envopt:"from=vault"`
+		Token    string `env:"TOKEN" envopt:"source=vault;env"`
+	}
+	env := map[string]string{"HOST": "db", "DB_PASSWORD": "from-env", "TOKEN": "env-token"}
+	vault := map[string]string{"DB_PASSWORD": "hunter2", "HOST": "vault-host"}
+
+	var s S
+	var result Result
+	err := Unmarshal(&s, Map(env), NamedLooker("vault", lookupMap(vault)), Record(&result))
+	require.NoError(t, err)
+	require.Equal(t, S{"db", "hunter2", "none", "env-token"}, s)
+	require.Equal(t, "vault", result.Fields[1].Layer)
+	require.Equal(t, "env", result.Fields[3].Layer)
+
+	s = S{}
+	err = Unmarshal(&s, Map(env), NamedLooker("vault", lookupMap(vault)), ConcurrentLookups(2))
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", s.Password)
+
+	err = Unmarshal(&s, Map(env))
+	require.EqualError(t, err, `unknown looker "vault" in from modifier: field Password (string) in struct S`)
+
+	failing := func(string) (*string, error) { return nil, errors.New("sealed") }
+	err = Unmarshal(&s, Map(env), NamedLooker("vault", failing))
+	require.EqualError(t, err, "sealed: field Password (string) in struct S")
+
+	require.NoError(t, Check(&s, NamedLooker("vault", failing)))
+	type Bad struct {
+		A string `env:"A" envopt:"from=file"`
+		B string `env:"B" envopt:"from=env,source=env"`
+	}
+	require.EqualError(t, Check(&Bad{}), "2 errors\ntop level (2):\n"+
+		`    unknown looker "file" in from modifier: field A (string) in struct Bad`+"\n"+
+		"    from and source modifiers both given: field B (string) in struct Bad")
+}
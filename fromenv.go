@@ -5,81 +5,542 @@
 package fromenv
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
-type unmarshalError struct {
-	err    error
-	cursor *cursor
-}
-
-func (e *unmarshalError) Error() string {
-	return fmt.Sprintf("%s: field %v (%v) in struct %v", e.err.Error(),
-		e.cursor.field.Name, e.cursor.value.Kind().String(), e.cursor.structType.Name())
-}
-
 // Unmarshal takes a pointer to a struct, recursively looks for struct fields
 // with a "env" tag, and, by default, uses the os.LookupEnv function to
 // determine the desired value from the environment.
 //
 // An env tag may optionally specify a default desired value; if no entry exists
 // in the environment for the field's key, then the desired value of the field
-// will be this default value.
+// will be this default value. References of the form "${KEY}" in a default
+// are replaced by the value of KEY, looked up as the field's key would be,
+// or by the empty string if KEY isn't found; for example,
+// `env:"CACHE_ADDR=${REDIS_HOST}:6379"`.
 //
 // Unmarshal will set the struct field (of type T) to the desired value by whichever method matches first:
 //
 // * Using a function of type "func(*T, string) error" configured via SetFunc.
 //
+// * Using a function registered for T via RegisterSetter.
+//
+// * If T satisfies an interface of `func SetContext(context.Context, string) error`,
+// then its SetContext function, passed the context given to UnmarshalContext.
+//
 // * If T satisfies an interface of `func Set(string) error`, then its Set function.
 //
 // * If T is a boolean, numeric, or string type, then the appropriate strconv function will be used.
 //
+// * If T is an interface type configured via Implementations, then the selected implementation.
+//
+// * If T is a map type, then its entries, separated as configured by
+// MapSeparators, as in "admin:read|write,user:read" for a
+// map[string][]string. Keys and values are set as fields of their types
+// would be; slice values are split into elements.
+//
+// A field may also have an "envopt" tag, holding a comma separated list of
+// modifiers:
+//
+// * "secret" marks the field's value as sensitive, so that it is redacted
+// by functions such as Diff. Fields of types that implement Secreter are
+// always secret.
+//
+// * "required" makes it an error for the field's key not to be found; its
+// tag default, if any, isn't used.
+//
+// * "encrypted" marks the field's value as ciphertext, to be decrypted as
+// configured by Decrypt. Encrypted fields are secret.
+//
+// * "source" limits the layers the field's value may come from, as
+// described by Layers.
+//
+// * "from" names the only layer, or looker configured via NamedLooker, to
+// look up the field's key with, as in "from=vault"; the tag default is
+// used if it's not found there.
+//
+// * "deprecated" reports a Warning when the field's key is found, naming
+// the key to use instead if given, as in "deprecated=NEW_KEY".
+//
+// * "shellwords" splits the value of a slice field into words using shell
+// quoting rules, so that `-v --name "my app"` is three elements; each
+// element is set as a field of the element type would be.
+//
+// * "csv" sets the fields of a struct field positionally from the columns
+// of its value, a row of comma separated values, such as "host,443,true".
+// A field's column is its position among the struct's exported fields,
+// unless given by an envcsv tag, as in `envcsv:"2"`; fields tagged
+// `envcsv:"-"` are left unset.
+//
+// * "layouts" lists the layouts, separated by semicolons, tried in order
+// to parse the value of a time.Time field, as in
+// "layouts=RFC3339;2006-01-02". Names of the time package's layout
+// constants, such as RFC3339 or DateOnly, stand for their layouts. Layouts
+// can't contain commas. Marshal formats the field with the first layout.
+//
+// * "envmap" sets a map field from every key that starts with the field's
+// key and a separator, "_" unless given as the modifier's value, as in
+// "envmap=__". The rest of the key is the map key, so that the key LIMITS
+// with LIMITS_api=10 sets m["api"]; for a map of maps, it's split at the
+// first separator into the outer and inner map keys, so that
+// LIMITS_api_burst=10 sets m["api"]["burst"]. Keys are listed as
+// configured by ListKeys, and from the environment or Map by default. The
+// tag default isn't used.
+//
+// * "oneof" makes a struct field a union of variants, selected by the value
+// of the field's key. The variants are the struct's exported fields that
+// are pointers to structs, named by their "variant" modifier, as in
+// "variant=s3", or by their lowercased field name. The selected variant
+// is allocated if nil, and decoded; the others are set to nil, so that
+// their required keys aren't enforced, and the struct's other fields are
+// ignored. An unknown variant is an error.
+//
+// * "if" names a key, as in "if=FEATURE_X", whose value must be true, as
+// parsed by strconv.ParseBool, for the field to be decoded; otherwise the
+// field is left unchanged, its key isn't looked up, and it isn't required.
+// On a struct field without a key, it applies to the fields within it.
+//
+// Fields of type Lazy are looked up when first used, rather than by
+// Unmarshal; see Lazy.
+//
+// Fields are visited depth first, in declaration order: the fields of a
+// nested struct are visited before the fields that follow it. A struct
+// reachable by several paths is only visited at the first. The order
+// depends only on the struct types and which pointers are nil, so traces,
+// errors, and generated documentation don't change between runs.
+//
 // Unmarshal will return an error if the env tag is used on a struct field that
-// can't be set with any of the above, or if the value's setting function fails.
+// can't be set with any of the above, if the value's setting function fails,
+// or if an envopt tag holds an unknown modifier.
 func Unmarshal(in interface{}, options ...Option) error {
 	// The input interface should be a non-nil pointer to struct.
 	if !isStructPtr(in) {
 		return errors.New("passed non-pointer or nil pointer")
 	}
-	config := &config{
-		looker: osLookup,
+	return unmarshal(newConfig(options), in)
+}
+
+// unmarshal sets the tagged fields of the struct pointer in.
+func unmarshal(config *config, in interface{}) error {
+	for _, path := range config.only {
+		if !validPath(reflect.TypeOf(in).Elem(), path) {
+			return fmt.Errorf("unknown field path: %v", path)
+		}
+	}
+	if config.workers > 0 {
+		config.prefetch(in)
+	}
+	_, err := decode(config, in, "", nil)
+	if err == nil {
+		err = config.collected()
 	}
-	for _, option := range options {
-		option(config)
+	if err == nil {
+		config.checkUnused()
 	}
+	return err
+}
+
+// UnmarshalContext is like Unmarshal, but passes ctx to the SetContext
+// function of any types that have one, and stops if ctx is done.
+func UnmarshalContext(ctx context.Context, in interface{}, options ...Option) error {
+	return Unmarshal(in, append(options, withContext(ctx))...)
+}
+
+// withContext configures Unmarshal to use ctx.
+func withContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.ctx = ctx
+	}
+}
+
+// UnmarshalPath is like Unmarshal, but only sets the field named by path,
+// or the fields within it if it's a struct. The path is a dot separated
+// list of field names, starting from the struct pointed to by in; for
+// example, "Redis.Addr".
+//
+// To set all fields of a nested struct, Unmarshal may instead be passed a
+// pointer to that struct, as in Unmarshal(&cfg.Redis), but only UnmarshalPath
+// can set a single tagged field.
+func UnmarshalPath(in interface{}, path string, options ...Option) error {
+	if !isStructPtr(in) {
+		return errors.New("passed non-pointer or nil pointer")
+	}
+	if !validPath(reflect.TypeOf(in).Elem(), path) {
+		return fmt.Errorf("unknown field path: %v", path)
+	}
+	return Unmarshal(in, append(options, Only(path))...)
+}
+
+// UnmarshalKeys is like Unmarshal, but only sets fields whose tags name one
+// of the given keys. It can be used to update a struct after being notified
+// that specific keys have changed.
+func UnmarshalKeys(in interface{}, keys []string, options ...Option) error {
+	return Unmarshal(in, append(options, onlyKeys(keys...))...)
+}
+
+// onlyKeys configures Unmarshal to only set the fields tagged with keys.
+func onlyKeys(keys ...string) Option {
+	return func(c *config) {
+		if c.onlyKeys == nil {
+			c.onlyKeys = make(map[string]struct{})
+		}
+		for _, k := range keys {
+			c.onlyKeys[k] = struct{}{}
+		}
+	}
+}
+
+// Only configures Unmarshal to only set the fields at or within the given
+// paths, each a dot separated list of field names as for UnmarshalPath, as
+// in Only("Redis", "HTTP"), so that tools sharing a service's configuration
+// struct can decode just the sections they need. Keys of other fields
+// aren't looked up, so their required keys aren't enforced. Unmarshal
+// returns an error for paths that name no field, as UnmarshalPath does.
+// Paths given by several Only options are combined.
+func Only(paths ...string) Option {
+	return func(c *config) {
+		c.only = append(c.only, paths...)
+	}
+}
+
+// validPath returns true if path names a field reachable from struct type t.
+func validPath(t reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Interface {
+			// The field's type is only known at runtime.
+			return true
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return false
+		}
+		t = field.Type
+	}
+	return true
+}
+
+// decode sets the tagged fields reachable from the struct pointer in,
+// returning the number of fields that were set. The path is that of the
+// struct pointed to by in. The allocating list holds the struct types
+// currently being allocated by AllocateNested, and prevents unbounded
+// allocation of recursive types.
+func decode(config *config, in interface{}, path string, allocating []reflect.Type) (int, error) {
+	n := 0
 
 	// Visit each struct field reachable from the input interface,
 	// processing any fields with the "env" struct tag.
-	return visit(in, func(c *cursor) error {
-		key, defval := parseTag(c)
+	visitor := func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			config.tracef("skipped: type %v is skipped", c.field.Type)
+			return errSkipField
+		}
+
+		switch config.scope(c.path) {
+		case outOfScope:
+			config.tracef("skipped: outside the decoded path")
+			return errSkipField
+		case aboveScope:
+			if c.field.Tag.Get(tagName) != "" {
+				return nil
+			}
+		}
+
+		if c.info.modErr != nil {
+			return config.fieldError(CodeInvalidTag, c.info.modErr, c)
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		if on, err := config.enabled(c); err != nil {
+			return config.fieldError(CodeLookupFailure, err, c)
+		} else if !on {
+			config.tracef("skipped: %v isn't true", c.info.mods["if"])
+			return errSkipField
+		}
+
+		key := c.info.key
 		if len(key) == 0 {
+			if c.field.PkgPath != "" && structHasTags(c.field.Type) {
+				if config.strict {
+					return config.fieldError(CodeInvalidTag, errors.New("unexported field contains tagged fields"), c)
+				}
+				config.warn(c, "unexported field contains tagged fields, which are ignored")
+			}
+			if config.allocNested {
+				return allocate(config, c, allocating, &n)
+			}
 			return nil
 		}
+		if config.onlyKeys != nil {
+			if _, ok := config.onlyKeys[key]; !ok {
+				config.tracef("skipped: key not selected")
+				return nil
+			}
+		}
 
-		val, err := config.looker(key)
-		if err != nil {
-			return &unmarshalError{err, c}
+		if err := config.ctx.Err(); err != nil {
+			return err
 		}
+		config.checkSecret(c)
 
-		if val == nil {
-			if defval == nil {
+		if lazy, ok := isLazy(c.value); ok {
+			config.use(key)
+			cur := *c
+			lazy.bindLazy(func(v reflect.Value) error {
+				config.lazyMu.Lock()
+				defer config.lazyMu.Unlock()
+				val, _, _, err := config.resolveField(&cur, cur.info)
+				if err != nil {
+					return config.fieldError(CodeLookupFailure, err, &cur)
+				}
+				if val == nil {
+					return nil
+				}
+				if err := config.setField(cur.info, v, *val, nil); err != nil {
+					return config.parseError(err, &cur, *val)
+				}
+				return nil
+			})
+			n++
+			config.tracef("lazy: looked up when first used")
+			return errSkipField
+		}
+
+		if _, ok := c.info.mods["oneof"]; ok {
+			m, err := config.decodeUnion(c, allocating)
+			n += m
+			if err != nil {
+				return err
+			}
+			return errSkipField
+		}
+		if _, ok := c.info.mods["envmap"]; ok {
+			found, err := config.decodeEnvMap(c)
+			if err != nil {
+				return err
+			}
+			if found {
+				n++
 				return nil
 			}
-			val = defval
+			if _, ok := c.info.mods["required"]; ok {
+				return config.fieldError(CodeMissingRequired, errMissingRequired, c)
+			}
+			config.tracef("no keys with the prefix found: left unchanged")
+			config.record(c, key, SourceNone, "", "")
+			return nil
 		}
 
-		err = setValue(config, c.value, *val)
+		val, source, layer, err := config.resolveField(c, c.info)
 		if err != nil {
-			return &unmarshalError{err, c}
+			return config.fieldError(CodeLookupFailure, err, c)
+		}
+		if source != SourceEnv && config.keepExisting && !c.value.IsZero() {
+			config.tracef("kept existing value")
+			config.recordExisting(c)
+			return nil
+		}
+		if val == nil {
+			if config.resetUnset && c.value.CanSet() {
+				config.tracef("not found, and no default: reset to zero value")
+				c.value.Set(reflect.Zero(c.value.Type()))
+			} else {
+				config.tracef("not found, and no default: left unchanged")
+			}
+			config.record(c, key, SourceNone, "", "")
+			return nil
 		}
 
+		err = config.setField(c.info, c.value, *val, &c.info.kinds)
+		if err != nil {
+			return config.parseError(err, c, *val)
+		}
+
+		config.record(c, key, source, layer, *val)
+		config.traceSet(c)
+		if source == SourceEnv {
+			config.checkFound(c, *val)
+		}
+		n++
 		return nil
-	})
+	}
+	if config.trace != nil {
+		visitor = config.traced(visitor)
+	}
+	if config.collect {
+		visitor = config.collecting(visitor)
+	}
+	err := visit(in, path, visitor)
+	return n, err
+}
+
+// resolve looks up the value for the field, returning its default, from
+// the Defaults option or its tag, if it's not found, or nil if it has no
+// default.
+func (config *config) resolve(info *fieldInfo) (*string, Source, string, error) {
+	val, layer, useDefault, err := config.lookupField(info)
+	if err == nil && val != nil && *val == "" {
+		val, err = config.checkEmpty(val)
+		if val == nil {
+			config.tracef("empty value treated as unset")
+		}
+	}
+	if err != nil {
+		return nil, SourceNone, "", err
+	}
+	if _, ok := info.mods["encrypted"]; ok && val != nil {
+		plain, err := config.decrypt(*val)
+		if err != nil {
+			return nil, SourceNone, "", err
+		}
+		val = &plain
+	}
+	if val == nil {
+		if _, ok := info.mods["required"]; ok {
+			return nil, SourceNone, "", config.missingRequired(info.key)
+		}
+		defval, from := info.defval, "tag default"
+		if v, ok := config.defaults[info.key]; ok {
+			defval, from = &v, "default from Defaults"
+		}
+		if defval == nil || !useDefault {
+			if defval != nil {
+				config.tracef("%v not allowed by source modifier", from)
+			}
+			return nil, SourceNone, "", nil
+		}
+		if config.traceLines != nil {
+			shown := *defval
+			if info.secret {
+				shown = config.redact(info.key, shown)
+			}
+			config.tracef("not found: using %v %q", from, shown)
+		}
+		def, err := config.expand(*defval)
+		if err != nil {
+			return nil, SourceNone, "", err
+		}
+		return &def, SourceDefault, "", nil
+	}
+	return val, SourceEnv, layer, nil
+}
+
+// errMissingRequired is returned for a required field whose key isn't
+// found.
+var errMissingRequired = errors.New("missing required key")
+
+// lookupField looks up the field's key, with the looker named by its from
+// modifier, or in the layers named by its source modifier, if it has
+// either, and returns whether the modifier allows the use
+// of the tag default.
+func (config *config) lookupField(info *fieldInfo) (*string, string, bool, error) {
+	if from, ok := info.mods["from"]; ok {
+		val, layer, err := config.lookupFrom(info.key, from)
+		return val, layer, true, err
+	}
+	sources, ok := info.mods["source"]
+	if !ok {
+		val, layer, err := config.lookup(info.key)
+		return val, layer, true, err
+	}
+	names, useDefault, err := parseSources(sources)
+	if err == nil {
+		err = config.checkSources(names)
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	val, layer, err := config.lookupSources(info.key, names)
+	return val, layer, useDefault, err
+}
+
+// allocate decodes into a new struct for a nil struct pointer field at the
+// cursor, and sets the field to it if any of the struct's fields were set.
+func allocate(config *config, c *cursor, allocating []reflect.Type, n *int) error {
+	if c.value.Kind() != reflect.Ptr || !c.value.IsNil() || !c.value.CanSet() {
+		return nil
+	}
+	t := c.value.Type().Elem()
+	if t.Kind() != reflect.Struct || !hasTaggedFields(t) {
+		return nil
+	}
+	for _, a := range allocating {
+		if a == t {
+			return nil
+		}
+	}
+
+	recorded := config.recorded()
+	p := reflect.New(t)
+	m, err := decode(config, p.Interface(), c.path, append(allocating, t))
+	if err != nil {
+		return err
+	}
+	if m > 0 {
+		c.value.Set(p)
+		*n += m
+	} else if config.result != nil {
+		config.result.Fields = config.result.Fields[:recorded]
+	}
+
+	// The new struct has already been visited.
+	return errSkipField
+}
+
+// structHasTags returns true if t is a struct type, or pointer to struct
+// type, for which hasTaggedFields is true.
+func structHasTags(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && hasTaggedFields(t)
+}
+
+// hasTaggedFields returns true if the struct type t, or any struct
+// reachable from its exported fields, has a field with an env tag.
+func hasTaggedFields(t reflect.Type) bool {
+	return hasTagged(t, make(map[reflect.Type]struct{}))
+}
+
+func hasTagged(t reflect.Type, seen map[reflect.Type]struct{}) bool {
+	if _, ok := seen[t]; ok {
+		return false
+	}
+	seen[t] = struct{}{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Tag.Get(tagName) != "" {
+			return true
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && hasTagged(ft, seen) {
+			return true
+		}
+	}
+	return false
 }
 
 // A LookupEnvFunc retrieves the value of the environment variable
@@ -92,17 +553,64 @@ type LookupEnvFunc func(key string) (value *string, err error)
 func Looker(f LookupEnvFunc) Option {
 	return func(c *config) {
 		c.looker = f
+		c.keys = nil
 	}
 }
 
 // Map configures Unmarshal to use the given map for environment lookups.
 func Map(m map[string]string) Option {
-	return Looker(func(k string) (*string, error) {
+	return func(c *config) {
+		c.looker = lookupMap(m)
+		c.keys = func() []string {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			return keys
+		}
+	}
+}
+
+// lookupMap returns a LookupEnvFunc that looks up keys in m.
+func lookupMap(m map[string]string) LookupEnvFunc {
+	return func(k string) (*string, error) {
 		if v, ok := m[k]; ok {
 			return &v, nil
 		}
 		return nil, nil
-	})
+	}
+}
+
+// AllocateNested configures Unmarshal to allocate nil struct pointer fields
+// whose struct type contains tagged fields. The new struct is kept only if
+// at least one of its fields is set from the environment or a default.
+func AllocateNested() Option {
+	return func(c *config) {
+		c.allocNested = true
+	}
+}
+
+// SkipTypes configures Unmarshal to ignore struct fields of the given types,
+// or of pointers to the given types. Skipped fields are neither set nor
+// searched for nested tagged fields.
+func SkipTypes(types ...reflect.Type) Option {
+	return func(c *config) {
+		if c.skipTypes == nil {
+			c.skipTypes = make(map[reflect.Type]struct{})
+		}
+		for _, t := range types {
+			c.skipTypes[t] = struct{}{}
+		}
+	}
+}
+
+// Strict configures Unmarshal to return an error for tagged fields that it
+// would otherwise silently ignore, such as those within a struct held by an
+// unexported field.
+func Strict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
 }
 
 // DefaultsOnly configures Unmarshal to only set fields with a tag-defined
@@ -111,17 +619,20 @@ func DefaultsOnly() Option {
 	return Map(nil)
 }
 
-type setFunc func(val reflect.Value, s string) error
+// A setFunc sets val, found under key, to the value s.
+type setFunc func(val reflect.Value, key, s string) error
 
-// validateSetFunc returns ok if fn is a "func(*T, string) error", returning
-// reflect.Type T and the equivalent of fn that takes a reflect.Value of type T.
+// validateSetFunc returns ok if fn is a "func(*T, string) error" or a
+// "func(*T, string, string) error", returning reflect.Type T and the
+// equivalent of fn that takes a reflect.Value of type T.
 func validateSetFunc(fn interface{}) (argType reflect.Type, setFn setFunc, ok bool) {
 	fnValue := reflect.ValueOf(fn)
 	if fnValue.Kind() != reflect.Func {
 		return
 	}
 	fnType := fnValue.Type()
-	if !(fnType.NumIn() == 2 && !fnType.IsVariadic() && fnType.NumOut() == 1) {
+	nIn := fnType.NumIn()
+	if !((nIn == 2 || nIn == 3) && !fnType.IsVariadic() && fnType.NumOut() == 1) {
 		return
 	}
 	a0 := fnType.In(0)
@@ -129,8 +640,11 @@ func validateSetFunc(fn interface{}) (argType reflect.Type, setFn setFunc, ok bo
 		return
 	}
 
-	if fnType.In(1) != reflect.TypeOf((*string)(nil)).Elem() {
-		return
+	stringType := reflect.TypeOf((*string)(nil)).Elem()
+	for i := 1; i < nIn; i++ {
+		if fnType.In(i) != stringType {
+			return
+		}
 	}
 
 	errIface := reflect.TypeOf((*error)(nil)).Elem()
@@ -139,8 +653,12 @@ func validateSetFunc(fn interface{}) (argType reflect.Type, setFn setFunc, ok bo
 	}
 
 	argType = a0.Elem()
-	setFn = func(val reflect.Value, s string) error {
-		rets := fnValue.Call([]reflect.Value{val.Addr(), reflect.ValueOf(s)})
+	setFn = func(val reflect.Value, key, s string) error {
+		args := []reflect.Value{val.Addr(), reflect.ValueOf(s)}
+		if nIn == 3 {
+			args = []reflect.Value{val.Addr(), reflect.ValueOf(key), reflect.ValueOf(s)}
+		}
+		rets := fnValue.Call(args)
 		if rets[0].IsNil() {
 			return nil
 		}
@@ -152,6 +670,10 @@ func validateSetFunc(fn interface{}) (argType reflect.Type, setFn setFunc, ok bo
 
 // SetFunc takes a function of form "func(*T, string) error", and configures
 // Unmarshal to use that function to set the value of any type T's.
+//
+// The function may instead be of form "func(*T, key, value string) error",
+// in which case it's also passed the environment key that the value was
+// found under; for a tag default, that's the tag's key.
 func SetFunc(fn interface{}) Option {
 	return func(c *config) {
 		argType, setFn, ok := validateSetFunc(fn)
@@ -166,6 +688,62 @@ func SetFunc(fn interface{}) Option {
 	}
 }
 
+// registry holds the functions registered by RegisterSetter. The map is
+// replaced, rather than modified, by each registration, so that it can be
+// read without locking.
+var registry struct {
+	sync.Mutex
+	setFuncs atomic.Pointer[map[reflect.Type]setFunc]
+}
+
+// RegisterSetter configures every call to Unmarshal in the process to use
+// fn to set the value of any type T's, unless a function for T is
+// configured via SetFunc for that call. It's intended to be called from
+// init functions, such as by a package defining T.
+func RegisterSetter[T any](fn func(*T, string) error) {
+	registry.Lock()
+	defer registry.Unlock()
+	setFuncs := make(map[reflect.Type]setFunc)
+	if prev := registry.setFuncs.Load(); prev != nil {
+		for t, f := range *prev {
+			setFuncs[t] = f
+		}
+	}
+	setFuncs[reflect.TypeOf((*T)(nil)).Elem()] = func(val reflect.Value, key, s string) error {
+		return fn(val.Addr().Interface().(*T), s)
+	}
+	registry.setFuncs.Store(&setFuncs)
+}
+
+// registeredSetter returns the function registered for type t.
+func registeredSetter(t reflect.Type) (setFunc, bool) {
+	setFuncs := registry.setFuncs.Load()
+	if setFuncs == nil {
+		return nil, false
+	}
+	fn, ok := (*setFuncs)[t]
+	return fn, ok
+}
+
+// Implementations takes a nil pointer to an interface type I, and configures
+// Unmarshal to set tagged fields of type I using a function from impls. The
+// field's desired value selects the function, and the field is set to the
+// value the function returns. If that value is a pointer to struct, its
+// fields are then set in turn.
+func Implementations(iface interface{}, impls map[string]func() interface{}) Option {
+	return func(c *config) {
+		t := reflect.TypeOf(iface)
+		if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+			panic("expected a nil pointer to an interface type")
+		}
+
+		if c.impls == nil {
+			c.impls = make(map[reflect.Type]map[string]func() interface{})
+		}
+		c.impls[t.Elem()] = impls
+	}
+}
+
 // An Option is a functional option for Unmarshal.
 type Option func(*config)
 
@@ -184,63 +762,309 @@ func osLookup(key string) (*string, error) {
 	return nil, nil
 }
 
+// osKeys returns the names of the variables in the process environment.
+func osKeys() []string {
+	env := os.Environ()
+	keys := make([]string, 0, len(env))
+	for _, kv := range env {
+		if i := strings.Index(kv, "="); i > 0 {
+			keys = append(keys, kv[:i])
+		}
+	}
+	return keys
+}
+
+// defaultOptions holds the options set by SetDefaultOptions.
+var defaultOptions atomic.Pointer[[]Option]
+
+// SetDefaultOptions sets options that are applied by every later call to
+// Unmarshal, or to other functions that accept options, before the
+// options passed to the call. Each call replaces the options set by the
+// previous call. It's intended to be called during program
+// initialization, such as from a main package.
+func SetDefaultOptions(options ...Option) {
+	options = append([]Option(nil), options...)
+	defaultOptions.Store(&options)
+}
+
+// newConfig returns a config with the default options, and then the given
+// options, applied.
+func newConfig(options []Option) *config {
+	return applyOptions(defaults(), options)
+}
+
+// defaults returns the options set by SetDefaultOptions.
+func defaults() []Option {
+	if options := defaultOptions.Load(); options != nil {
+		return *options
+	}
+	return nil
+}
+
+// applyOptions returns a config with each list of options applied in turn.
+func applyOptions(lists ...[]Option) *config {
+	config := &config{
+		looker: osLookup,
+		keys:   osKeys,
+		ctx:    context.Background(),
+		lazyMu: new(sync.Mutex),
+	}
+	for _, options := range lists {
+		for _, option := range options {
+			option(config)
+		}
+	}
+	return config
+}
+
 type config struct {
-	looker   LookupEnvFunc
-	setFuncs map[reflect.Type]setFunc
+	looker        LookupEnvFunc
+	keys          func() []string
+	relaxed       map[string][]string
+	decrypter     Decrypter
+	workers       int
+	layers        []Layer
+	setFuncs      map[reflect.Type]setFunc
+	allocNested   bool
+	skipTypes     map[reflect.Type]struct{}
+	impls         map[reflect.Type]map[string]func() interface{}
+	only          []string
+	onlyKeys      map[string]struct{}
+	strict        bool
+	result        *Result
+	redactor      Redactor
+	allowKeys     []string
+	warnings      func(Warning)
+	warnSecrets   bool
+	keepExisting  bool
+	resetUnset    bool
+	defaults      map[string]string
+	emptyPolicy   EmptyPolicy
+	profile       string
+	keyVars       map[string]string
+	keyFuncs      []func(string, FieldInfo) string
+	used          map[string]struct{}
+	unused        []string
+	trace         io.Writer
+	errorFormat   func(*FieldError) string
+	errorValues   bool
+	collect       bool
+	errs          []*FieldError
+	traceLines    []string
+	profileFormat string
+	denyKeys      []string
+	mapSeps       *mapSeparators
+	lookers       map[string]LookupEnvFunc
+	transforms    []func(FieldInfo, string) (string, error)
+	ctx           context.Context
+
+	includeSecrets bool
+
+	// lazyMu serializes the lookups of Lazy fields, which may be made
+	// concurrently after Unmarshal returns, and share the config's state.
+	lazyMu *sync.Mutex
+	// plan holds the rewritten keys of the decoded type, if decoding with
+	// a Decoder.
+	plan *plan
+}
+
+const (
+	inScope = iota
+	aboveScope
+	outOfScope
+)
+
+// scope returns whether the field at path should be set, or only
+// searched for nested fields that should be set, or neither.
+func (c *config) scope(path string) int {
+	if len(c.only) == 0 {
+		return inScope
+	}
+	result := outOfScope
+	for _, p := range c.only {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return inScope
+		}
+		if strings.HasPrefix(p, path+".") {
+			result = aboveScope
+		}
+	}
+	return result
+}
+
+// skipped returns true if fields of type t should be ignored.
+func (c *config) skipped(t reflect.Type) bool {
+	if _, ok := c.skipTypes[t]; ok {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		_, ok := c.skipTypes[t.Elem()]
+		return ok
+	}
+	return false
 }
 
 const (
-	tagName = "env"
-	tagSep  = "="
+	tagName    = "env"
+	tagSep     = "="
+	optTagName = "envopt"
+	optSep     = ","
+	listSep    = ";"
 )
 
-// parseTag returns the environment key and possible default value
-// encoded in the field struct tag.
-func parseTag(c *cursor) (string, *string) {
-	tag := c.field.Tag.Get(tagName)
-	s := strings.SplitN(tag, tagSep, 2)
-	if len(s) == 1 {
-		return s[0], nil
+// modifiers lists the names allowed in an envopt tag.
+var modifiers = map[string]struct{}{
+	"csv":        {},
+	"deprecated": {},
+	"encrypted":  {},
+	"envmap":     {},
+	"from":       {},
+	"if":         {},
+	"layouts":    {},
+	"oneof":      {},
+	"required":   {},
+	"secret":     {},
+	"shellwords": {},
+	"source":     {},
+	"variant":    {},
+}
+
+// parseModifiers returns the modifiers encoded in the field's envopt struct
+// tag, a comma separated list of names, each optionally followed by "=" and
+// a value.
+func parseModifiers(field reflect.StructField) (map[string]string, error) {
+	tag := field.Tag.Get(optTagName)
+	if tag == "" {
+		return nil, nil
+	}
+	mods := make(map[string]string)
+	for _, m := range strings.Split(tag, optSep) {
+		s := strings.SplitN(strings.TrimSpace(m), tagSep, 2)
+		if _, ok := modifiers[s[0]]; !ok {
+			return nil, fmt.Errorf("unknown modifier: %q", s[0])
+		}
+		if len(s) == 1 {
+			mods[s[0]] = ""
+		} else {
+			mods[s[0]] = s[1]
+		}
 	}
-	return s[0], &s[1]
+	return mods, nil
 }
 
 type cursor struct {
 	structType reflect.Type
 	field      reflect.StructField
 	value      reflect.Value
+	path       string
+	info       *fieldInfo
 }
 
-// visit executes visitor on all reachable fields from its input struct.
-func visit(in interface{}, visitor func(*cursor) error) error {
-	prev := make(map[reflect.Value]struct{})
-	for q := []reflect.Value{reflect.ValueOf(in)} ; len(q) != 0 ; q = q[1:] {
-		structPtr, ok := settableStructPtr(q[0])
-		if !ok {
-			continue
-		}
-		if _, inPrev := prev[structPtr]; inPrev {
-			continue
+// errSkipField is returned by a visitor to prevent visit from descending
+// into the value of the field at the cursor.
+var errSkipField = errors.New("skip this field")
+
+// visit executes visitor on all reachable fields from its input struct,
+// depth first, in declaration order: after the visitor returns for a field,
+// the struct the field holds or points to, if any, is visited before the
+// next field. A struct reachable by several paths is only visited at the
+// first. The order depends only on the struct types and which pointers are
+// nil, so results, traces, and errors are listed in the same order on
+// every run. Each cursor's path is the dot separated list of field names
+// leading to the field, starting from base.
+func visit(in interface{}, base string, visitor func(*cursor) error) error {
+	// A struct without nested structs needs no visited set.
+	if structPtr, ok := settableStructPtr(reflect.ValueOf(in)); ok {
+		if info := cachedStruct(structPtr.Type()); info.flat {
+			return visitFields(structPtr, info, base, visitor, nil)
 		}
-		prev[structPtr] = struct{}{}
+	}
 
-		structType := structPtr.Type()
-		n := structType.NumField()
-		for i := 0; i < n; i++ {
-			field := structType.Field(i)
-			value := structPtr.Field(i)
-			c := cursor{structType, field, value}
-			if err := visitor(&c); err != nil {
+	st := visitStates.Get().(*visitState)
+	defer st.release()
+	return st.visit(reflect.ValueOf(in), base, visitor)
+}
+
+// visit visits the fields of the struct that v holds or points to, if it
+// hasn't already been visited.
+func (st *visitState) visit(v reflect.Value, path string, visitor func(*cursor) error) error {
+	structPtr, ok := settableStructPtr(v)
+	if !ok {
+		return nil
+	}
+	vs := visitedStruct{structPtr.Addr().UnsafePointer(), structPtr.Type()}
+	if _, inPrev := st.prev[vs]; inPrev {
+		return nil
+	}
+	st.prev[vs] = struct{}{}
+
+	return visitFields(structPtr, cachedStruct(structPtr.Type()), path, visitor, func(c *cursor) error {
+		return st.visit(c.value, c.path, visitor)
+	})
+}
+
+// A visitedStruct identifies a struct, or another value, by address and
+// type, as a struct and its first field share an address.
+type visitedStruct struct {
+	ptr unsafe.Pointer
+	typ reflect.Type
+}
+
+// A visitState holds the scratch space used by visit, kept in visitStates
+// for reuse by later calls.
+type visitState struct {
+	prev map[visitedStruct]struct{}
+}
+
+var visitStates = sync.Pool{
+	New: func() interface{} {
+		return &visitState{prev: make(map[visitedStruct]struct{})}
+	},
+}
+
+// release clears the state, so it doesn't keep the visited values alive,
+// and returns it to visitStates.
+func (st *visitState) release() {
+	for v := range st.prev {
+		delete(st.prev, v)
+	}
+	visitStates.Put(st)
+}
+
+// visitFields executes visitor on the fields of the struct value, calling
+// descend, if not nil, for each field that visitor doesn't skip, before
+// moving on to the next field.
+func visitFields(structPtr reflect.Value, info *structInfo, path string, visitor func(*cursor) error, descend func(*cursor) error) error {
+	structType := structPtr.Type()
+	for i := range info.fields {
+		f := &info.fields[i]
+		c := cursor{structType, f.field, structPtr.Field(i), joinPath(path, f.field.Name), f}
+		if err := visitor(&c); err != nil {
+			if err == errSkipField {
+				continue
+			}
+			return err
+		}
+		if descend != nil {
+			if err := descend(&c); err != nil {
 				return err
 			}
-			q = append(q, value)
 		}
 	}
-
 	return nil
 }
 
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
 func settableStructPtr(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
 	if v.Kind() == reflect.Ptr && !v.IsNil() {
 		v = v.Elem()
 	}
@@ -250,8 +1074,24 @@ func settableStructPtr(v reflect.Value) (reflect.Value, bool) {
 	return reflect.Value{}, false
 }
 
-// Set the struct field at the cursor to the given string.
-func setValue(cfg *config, value reflect.Value, str string) error {
+// setField sets the value of the field to str, by setValue, unless one of
+// its modifiers gives the format of the value.
+func (config *config) setField(info *fieldInfo, value reflect.Value, str string, kinds *setterKinds) error {
+	if _, ok := info.mods["shellwords"]; ok {
+		return setShellWords(config, value, info.key, str)
+	}
+	if _, ok := info.mods["csv"]; ok {
+		return setCSV(config, value, info.key, str)
+	}
+	if layouts, ok := info.mods["layouts"]; ok {
+		return setLayouts(value, layouts, str)
+	}
+	return setValue(config, value, info.key, str, kinds)
+}
+
+// Set the struct field at the cursor to the given string. The kinds are
+// those of the value's type, or nil if not already known.
+func setValue(cfg *config, value reflect.Value, key, str string, kinds *setterKinds) error {
 	if value.Kind() == reflect.Ptr {
 		if value.IsNil() {
 			value.Set(reflect.New(value.Type().Elem()))
@@ -264,11 +1104,28 @@ func setValue(cfg *config, value reflect.Value, str string) error {
 	}
 
 	if setfn, ok := cfg.setFuncs[value.Type()]; ok {
-		return setfn(value, str)
+		return setfn(value, key, str)
 	}
 
-	if s, ok := isSetter(value); ok {
-		return s.Set(str)
+	if setfn, ok := registeredSetter(value.Type()); ok {
+		return setfn(value, key, str)
+	}
+
+	if kinds == nil {
+		k := typeSetterKinds(value.Type())
+		kinds = &k
+	}
+
+	if kinds.contextSetter {
+		if s, ok := isContextSetter(value); ok {
+			return s.SetContext(cfg.ctx, str)
+		}
+	}
+
+	if kinds.setter {
+		if s, ok := isSetter(value); ok {
+			return s.Set(str)
+		}
 	}
 
 	switch value.Kind() {
@@ -295,17 +1152,69 @@ func setValue(cfg *config, value reflect.Value, str string) error {
 		x, err := strconv.ParseBool(str)
 		value.SetBool(x)
 		return err
+
+	case reflect.Map:
+		return setMap(cfg, value, key, str)
+
+	case reflect.Interface:
+		impls, ok := cfg.impls[value.Type()]
+		if !ok {
+			break
+		}
+		fn, ok := impls[str]
+		if !ok {
+			return fmt.Errorf("unknown implementation: %q", str)
+		}
+		x := reflect.ValueOf(fn())
+		if !x.IsValid() || !x.Type().AssignableTo(value.Type()) {
+			return fmt.Errorf("implementation %q does not implement %v", str, value.Type().String())
+		}
+		value.Set(x)
+		return nil
 	}
 
-	return fmt.Errorf("unsupported type: %v", value.Type().String())
+	return fmt.Errorf("%w: %v", errUnsupportedType, value.Type().String())
 }
 
 type setter interface {
 	Set(string) error
 }
 
+var (
+	setterType        = reflect.TypeOf((*setter)(nil)).Elem()
+	contextSetterType = reflect.TypeOf((*contextSetter)(nil)).Elem()
+)
+
+// setterKinds records the setter interfaces implemented by a pointer to a
+// type, so that setValue only converts values that implement them to
+// interfaces.
+type setterKinds struct {
+	setter        bool
+	contextSetter bool
+}
+
+// typeSetterKinds returns the setterKinds of type t, or of the type t
+// points to.
+func typeSetterKinds(t reflect.Type) setterKinds {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pt := reflect.PtrTo(t)
+	return setterKinds{pt.Implements(setterType), pt.Implements(contextSetterType)}
+}
+
 func isSetter(value reflect.Value) (setter, bool) {
 	i := value.Addr().Interface()
 	s, ok := i.(setter)
 	return s, ok
 }
+
+type contextSetter interface {
+	SetContext(context.Context, string) error
+}
+
+func isContextSetter(value reflect.Value) (contextSetter, bool) {
+	i := value.Addr().Interface()
+	s, ok := i.(contextSetter)
+	return s, ok
+}
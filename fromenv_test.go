@@ -5,8 +5,12 @@
 package fromenv
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -66,6 +70,21 @@ func TestVisitLoop(t *testing.T) {
 	err = Unmarshal(&s1, Map(env))
 	require.NoError(t, err)
 	require.Equal(t, s1.Str1, "k1-val")
+	require.Equal(t, s2.Str1, "k1-val")
+
+	// A struct and its first field share an address, but both are visited.
+	type Inner struct {
+		Str1 string `env:"k1"`
+	}
+	type Outer struct {
+		In  Inner
+		Out *Outer
+	}
+	var o Outer
+	o.Out = &o
+	err = Unmarshal(&o, Map(env))
+	require.NoError(t, err)
+	require.Equal(t, o.In.Str1, "k1-val")
 }
 
 func TestTypeLogic(t *testing.T) {
@@ -145,6 +164,17 @@ func TestTypeLogic(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, s9.unexportedS8.S7.S7str)
 	require.Empty(t, s9.unexportedS8.S8str)
+
+	err = Unmarshal(&s9, Map(env10), Strict())
+	require.EqualError(t, err, "unexported field contains tagged fields: field unexportedS8 (struct) in struct S9")
+
+	type S10 struct {
+		unexportedS7ptr *S7
+		unexportedInt   int
+	}
+	var s10 S10
+	err = Unmarshal(&s10, Map(env10), Strict())
+	require.EqualError(t, err, "unexported field contains tagged fields: field unexportedS7ptr (ptr) in struct S10")
 }
 
 func TestRealEnvironment(t *testing.T) {
@@ -373,6 +403,8 @@ func TestSetFunc(t *testing.T) {
 			func(x int, y string) error { return nil },
 			func(x *int, y int) error { return nil },
 			func(x *int, y string) int { return 0 },
+			func(x *int, y string, z int) error { return nil },
+			func(x *int, y ...string) error { return nil },
 		}
 		b0 := struct{}{}
 		for i := range badfuncs {
@@ -413,6 +445,35 @@ func TestSetFunc(t *testing.T) {
 		require.Regexp(t, ".*invalid duration.*field D", err)
 	})
 
+	t.Run("keyed", func(t *testing.T) {
+		t.Parallel()
+
+		keyedSetter := func(d *time.Duration, key, s string) error {
+			x, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("%v must be a duration", key)
+			}
+			*d = x
+			return nil
+		}
+
+		type S1 struct {
+			D time.Duration `env:"k1=1s"`
+		}
+
+		var s1 S1
+		err := Unmarshal(&s1, Map(map[string]string{"k1": "5s"}), SetFunc(keyedSetter))
+		require.NoError(t, err)
+		require.Equal(t, 5*time.Second, s1.D)
+
+		err = Unmarshal(&s1, DefaultsOnly(), SetFunc(keyedSetter))
+		require.NoError(t, err)
+		require.Equal(t, time.Second, s1.D)
+
+		err = Unmarshal(&s1, Map(map[string]string{"k1": "x"}), SetFunc(keyedSetter))
+		require.EqualError(t, err, "k1 must be a duration: field D (int64) in struct S1")
+	})
+
 	t.Run("pointer", func(t *testing.T) {
 		t.Parallel()
 
@@ -437,3 +498,412 @@ func TestSetFunc(t *testing.T) {
 	})
 
 }
+
+func TestAllocateNested(t *testing.T) {
+	t.Parallel()
+
+	type S1 struct {
+		Str1 string `env:"k1"`
+	}
+	type S2 struct {
+		Str2 string `env:"k2=k2-default"`
+	}
+	type S3 struct {
+		S3ptr *S3
+		Str3  string `env:"k3"`
+	}
+	type S struct {
+		S1ptr   *S1
+		S1again *S1
+		S2ptr   *S2
+		S3ptr   *S3
+		notag   *struct{ X int }
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"k1": "k1-val", "k3": "k3-val"}), AllocateNested())
+	require.NoError(t, err)
+	require.NotNil(t, s.S1ptr)
+	require.Equal(t, "k1-val", s.S1ptr.Str1)
+	require.NotNil(t, s.S1again)
+	require.NotNil(t, s.S2ptr)
+	require.Equal(t, "k2-default", s.S2ptr.Str2)
+	require.Nil(t, s.notag)
+
+	// Recursive types are allocated only one level deep.
+	require.NotNil(t, s.S3ptr)
+	require.Equal(t, "k3-val", s.S3ptr.Str3)
+	require.Nil(t, s.S3ptr.S3ptr)
+
+	var s2 S
+	err = Unmarshal(&s2, Map(nil), AllocateNested())
+	require.NoError(t, err)
+	require.Nil(t, s2.S1ptr)
+	require.NotNil(t, s2.S2ptr)
+	require.Nil(t, s2.S3ptr)
+}
+
+func TestSkipTypes(t *testing.T) {
+	t.Parallel()
+
+	type Heavy struct {
+		Str1 string `env:"k1"`
+	}
+	type S struct {
+		H    Heavy
+		Hptr *Heavy
+		D    time.Duration `env:"k2"`
+		Str3 string        `env:"k3"`
+	}
+
+	env := map[string]string{
+		"k1": "k1-val",
+		"k2": "not-a-duration",
+		"k3": "k3-val",
+	}
+	s := S{Hptr: &Heavy{}}
+	err := Unmarshal(&s, Map(env), SkipTypes(reflect.TypeOf(Heavy{}), reflect.TypeOf(time.Duration(0))))
+	require.NoError(t, err)
+	require.Empty(t, s.H.Str1)
+	require.Empty(t, s.Hptr.Str1)
+	require.Zero(t, s.D)
+	require.Equal(t, "k3-val", s.Str3)
+}
+
+type testStorage interface {
+	Driver() string
+}
+
+type testDiskStorage struct {
+	Path string `env:"DISK_PATH=/var/lib"`
+}
+
+func (*testDiskStorage) Driver() string { return "disk" }
+
+type testS3Storage struct {
+	Bucket string `env:"S3_BUCKET"`
+}
+
+func (*testS3Storage) Driver() string { return "s3" }
+
+func TestImplementations(t *testing.T) {
+	t.Parallel()
+
+	impls := Implementations((*testStorage)(nil), map[string]func() interface{}{
+		"disk": func() interface{} { return &testDiskStorage{} },
+		"s3":   func() interface{} { return &testS3Storage{} },
+		"bad":  func() interface{} { return 1 },
+	})
+
+	type S struct {
+		Storage testStorage `env:"STORAGE_DRIVER=disk"`
+	}
+
+	var s1 S
+	err := Unmarshal(&s1, Map(nil), impls)
+	require.NoError(t, err)
+	require.Equal(t, "disk", s1.Storage.Driver())
+	require.Equal(t, "/var/lib", s1.Storage.(*testDiskStorage).Path)
+
+	var s2 S
+	env := map[string]string{
+		"STORAGE_DRIVER": "s3",
+		"S3_BUCKET":      "b1",
+	}
+	err = Unmarshal(&s2, Map(env), impls)
+	require.NoError(t, err)
+	require.Equal(t, "b1", s2.Storage.(*testS3Storage).Bucket)
+
+	var s3 S
+	err = Unmarshal(&s3, Map(map[string]string{"STORAGE_DRIVER": "gcs"}), impls)
+	require.EqualError(t, err, "unknown implementation: \"gcs\": field Storage (interface) in struct S")
+
+	var s4 S
+	err = Unmarshal(&s4, Map(map[string]string{"STORAGE_DRIVER": "bad"}), impls)
+	require.EqualError(t, err, "implementation \"bad\" does not implement fromenv.testStorage: field Storage (interface) in struct S")
+
+	require.Panics(t, func() { Implementations(testStorage(nil), nil)(&config{}) })
+}
+
+func TestUnmarshalPath(t *testing.T) {
+	t.Parallel()
+
+	type Redis struct {
+		Addr string `env:"REDIS_ADDR"`
+		DB   int    `env:"REDIS_DB"`
+	}
+	type S struct {
+		Name  string `env:"NAME"`
+		Redis Redis
+		Cache *Redis
+	}
+
+	env := map[string]string{
+		"NAME":       "name-val",
+		"REDIS_ADDR": "redis:6379",
+		"REDIS_DB":   "2",
+	}
+
+	var s1 S
+	err := UnmarshalPath(&s1, "Redis", Map(env))
+	require.NoError(t, err)
+	require.Empty(t, s1.Name)
+	require.Equal(t, Redis{"redis:6379", 2}, s1.Redis)
+
+	var s2 S
+	err = UnmarshalPath(&s2, "Redis.Addr", Map(env))
+	require.NoError(t, err)
+	require.Equal(t, Redis{Addr: "redis:6379"}, s2.Redis)
+
+	var s3 S
+	err = UnmarshalPath(&s3, "Cache.DB", Map(env), AllocateNested())
+	require.NoError(t, err)
+	require.Empty(t, s3.Redis.Addr)
+	require.Equal(t, &Redis{DB: 2}, s3.Cache)
+
+	err = UnmarshalPath(&s3, "Redis.Port", Map(env))
+	require.EqualError(t, err, "unknown field path: Redis.Port")
+	err = UnmarshalPath(&s3, "Name.Len", Map(env))
+	require.EqualError(t, err, "unknown field path: Name.Len")
+}
+
+func TestVisitOrder(t *testing.T) {
+	t.Parallel()
+
+	type Leaf struct {
+		A string `env:"LEAF_A"`
+		B string `env:"LEAF_B"`
+	}
+	type Mid struct {
+		X    string `env:"MID_X"`
+		Leaf *Leaf
+		Y    string `env:"MID_Y"`
+	}
+	type S struct {
+		First  string `env:"FIRST"`
+		Mid    Mid
+		Shared *Leaf
+		Last   string `env:"LAST"`
+	}
+
+	s := S{Mid: Mid{Leaf: &Leaf{}}}
+	s.Shared = s.Mid.Leaf
+	var paths []string
+	for i := 0; i < 3; i++ {
+		var result Result
+		require.NoError(t, Unmarshal(&s, Map(nil), Record(&result)))
+		paths = paths[:0]
+		for _, f := range result.Fields {
+			paths = append(paths, f.Path)
+		}
+		require.Equal(t, []string{"First", "Mid.X", "Mid.Leaf.A", "Mid.Leaf.B", "Mid.Y", "Last"}, paths)
+	}
+}
+
+func TestOnly(t *testing.T) {
+	t.Parallel()
+
+	type Redis struct {
+		Addr string `env:"REDIS_ADDR" envopt:"required"`
+	}
+	type HTTP struct {
+		Port int `env:"HTTP_PORT=8080"`
+	}
+	type DB struct {
+		URL string `env:"DB_URL" envopt:"required"`
+	}
+	type S struct {
+		Name  string `env:"NAME"`
+		Redis Redis
+		HTTP  HTTP
+		DB    DB
+	}
+
+	var looked []string
+	var mu sync.Mutex
+	looker := func(key string) (*string, error) {
+		mu.Lock()
+		looked = append(looked, key)
+		mu.Unlock()
+		return lookupMap(map[string]string{"NAME": "n", "REDIS_ADDR": "redis:6379"})(key)
+	}
+
+	var s S
+	err := Unmarshal(&s, Looker(looker), Only("Redis", "HTTP"))
+	require.NoError(t, err)
+	require.Equal(t, S{Redis: Redis{"redis:6379"}, HTTP: HTTP{8080}}, s)
+	require.ElementsMatch(t, []string{"REDIS_ADDR", "HTTP_PORT"}, looked)
+
+	looked = nil
+	s = S{}
+	err = Unmarshal(&s, Looker(looker), Only("Redis"), Only("Name"), ConcurrentLookups(4))
+	require.NoError(t, err)
+	require.Equal(t, S{Name: "n", Redis: Redis{"redis:6379"}}, s)
+	require.ElementsMatch(t, []string{"NAME", "REDIS_ADDR"}, looked)
+
+	err = Unmarshal(&s, Looker(looker), Only("DB"))
+	require.EqualError(t, err, "missing required key: field URL (string) in struct DB")
+
+	err = Unmarshal(&s, noLookup(), Only("Rdis"))
+	require.EqualError(t, err, "unknown field path: Rdis")
+}
+
+func TestModifiers(t *testing.T) {
+	t.Parallel()
+
+	type S1 struct {
+		Str1 string `env:"k1" envopt:"secret"`
+	}
+	var s1 S1
+	err := Unmarshal(&s1, Map(map[string]string{"k1": "k1-val"}))
+	require.NoError(t, err)
+	require.Equal(t, "k1-val", s1.Str1)
+
+	type S2 struct {
+		Str1 string `env:"k1" envopt:"secret,bogus=1"`
+	}
+	var s2 S2
+	err = Unmarshal(&s2, Map(nil))
+	require.EqualError(t, err, "unknown modifier: \"bogus\": field Str1 (string) in struct S2")
+}
+
+func TestUnmarshalKeys(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Str1 string `env:"k1"`
+		Str2 string `env:"k2=k2-default"`
+		Str3 string `env:"k3"`
+	}
+
+	env := map[string]string{
+		"k1": "k1-val",
+		"k3": "k3-val",
+	}
+	var s S
+	err := UnmarshalKeys(&s, []string{"k1", "k2"}, Map(env))
+	require.NoError(t, err)
+	require.Equal(t, S{Str1: "k1-val", Str2: "k2-default"}, s)
+}
+
+func TestDefaultOptions(t *testing.T) {
+	// Default options affect all tests, so this test isn't parallel.
+	defer SetDefaultOptions()
+
+	type S struct {
+		Str1 string        `env:"k1"`
+		D    time.Duration `env:"k2"`
+	}
+
+	durSetter := func(d *time.Duration, s string) error {
+		x, err := time.ParseDuration(s)
+		*d = x
+		return err
+	}
+	SetDefaultOptions(Map(map[string]string{"k1": "default-map", "k2": "1s"}), SetFunc(durSetter))
+
+	var s1 S
+	err := Unmarshal(&s1)
+	require.NoError(t, err)
+	require.Equal(t, S{"default-map", time.Second}, s1)
+
+	// Per-call options are applied after the defaults.
+	var s2 S
+	err = Unmarshal(&s2, Map(map[string]string{"k1": "call-map"}))
+	require.NoError(t, err)
+	require.Equal(t, S{Str1: "call-map"}, s2)
+
+	SetDefaultOptions()
+	var s3 S
+	err = Unmarshal(&s3, Map(map[string]string{"k2": "1s"}))
+	require.Error(t, err)
+}
+
+type testRegistered struct {
+	s string
+}
+
+func init() {
+	RegisterSetter(func(r *testRegistered, s string) error {
+		if s == "" {
+			return errors.New("empty testRegistered")
+		}
+		r.s = "registered:" + s
+		return nil
+	})
+}
+
+func TestRegisterSetter(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		R    testRegistered  `env:"k1"`
+		Rptr *testRegistered `env:"k1"`
+	}
+
+	var s1 S
+	err := Unmarshal(&s1, Map(map[string]string{"k1": "x"}))
+	require.NoError(t, err)
+	require.Equal(t, "registered:x", s1.R.s)
+	require.Equal(t, "registered:x", s1.Rptr.s)
+
+	var s2 S
+	err = Unmarshal(&s2, Map(map[string]string{"k1": ""}))
+	require.EqualError(t, err, "empty testRegistered: field R (struct) in struct S")
+
+	// A SetFunc option overrides the registered function.
+	callSetter := func(r *testRegistered, s string) error {
+		r.s = "call:" + s
+		return nil
+	}
+	var s3 S
+	err = Unmarshal(&s3, Map(map[string]string{"k1": "x"}), SetFunc(callSetter))
+	require.NoError(t, err)
+	require.Equal(t, "call:x", s3.R.s)
+}
+
+type testCtxSetter struct {
+	v string
+}
+
+type testCtxKey struct{}
+
+func (tcs *testCtxSetter) SetContext(ctx context.Context, s string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tcs.v, _ = ctx.Value(testCtxKey{}).(string)
+	tcs.v += s
+	return nil
+}
+
+func (tcs *testCtxSetter) Set(s string) error {
+	return errors.New("Set called instead of SetContext")
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		V testCtxSetter `env:"k1"`
+	}
+
+	env := Map(map[string]string{"k1": "-val"})
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "ctx")
+
+	var s1 S
+	err := UnmarshalContext(ctx, &s1, env)
+	require.NoError(t, err)
+	require.Equal(t, "ctx-val", s1.V.v)
+
+	var s2 S
+	err = Unmarshal(&s2, env)
+	require.NoError(t, err)
+	require.Equal(t, "-val", s2.V.v)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	var s3 S
+	err = UnmarshalContext(canceled, &s3, env)
+	require.Equal(t, context.Canceled, err)
+}
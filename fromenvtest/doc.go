@@ -0,0 +1,21 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package fromenvtest provides utilities for testing code that uses the
+// fromenv package.
+package fromenvtest
+
+import (
+	"github.com/alfred-landrum/fromenv"
+)
+
+// Env returns a lookup function that looks up keys in m.
+func Env(m map[string]string) fromenv.LookupEnvFunc {
+	return func(key string) (*string, error) {
+		if v, ok := m[key]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// ParseEnv parses a .env file: lines of KEY=value, optionally preceded by
+// "export", with blank lines and lines starting with '#' ignored. A value
+// may be double quoted, with Go escapes, or single quoted, taken as is.
+func ParseEnv(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("line %d: missing KEY=value", n)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch {
+		case strings.HasPrefix(value, `"`):
+			v, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value", n)
+			}
+			value = v
+		case strings.HasPrefix(value, "'"):
+			if len(value) < 2 || !strings.HasSuffix(value, "'") {
+				return nil, fmt.Errorf("line %d: invalid quoted value", n)
+			}
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+// CheckExample checks that the .env file at path, such as a committed
+// .env.example, documents the struct type of in, a struct or pointer to
+// one. It returns an error describing each problem found:
+//
+// * A key tagged in the struct that's missing from the file.
+//
+// * A key in the file that's not tagged in the struct, or collected by an
+// envmap field.
+//
+// * A tag default that fails to parse.
+//
+// * A non-empty value in the file that fails to parse. Empty values are
+// taken as placeholders, including for required keys.
+//
+// Keys are those described by fromenv.SchemaOf, including the keys of
+// structs that nil pointers would point to. The options are used when
+// rewriting keys and parsing values, such as to configure KeyVars or
+// SetFunc.
+func CheckExample(path string, in interface{}, options ...fromenv.Option) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	example, err := ParseEnv(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	t := reflect.TypeOf(in)
+	if t == nil || (t.Kind() == reflect.Ptr && t.Elem().Kind() != reflect.Struct) ||
+		(t.Kind() != reflect.Ptr && t.Kind() != reflect.Struct) {
+		return errors.New("passed non-struct")
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema, err := schemaOf(t, options)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var problems []string
+	var prefixes []string
+	keys := make(map[string]bool)
+	for _, v := range schema.Vars {
+		if v.Prefix {
+			prefixes = append(prefixes, v.Key)
+			continue
+		}
+		keys[v.Key] = true
+		if _, ok := example[v.Key]; !ok {
+			problems = append(problems, "missing key "+v.Key)
+		}
+	}
+	var stale []string
+	for key := range example {
+		if !keys[key] && !hasPrefix(key, prefixes) {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		problems = append(problems, "stale key "+key)
+	}
+
+	// Missing required keys are reported as missing keys above, or are
+	// listed in the example with an empty placeholder value.
+	if err := decodeExample(t, options, fromenv.DefaultsOnly()); err != nil {
+		problems = append(problems, "invalid default: "+err.Error())
+	}
+	values := make(map[string]string)
+	for k, v := range example {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	if err := decodeExample(t, options, fromenv.Map(values)); err != nil {
+		problems = append(problems, "invalid example value: "+err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s", path, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// hasPrefix returns true if key starts with one of the prefixes.
+func hasPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeExample decodes a new struct of type t with options and lookup,
+// allocating its nested structs, and returns its errors, other than those
+// for missing required keys.
+func decodeExample(t reflect.Type, options []fromenv.Option, lookup fromenv.Option) error {
+	options = append(append([]fromenv.Option(nil), options...), lookup, fromenv.AllocateNested(), fromenv.CollectErrors())
+	err := fromenv.Unmarshal(reflect.New(t).Interface(), options...)
+	var list fromenv.ErrorList
+	if !errors.As(err, &list) {
+		return err
+	}
+	var kept fromenv.ErrorList
+	for _, e := range list {
+		if e.Code != fromenv.CodeMissingRequired {
+			kept = append(kept, e)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return kept[0]
+	}
+	return kept
+}
+
+// AssertExample reports an error to t if CheckExample fails.
+func AssertExample(t testing.TB, path string, in interface{}, options ...fromenv.Option) {
+	t.Helper()
+	if err := CheckExample(path, in, options...); err != nil {
+		t.Error(err)
+	}
+}
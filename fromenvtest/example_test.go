@@ -0,0 +1,83 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnv(t *testing.T) {
+	t.Parallel()
+
+	env, err := ParseEnv(strings.NewReader(`
+# comment
+A=1
+export B = "two words\n"
+C='it''s'
+D=
+E='single'
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"A": "1", "B": "two words\n", "C": "it''s", "D": "", "E": "single"}, env)
+
+	_, err = ParseEnv(strings.NewReader("A=1\nB\n"))
+	require.EqualError(t, err, "line 2: missing KEY=value")
+	_, err = ParseEnv(strings.NewReader(`A="x`))
+	require.EqualError(t, err, "line 1: invalid quoted value")
+}
+
+func TestCheckExample(t *testing.T) {
+	t.Parallel()
+
+	AssertExample(t, "testdata/example.env", &goldenConfig{})
+	AssertExample(t, "testdata/example.env", goldenConfig{})
+
+	err := CheckExample("testdata/stale.env", &goldenConfig{})
+	require.EqualError(t, err, "testdata/stale.env: missing key GREETING; missing key PASSWORD; "+
+		"stale key OLD_SETTING; "+
+		`invalid example value: strconv.ParseInt: parsing "eighty": invalid syntax: field Port (int) in struct goldenConfig`)
+
+	type badDefault struct {
+		Name string `env:"NAME"`
+		Port int    `env:"PORT=http"`
+	}
+	err = CheckExample("testdata/stale.env", &badDefault{})
+	require.Contains(t, err.Error(), `invalid default: strconv.ParseInt: parsing "http"`)
+
+	type withRequired struct {
+		Name  string `env:"NAME" envopt:"required"`
+		Token string `env:"TOKEN" envopt:"required,secret"`
+		Port  int    `env:"PORT=80" envopt:"required"`
+	}
+	example := filepath.Join(t.TempDir(), "required.env")
+	require.NoError(t, os.WriteFile(example, []byte("NAME=app\nTOKEN=\nPORT=\n"), 0o600))
+	require.NoError(t, CheckExample(example, &withRequired{}))
+	require.NoError(t, os.WriteFile(example, []byte("NAME=app\nTOKEN=\nPORT=x\n"), 0o600))
+	err = CheckExample(example, &withRequired{})
+	require.EqualError(t, err, example+`: invalid example value: strconv.ParseInt: parsing "x": invalid syntax: field Port (int) in struct withRequired`)
+
+	type inner struct {
+		Port int `env:"INNER_PORT=http"`
+	}
+	type shaped struct {
+		Name   string            `env:"${APP}_NAME"`
+		Labels map[string]string `env:"LABEL" envopt:"envmap"`
+		Inner  *inner
+	}
+	require.NoError(t, os.WriteFile(example, []byte("WEB_NAME=app\nLABEL_team=core\nINNER_PORT=8080\n"), 0o600))
+	err = CheckExample(example, &shaped{}, fromenv.KeyVars(map[string]string{"APP": "WEB"}))
+	require.EqualError(t, err, example+`: invalid default: strconv.ParseInt: parsing "http": invalid syntax: field Port (int) in struct inner`)
+
+	err = CheckExample("testdata/missing.env", &goldenConfig{})
+	require.Error(t, err)
+	err = CheckExample("testdata/example.env", "config")
+	require.EqualError(t, err, "passed non-struct")
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// A Fault describes a failure that Faulty injects into lookups.
+type Fault struct {
+	// Key is the key whose lookups fail, or "" for every key.
+	Key string
+	// Latency delays each affected lookup.
+	Latency time.Duration
+	// Err, if not nil, is returned by affected lookups.
+	Err error
+	// Missing makes affected lookups report the key as not found.
+	Missing bool
+	// Times limits the fault to the first Times affected lookups, if
+	// positive, such as to test retries.
+	Times int
+}
+
+// Faulty returns a lookup function that looks up keys with next, or finds
+// no keys if next is nil, except as changed by faults. Each lookup is
+// affected by the first of the faults that applies to its key and hasn't
+// been used up. The returned function is safe for concurrent use if next
+// is.
+func Faulty(next fromenv.LookupEnvFunc, faults ...Fault) fromenv.LookupEnvFunc {
+	var mu sync.Mutex
+	used := make([]int, len(faults))
+	fault := func(key string) *Fault {
+		mu.Lock()
+		defer mu.Unlock()
+		for i := range faults {
+			f := &faults[i]
+			if f.Key != "" && f.Key != key {
+				continue
+			}
+			if f.Times > 0 && used[i] >= f.Times {
+				continue
+			}
+			used[i]++
+			return f
+		}
+		return nil
+	}
+
+	return func(key string) (*string, error) {
+		f := fault(key)
+		if f != nil {
+			time.Sleep(f.Latency)
+			if f.Err != nil {
+				return nil, f.Err
+			}
+			if f.Missing {
+				return nil, nil
+			}
+		}
+		if next == nil {
+			return nil, nil
+		}
+		return next(key)
+	}
+}
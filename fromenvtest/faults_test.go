@@ -0,0 +1,52 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaulty(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Token string `env:"TOKEN"`
+	}
+
+	env := Env(map[string]string{"HOST": "h", "TOKEN": "t"})
+	unavailable := errors.New("backend unavailable")
+
+	f := Faulty(env,
+		Fault{Key: "TOKEN", Err: unavailable, Times: 1},
+		Fault{Key: "HOST", Missing: true},
+	)
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Looker(f))
+	require.EqualError(t, err, "backend unavailable: field Token (string) in struct S")
+
+	// The error was only injected once.
+	s = S{}
+	err = fromenv.Unmarshal(&s, fromenv.Looker(f))
+	require.NoError(t, err)
+	require.Equal(t, S{"localhost", "t"}, s)
+
+	slow := Faulty(env, Fault{Latency: 20 * time.Millisecond})
+	start := time.Now()
+	s = S{}
+	err = fromenv.Unmarshal(&s, fromenv.Looker(slow))
+	require.NoError(t, err)
+	require.Equal(t, S{"h", "t"}, s)
+	require.True(t, time.Since(start) >= 40*time.Millisecond)
+
+	v, err := Faulty(nil)("HOST")
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// Generate returns an environment with a valid value for each key tagged in
+// the struct type of in, a struct or pointer to one, including the keys of
+// nested structs. The values are pseudo-random, determined by seed, so a
+// property test can decode many environments and reproduce any failure
+// from its seed.
+//
+// Values are generated for boolean, numeric, and string types, and for
+// the types handled by fromenv.CommonTypes, in the forms that it parses.
+// Keys of fields of other types, including types with Set methods, and
+// of envmap fields, are left out, so those fields get their tag defaults.
+// If fields of different types share a key, the first field's type is
+// used. If the struct's tags are invalid, the environment is empty.
+func Generate(in interface{}, seed int64) map[string]string {
+	g := generator{rand.New(rand.NewSource(seed)), make(map[string]string)}
+	t := reflect.TypeOf(in)
+	if t == nil {
+		return g.env
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return g.env
+	}
+	schema, err := schemaOf(t, nil)
+	if err != nil {
+		return g.env
+	}
+	for _, v := range schema.Vars {
+		if v.Prefix {
+			continue
+		}
+		if ft := fieldType(t, v.Path); ft != nil {
+			if val, ok := g.value(ft); ok {
+				g.env[v.Key] = val
+			}
+		}
+	}
+	return g.env
+}
+
+// Generated returns a lookup function for the environment returned by
+// Generate.
+func Generated(in interface{}, seed int64) fromenv.LookupEnvFunc {
+	return Env(Generate(in, seed))
+}
+
+type generator struct {
+	rand *rand.Rand
+	env  map[string]string
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	urlType      = reflect.TypeOf(url.URL{})
+	ipType       = reflect.TypeOf(net.IP{})
+	regexpType   = reflect.TypeOf(regexp.Regexp{})
+	setterType   = reflect.TypeOf((*interface{ Set(string) error })(nil)).Elem()
+)
+
+// value returns a valid value for type t, if it can generate one.
+func (g *generator) value(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r := g.rand
+	switch t {
+	case durationType:
+		return time.Duration(r.Int63n(int64(100 * time.Hour))).String(), true
+	case timeType:
+		return time.Unix(r.Int63n(1<<32), 0).UTC().Format(time.RFC3339), true
+	case urlType:
+		return "https://" + g.word() + ".example.com/" + g.word(), true
+	case ipType:
+		return net.IPv4(byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256))).String(), true
+	case regexpType:
+		return "^" + g.word() + "[0-9]*$", true
+	}
+
+	if reflect.PtrTo(t).Implements(setterType) {
+		return "", false
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return g.word(), true
+	case reflect.Bool:
+		return strconv.FormatBool(r.Intn(2) == 1), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		max := int64(math.MaxInt64) >> (64 - t.Bits())
+		return strconv.FormatInt(r.Int63n(max)-r.Int63n(max), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(r.Uint64()>>(64-t.Bits()), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(r.NormFloat64()*1000, 'g', -1, t.Bits()), true
+	}
+	return "", false
+}
+
+// word returns a short string of lower case letters and digits.
+func (g *generator) word() string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 1+g.rand.Intn(12))
+	b[0] = chars[g.rand.Intn(26)]
+	for i := 1; i < len(b); i++ {
+		b[i] = chars[g.rand.Intn(len(chars))]
+	}
+	return string(b)
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+type level int
+
+func (l *level) Set(s string) error {
+	switch s {
+	case "low":
+		*l = 0
+	case "high":
+		*l = 1
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+type generateConfig struct {
+	Name    string        `env:"NAME=server"`
+	Port    uint16        `env:"PORT"`
+	Offset  int8          `env:"OFFSET"`
+	Ratio   float32       `env:"RATIO"`
+	Debug   bool          `env:"DEBUG"`
+	Timeout time.Duration `env:"TIMEOUT"`
+	Started time.Time     `env:"STARTED"`
+	Home    *url.URL      `env:"HOME_URL"`
+	Addr    net.IP        `env:"ADDR"`
+	Level   level         `env:"LEVEL=low"`
+	DB      struct {
+		Host string `env:"DB_HOST"`
+		Next *generateConfig
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(0); seed < 200; seed++ {
+		var c generateConfig
+		err := fromenv.Unmarshal(&c, fromenv.Looker(Generated(&c, seed)), fromenv.CommonTypes())
+		require.NoError(t, err, "seed %d", seed)
+	}
+
+	env := Generate(generateConfig{}, 1)
+	require.Equal(t, env, Generate(&generateConfig{}, 1))
+	require.NotEqual(t, env, Generate(&generateConfig{}, 2))
+	require.Len(t, env, 10)
+	require.NotContains(t, env, "LEVEL")
+	require.Contains(t, env, "DB_HOST")
+}
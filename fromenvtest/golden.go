@@ -0,0 +1,123 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// UpdateGolden is the environment variable that, when set to a non-empty
+// value, makes Golden and Snapshot write golden files rather than compare
+// against them.
+const UpdateGolden = "FROMENV_UPDATE_GOLDEN"
+
+// Golden unmarshals in with options, marshals it back with the same
+// options, and compares the result, in .env file form, to the golden file
+// at path. It reports an error to t listing the lines that differ. If the
+// UpdateGolden environment variable is set, Golden writes the file
+// instead. Secret fields are left out, as by Marshal.
+func Golden(t testing.TB, path string, in interface{}, options ...fromenv.Option) {
+	t.Helper()
+	if err := fromenv.Unmarshal(in, options...); err != nil {
+		t.Errorf("unmarshal: %v", err)
+		return
+	}
+	env, err := fromenv.Marshal(in, options...)
+	if err != nil {
+		t.Errorf("marshal: %v", err)
+		return
+	}
+	Snapshot(t, path, []byte(FormatEnv(env)))
+}
+
+// Snapshot compares got to the contents of the file at path, such as the
+// output of fromenv.Usage, and reports an error to t listing the lines
+// that differ. If the UpdateGolden environment variable is set, Snapshot
+// writes got to the file instead, so that changes to the output show up
+// in version control.
+func Snapshot(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if os.Getenv(UpdateGolden) != "" {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Errorf("update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("read golden file: %v", err)
+		return
+	}
+	if diff := diffLines(string(want), string(got)); diff != "" {
+		t.Errorf("%s differs from golden file (-want +got):\n%s", path, diff)
+	}
+}
+
+// FormatEnv returns the entries of env in the form of a .env file: one
+// KEY=value line per entry, sorted by key. Values that are empty or hold
+// spaces, quotes, '#', or non-printing characters are double quoted, with
+// Go escapes.
+func FormatEnv(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(quoteEnv(env[k]))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// quoteEnv returns v, quoted if it needs to be.
+func quoteEnv(v string) string {
+	if v == "" {
+		return `""`
+	}
+	for _, r := range v {
+		if r <= ' ' || r == '"' || r == '\'' || r == '#' || r == '\\' || !strconv.IsPrint(r) {
+			return strconv.Quote(v)
+		}
+	}
+	return v
+}
+
+// diffLines returns the lines only in want, prefixed by "-", and those
+// only in got, prefixed by "+", or "" if there are none.
+func diffLines(want, got string) string {
+	count := make(map[string]int)
+	for _, l := range strings.Split(want, "\n") {
+		count[l]++
+	}
+	for _, l := range strings.Split(got, "\n") {
+		count[l]--
+	}
+	var b strings.Builder
+	for _, l := range strings.Split(want, "\n") {
+		if count[l] > 0 {
+			b.WriteString("-" + l + "\n")
+			count[l]--
+		}
+	}
+	for _, l := range strings.Split(got, "\n") {
+		if count[l] < 0 {
+			b.WriteString("+" + l + "\n")
+			count[l]++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+type goldenConfig struct {
+	Name     string `env:"NAME=server"`
+	Port     int    `env:"PORT=8080"`
+	Greeting string `env:"GREETING"`
+	Password string `env:"PASSWORD" envopt:"secret"`
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+
+	env := fromenv.Looker(Env(map[string]string{
+		"PORT":     "9090",
+		"GREETING": "hello, world",
+		"PASSWORD": "hunter2",
+	}))
+	Golden(t, "testdata/golden.env", &goldenConfig{}, env)
+
+	var ft fakeT
+	other := fromenv.Looker(Env(map[string]string{"PORT": "1"}))
+	Golden(&ft, "testdata/golden.env", &goldenConfig{}, other)
+	require.Equal(t, []string{"testdata/golden.env differs from golden file (-want +got):\n" +
+		"-GREETING=\"hello, world\"\n" +
+		"-PORT=9090\n" +
+		"+GREETING=\"\"\n" +
+		"+PORT=1\n"}, ft.errors)
+}
+
+func TestFormatEnv(t *testing.T) {
+	t.Parallel()
+
+	got := FormatEnv(map[string]string{"B": "x y", "A": "plain", "C": "", "D": "#1"})
+	require.Equal(t, "A=plain\nB=\"x y\"\nC=\"\"\nD=\"#1\"\n", got)
+
+	// A missing golden file is an error, unless updating.
+	path := filepath.Join(t.TempDir(), "out.env")
+	if os.Getenv(UpdateGolden) != "" {
+		t.Skip("updating golden files")
+	}
+	var ft fakeT
+	Golden(&ft, path, &goldenConfig{}, fromenv.DefaultsOnly())
+	require.Len(t, ft.errors, 1)
+	require.Contains(t, ft.errors[0], "read golden file")
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Name string `env:"NAME=server" envdoc:"server name"`
+		Port int    `env:"PORT=8080" envopt:"required"`
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, fromenv.Usage(&b, &S{}))
+	Snapshot(t, "testdata/usage.txt", b.Bytes())
+
+	if os.Getenv(UpdateGolden) != "" {
+		return
+	}
+	var ft fakeT
+	Snapshot(&ft, "testdata/usage.txt", []byte("KEY\n"))
+	require.Len(t, ft.errors, 1)
+	require.Contains(t, ft.errors[0], "+KEY\n")
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// schemaOf returns the Schema of struct type t, as described by
+// fromenv.SchemaOf with options.
+func schemaOf(t reflect.Type, options []fromenv.Option) (*fromenv.Schema, error) {
+	return fromenv.SchemaOf(reflect.New(t).Interface(), options...)
+}
+
+// fieldType returns the type of the field at path, a dot separated list of
+// field names, within struct type t, following pointers to structs.
+func fieldType(t reflect.Type, path string) reflect.Type {
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return nil
+		}
+		t = f.Type
+	}
+	return t
+}
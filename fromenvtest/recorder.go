@@ -0,0 +1,110 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// A Recorder is a lookup function that records the keys it's asked for,
+// so tests can check which variables a struct reads. It's safe for
+// concurrent use.
+type Recorder struct {
+	next fromenv.LookupEnvFunc
+
+	mu     sync.Mutex
+	calls  []string
+	counts map[string]int
+}
+
+// NewRecorder returns a Recorder that looks up keys with next, or finds no
+// keys if next is nil.
+func NewRecorder(next fromenv.LookupEnvFunc) *Recorder {
+	return &Recorder{next: next, counts: make(map[string]int)}
+}
+
+// Lookup records key, and looks it up with the Recorder's lookup function.
+func (r *Recorder) Lookup(key string) (*string, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, key)
+	r.counts[key]++
+	r.mu.Unlock()
+	if r.next == nil {
+		return nil, nil
+	}
+	return r.next(key)
+}
+
+// Option returns an Option configuring fromenv to use the Recorder.
+func (r *Recorder) Option() fromenv.Option {
+	return fromenv.Looker(r.Lookup)
+}
+
+// Calls returns the keys looked up, in order, including repeats.
+func (r *Recorder) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// Count returns the number of times key was looked up.
+func (r *Recorder) Count(key string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[key]
+}
+
+// Keys returns the distinct keys looked up, sorted.
+func (r *Recorder) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(r.counts))
+	for k := range r.counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reset forgets the keys looked up so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+	r.counts = make(map[string]int)
+}
+
+// AssertKeys reports an error to t unless the keys looked up are exactly
+// keys, in any order.
+func (r *Recorder) AssertKeys(t testing.TB, keys ...string) {
+	t.Helper()
+	want := make(map[string]bool)
+	for _, k := range keys {
+		want[k] = true
+	}
+	var missing, extra []string
+	for k := range want {
+		if r.Count(k) == 0 {
+			missing = append(missing, k)
+		}
+	}
+	for _, k := range r.Keys() {
+		if !want[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("keys not looked up: %s", strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		t.Errorf("unexpected keys looked up: %s", strings.Join(extra, ", "))
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenvtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records the errors reported to it.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		A  string `env:"A"`
+		B  string `env:"B=b-default"`
+		A2 string `env:"A"`
+	}
+
+	r := NewRecorder(Env(map[string]string{"A": "a"}))
+	var s S
+	err := fromenv.Unmarshal(&s, r.Option())
+	require.NoError(t, err)
+	require.Equal(t, S{"a", "b-default", "a"}, s)
+
+	require.Equal(t, []string{"A", "B", "A"}, r.Calls())
+	require.Equal(t, []string{"A", "B"}, r.Keys())
+	require.Equal(t, 2, r.Count("A"))
+	require.Equal(t, 0, r.Count("C"))
+	r.AssertKeys(t, "B", "A")
+
+	var ft fakeT
+	r.AssertKeys(&ft, "A", "C")
+	require.Equal(t, []string{"keys not looked up: C", "unexpected keys looked up: B"}, ft.errors)
+
+	r.Reset()
+	require.Empty(t, r.Calls())
+
+	// Without a lookup function, no keys are found.
+	r = NewRecorder(nil)
+	s = S{}
+	err = fromenv.Unmarshal(&s, r.Option())
+	require.NoError(t, err)
+	require.Equal(t, S{B: "b-default"}, s)
+	r.AssertKeys(t, "A", "B")
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func FuzzParseTag(f *testing.F) {
+	for _, tag := range []string{"", "KEY", "KEY=", "KEY=default", "KEY=a=b", "=x"} {
+		f.Add(tag)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		field := reflect.StructField{Tag: reflect.StructTag(tagName + ":" + strconv.Quote(tag))}
+		key, defval := parseTag(field)
+		if strings.Contains(key, tagSep) {
+			t.Fatalf("key %q holds separator", key)
+		}
+		got := key
+		if defval != nil {
+			got += tagSep + *defval
+		}
+		if got != tag {
+			t.Fatalf("parsed %q as %q", tag, got)
+		}
+	})
+}
+
+func FuzzParseModifiers(f *testing.F) {
+	for _, tag := range []string{"", "secret", "secret,source=env", " secret , source=flag;env;default", "source=", "bogus", ",,"} {
+		f.Add(tag)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		field := reflect.StructField{Tag: reflect.StructTag(optTagName + ":" + strconv.Quote(tag))}
+		mods, err := parseModifiers(field)
+		if err != nil {
+			return
+		}
+		for name, value := range mods {
+			if _, ok := modifiers[name]; !ok {
+				t.Fatalf("unknown modifier %q accepted", name)
+			}
+			if name == "source" {
+				names, _, err := parseSources(value)
+				if err == nil && len(names) > 0 && names[0] == "" {
+					t.Fatalf("empty source accepted from %q", value)
+				}
+			}
+		}
+	})
+}
+
+func FuzzSetValue(f *testing.F) {
+	type S struct {
+		String  string
+		Int     int
+		Int8    int8
+		Uint16  uint16
+		Uint64  uint64
+		Float32 float32
+		Float64 float64
+		Bool    bool
+		Ptr     *int
+		Setter  testSetIface
+	}
+	for _, s := range []string{"", "0", "-1", "0x1f", "1e3", "true", "NaN", "18446744073709551615", "héllo"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, str string) {
+		var s S
+		v := reflect.ValueOf(&s).Elem()
+		config := newConfig(nil)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			err := setValue(config, v.Field(i), field.Name, str, nil)
+			switch field.Type.Kind() {
+			case reflect.String:
+				if err != nil || s.String != str {
+					t.Fatalf("string field set to %q, err %v", s.String, err)
+				}
+			case reflect.Int:
+				if err == nil {
+					if want, _ := strconv.ParseInt(str, 0, 0); int64(s.Int) != want {
+						t.Fatalf("int field set to %v from %q", s.Int, str)
+					}
+				}
+			case reflect.Bool:
+				if want, perr := strconv.ParseBool(str); (err == nil) != (perr == nil) || s.Bool != want {
+					t.Fatalf("bool field set to %v from %q, err %v", s.Bool, str, err)
+				}
+			}
+		}
+	})
+}
@@ -1,5 +1,22 @@
 module github.com/alfred-landrum/fromenv
 
-go 1.14
+go 1.19
 
-require github.com/stretchr/testify v1.6.1
+require (
+	filippo.io/age v1.2.1
+	github.com/alecthomas/kong v0.8.1
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	github.com/stretchr/testify v1.6.1
+	golang.org/x/tools v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
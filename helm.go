@@ -0,0 +1,136 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HelmValues returns the skeleton of a Helm chart's values.yaml for the
+// variables described by s, for use with the template returned by
+// HelmTemplate. Each variable that isn't secret is listed under "env" by
+// its key, set to its default, or to the empty string or map if it has
+// none, and commented with its description and whether it's required.
+// Defaults with "${KEY}" references are treated as none, so that
+// Unmarshal expands them if no value is given. If
+// s has secret variables, "secretName" names the Secret they're read from.
+// Deprecated variables are omitted.
+func (s *Schema) HelmValues() ([]byte, error) {
+	env := &yaml.Node{Kind: yaml.MappingNode}
+	secrets := false
+	for _, v := range s.Vars {
+		if v.Deprecated {
+			continue
+		}
+		if v.Secret {
+			secrets = true
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: v.Key, HeadComment: v.Description}
+		val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		switch {
+		case v.Prefix:
+			val = &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+		case v.fixedDefault() != nil:
+			val.Value = *v.fixedDefault()
+		}
+		if v.Required {
+			val.LineComment = "required"
+		}
+		env.Content = append(env.Content, key, val)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if secrets {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "secretName", HeadComment: "The Secret holding secret variables."},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"})
+	}
+	if len(env.Content) > 0 {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "env"}, env)
+	}
+	if len(root.Content) == 0 {
+		root.Style = yaml.FlowStyle
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HelmTemplate returns a named template, defined as name, that renders the
+// env and envFrom sections of a container spec from the values described
+// by HelmValues, as in:
+//
+//	containers:
+//	  - name: app
+//	    {{- include "app.env" . | nindent 4 }}
+//
+// Required variables fail the render if their values are empty. Variables
+// that aren't required, and have no default, are omitted if their values
+// are empty. Secret variables, and the variables collected by secret
+// envmap fields, are read from the Secret named by secretName, as for
+// KubernetesEnv.
+func (s *Schema) HelmTemplate(name string) []byte {
+	var env, envFrom strings.Builder
+	for _, v := range s.Vars {
+		if v.Deprecated {
+			continue
+		}
+		value := fmt.Sprintf("(index .Values.env %q)", v.Key)
+		switch {
+		case v.Prefix && v.Secret:
+			fmt.Fprintf(&envFrom, "  - prefix: %s\n", v.Key)
+			fmt.Fprintf(&envFrom, "    secretRef:\n")
+			fmt.Fprintf(&envFrom, "      name: {{ .Values.secretName }}-%s\n", k8sName("", v.Key))
+		case v.Prefix:
+			fmt.Fprintf(&env, "{{- range $key, $value := %s }}\n", value)
+			fmt.Fprintf(&env, "  - name: %s{{ $key }}\n", v.Key)
+			fmt.Fprintf(&env, "    value: {{ $value | quote }}\n")
+			fmt.Fprintf(&env, "{{- end }}\n")
+		case v.Secret:
+			fmt.Fprintf(&env, "  - name: %s\n", v.Key)
+			fmt.Fprintf(&env, "    valueFrom:\n")
+			fmt.Fprintf(&env, "      secretKeyRef:\n")
+			fmt.Fprintf(&env, "        name: {{ .Values.secretName }}\n")
+			fmt.Fprintf(&env, "        key: %s\n", v.Key)
+			if !v.Required {
+				fmt.Fprintf(&env, "        optional: true\n")
+			}
+		case v.Required:
+			fmt.Fprintf(&env, "  - name: %s\n", v.Key)
+			fmt.Fprintf(&env, "    value: {{ required %q %s | quote }}\n", "env."+v.Key+" is required", value)
+		case v.fixedDefault() != nil:
+			fmt.Fprintf(&env, "  - name: %s\n", v.Key)
+			fmt.Fprintf(&env, "    value: {{ %s | quote }}\n", value)
+		default:
+			fmt.Fprintf(&env, "{{- with %s }}\n", value)
+			fmt.Fprintf(&env, "  - name: %s\n", v.Key)
+			fmt.Fprintf(&env, "    value: {{ . | quote }}\n")
+			fmt.Fprintf(&env, "{{- end }}\n")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "{{- define %q -}}\n", name)
+	if env.Len() > 0 {
+		fmt.Fprintf(&b, "env:\n%s", env.String())
+	}
+	if envFrom.Len() > 0 {
+		fmt.Fprintf(&b, "envFrom:\n%s", envFrom.String())
+	}
+	fmt.Fprintf(&b, "{{- end }}\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type helmConfig struct {
+	Name   string            `env:"NAME" envopt:"required" envdoc:"Service name"`
+	Token  string            `env:"TOKEN" envopt:"secret"`
+	Port   int               `env:"PORT=8080"`
+	Region string            `env:"REGION"`
+	Old    string            `env:"OLD_NAME" envopt:"deprecated=NAME"`
+	Labels map[string]string `env:"LABEL" envopt:"envmap"`
+	Keys   map[string]string `env:"API_KEY" envopt:"envmap,secret"`
+}
+
+func TestHelmValues(t *testing.T) {
+	t.Parallel()
+
+	s, err := SchemaOf(&helmConfig{})
+	require.NoError(t, err)
+	b, err := s.HelmValues()
+	require.NoError(t, err)
+	require.Equal(t, `# The Secret holding secret variables.
+secretName: ""
+env:
+  # Service name
+  NAME: "" # required
+  PORT: "8080"
+  REGION: ""
+  LABEL_: {}
+`, string(b))
+
+	b, err = (&Schema{}).HelmValues()
+	require.NoError(t, err)
+	require.Equal(t, "{}\n", string(b))
+}
+
+func TestHelmTemplate(t *testing.T) {
+	t.Parallel()
+
+	s, err := SchemaOf(&helmConfig{})
+	require.NoError(t, err)
+	tmpl := template.Must(template.New("").Funcs(template.FuncMap{
+		"quote": func(v interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(v)) },
+		"required": func(msg string, v interface{}) (interface{}, error) {
+			if v == nil || v == "" {
+				return nil, errors.New(msg)
+			}
+			return v, nil
+		},
+	}).Parse(string(s.HelmTemplate("app.env"))))
+
+	render := func(values string) (string, error) {
+		var v map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(values), &v))
+		var b strings.Builder
+		err := tmpl.ExecuteTemplate(&b, "app.env", map[string]interface{}{"Values": v})
+		return b.String(), err
+	}
+
+	out, err := render(`
+secretName: app-secrets
+env:
+  NAME: web
+  PORT: "80"
+  REGION: ""
+  LABEL_: {team: core}
+`)
+	require.NoError(t, err)
+	require.Equal(t, `env:
+  - name: NAME
+    value: "web"
+  - name: TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: app-secrets
+        key: TOKEN
+        optional: true
+  - name: PORT
+    value: "80"
+  - name: LABEL_team
+    value: "core"
+envFrom:
+  - prefix: API_KEY_
+    secretRef:
+      name: app-secrets-api-key`, out)
+
+	out, err = render(`env: {NAME: web, REGION: us-east-1}`)
+	require.NoError(t, err)
+	require.Contains(t, out, "  - name: REGION\n    value: \"us-east-1\"\n")
+
+	_, err = render(`env: {NAME: ""}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "env.NAME is required")
+}
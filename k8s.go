@@ -0,0 +1,119 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesEnv returns the env and envFrom sections of a Kubernetes
+// container spec that supply the variables described by s, as YAML to
+// paste into, or generate, a Deployment's manifest:
+//
+// * Secret variables are read from the Secret named secret, by
+// secretKeyRef entries.
+//
+// * Other variables with a default are set to it, and those without are
+// read from the ConfigMap named configMap, by configMapKeyRef entries.
+// Defaults with "${KEY}" references are treated as none, so that
+// Unmarshal expands them if the ConfigMap doesn't have the key.
+//
+// * The variables collected by an envmap field are read from a ConfigMap,
+// or a Secret if the field is secret, whose name is configMap or secret
+// followed by the field's key, such as "app-label" for the key "LABEL".
+// They're listed under envFrom, with the field's key prefix.
+//
+// References to keys that aren't required are optional, so that a pod
+// starts without them. Deprecated variables are omitted.
+func (s *Schema) KubernetesEnv(secret, configMap string) ([]byte, error) {
+	var spec k8sContainerEnv
+	for _, v := range s.Vars {
+		if v.Deprecated {
+			continue
+		}
+		if v.Prefix {
+			from := k8sEnvFrom{Prefix: v.Key}
+			if v.Secret {
+				from.SecretRef = &k8sRef{Name: k8sName(secret, v.Key)}
+			} else {
+				from.ConfigMapRef = &k8sRef{Name: k8sName(configMap, v.Key)}
+			}
+			spec.EnvFrom = append(spec.EnvFrom, from)
+			continue
+		}
+
+		env := k8sEnvVar{Name: v.Key}
+		ref := &k8sKeyRef{Key: v.Key, Optional: !v.Required}
+		switch {
+		case v.Secret:
+			ref.Name = secret
+			env.ValueFrom = &k8sEnvSource{SecretKeyRef: ref}
+		case v.fixedDefault() != nil:
+			env.Value = v.fixedDefault()
+		default:
+			ref.Name = configMap
+			env.ValueFrom = &k8sEnvSource{ConfigMapKeyRef: ref}
+		}
+		spec.Env = append(spec.Env, env)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(spec); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// k8sName returns the name of the object holding the variables with the
+// key prefix, for the object named base: base and the prefix, in
+// lowercase, joined by dashes.
+func k8sName(base, prefix string) string {
+	name := strings.ToLower(strings.Trim(prefix, "_-."))
+	name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+	if base == "" {
+		return name
+	}
+	return base + "-" + name
+}
+
+type k8sContainerEnv struct {
+	Env     []k8sEnvVar  `yaml:"env,omitempty"`
+	EnvFrom []k8sEnvFrom `yaml:"envFrom,omitempty"`
+}
+
+type k8sEnvVar struct {
+	Name      string        `yaml:"name"`
+	Value     *string       `yaml:"value,omitempty"`
+	ValueFrom *k8sEnvSource `yaml:"valueFrom,omitempty"`
+}
+
+type k8sEnvSource struct {
+	SecretKeyRef    *k8sKeyRef `yaml:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *k8sKeyRef `yaml:"configMapKeyRef,omitempty"`
+}
+
+type k8sKeyRef struct {
+	Name     string `yaml:"name"`
+	Key      string `yaml:"key"`
+	Optional bool   `yaml:"optional,omitempty"`
+}
+
+type k8sEnvFrom struct {
+	Prefix       string  `yaml:"prefix"`
+	SecretRef    *k8sRef `yaml:"secretRef,omitempty"`
+	ConfigMapRef *k8sRef `yaml:"configMapRef,omitempty"`
+}
+
+type k8sRef struct {
+	Name string `yaml:"name"`
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesEnv(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Name   string            `env:"NAME" envopt:"required"`
+		Token  string            `env:"TOKEN" envopt:"secret"`
+		Port   int               `env:"PORT=8080"`
+		Region string            `env:"REGION"`
+		Old    string            `env:"OLD_NAME" envopt:"deprecated=NAME"`
+		Labels map[string]string `env:"LABEL" envopt:"envmap"`
+		Keys   map[string]string `env:"API_KEY" envopt:"envmap,secret"`
+	}
+	s, err := SchemaOf(&Config{})
+	require.NoError(t, err)
+	b, err := s.KubernetesEnv("app-secrets", "app")
+	require.NoError(t, err)
+	require.Equal(t, `env:
+  - name: NAME
+    valueFrom:
+      configMapKeyRef:
+        name: app
+        key: NAME
+  - name: TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: app-secrets
+        key: TOKEN
+        optional: true
+  - name: PORT
+    value: "8080"
+  - name: REGION
+    valueFrom:
+      configMapKeyRef:
+        name: app
+        key: REGION
+        optional: true
+envFrom:
+  - prefix: LABEL_
+    configMapRef:
+      name: app-label
+  - prefix: API_KEY_
+    secretRef:
+      name: app-secrets-api-key
+`, string(b))
+
+	b, err = (&Schema{}).KubernetesEnv("s", "c")
+	require.NoError(t, err)
+	require.Equal(t, "{}\n", string(b))
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+// KeepExisting configures Unmarshal to treat the current value of a tagged
+// field, if it's not the zero value, as the field's default: when the
+// field's key isn't found, the field keeps its value, rather than being
+// set to its tag default. This allows a program to build a baseline
+// configuration in code, and override it from the environment. Required
+// fields must still be found.
+func KeepExisting() Option {
+	return func(c *config) {
+		c.keepExisting = true
+	}
+}
+
+// Defaults configures Unmarshal to use the value for a field's key in
+// defaults, if any, as the field's default, in place of its tag default.
+// Like tag defaults, they're only used when the key isn't found, and may
+// hold "${KEY}" references. Repeated uses of Defaults are merged, with
+// later values taking precedence.
+func Defaults(defaults map[string]string) Option {
+	return func(c *config) {
+		merged := make(map[string]string, len(c.defaults)+len(defaults))
+		for k, v := range c.defaults {
+			merged[k] = v
+		}
+		for k, v := range defaults {
+			merged[k] = v
+		}
+		c.defaults = merged
+	}
+}
+
+// recordExisting records that the field at the cursor kept its value.
+func (c *config) recordExisting(cur *cursor) {
+	value := ""
+	if s, err := c.marshalField(cur.info, cur.value); err == nil {
+		value = s
+	}
+	c.record(cur, cur.info.key, SourceExisting, "", value)
+}
+
+// ResetUnset configures Unmarshal to set tagged fields whose keys aren't
+// found, and that have no tag default, to their zero value, rather than
+// leaving them unchanged. It's intended for decoding into a struct that
+// was decoded before, such as with Watcher.Refresh, so that a value whose
+// key was removed from the environment doesn't linger. With KeepExisting,
+// fields with non-zero values are left unchanged.
+func ResetUnset() Option {
+	return func(c *config) {
+		c.resetUnset = true
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepExisting(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host    string        `env:"HOST=localhost"`
+		Port    int           `env:"PORT=80"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Token   string        `env:"TOKEN" envopt:"secret"`
+		Name    string        `env:"NAME=default-name"`
+	}
+
+	base := S{Host: "db.internal", Port: 5432, Timeout: time.Second, Token: "t0"}
+
+	s := base
+	var r Result
+	err := Unmarshal(&s, Map(map[string]string{"PORT": "6543"}), KeepExisting(), Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, S{"db.internal", 6543, time.Second, "t0", "default-name"}, s)
+	require.Equal(t, SourceExisting, r.Field("Host").Source)
+	require.Equal(t, "db.internal", r.Field("Host").Value)
+	require.Equal(t, SourceEnv, r.Field("Port").Source)
+	require.Equal(t, "[REDACTED]", r.Field("Token").Value)
+	require.Equal(t, SourceDefault, r.Field("Name").Source)
+	require.Equal(t, "existing", SourceExisting.String())
+
+	// Without the option, tag defaults replace existing values.
+	s = base
+	err = Unmarshal(&s, Map(nil))
+	require.NoError(t, err)
+	require.Equal(t, S{"localhost", 80, time.Second, "t0", "default-name"}, s)
+
+	type Req struct {
+		A string `env:"A" envopt:"required"`
+	}
+	err = Unmarshal(&Req{"set"}, Map(nil), KeepExisting())
+	require.EqualError(t, err, "missing required key: field A (string) in struct Req")
+}
+
+func TestResetUnset(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Level string `env:"LEVEL"`
+	}
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Port  *int   `env:"PORT"`
+		Name  string `env:"NAME"`
+		Inner Inner
+	}
+
+	port := 80
+	s := S{"db", &port, "old-name", Inner{"debug"}}
+	err := Unmarshal(&s, Map(map[string]string{"NAME": "new-name"}), ResetUnset())
+	require.NoError(t, err)
+	require.Equal(t, S{"localhost", nil, "new-name", Inner{}}, s)
+
+	// Without the option, absent keys leave fields unchanged.
+	s = S{"db", &port, "old-name", Inner{"debug"}}
+	err = Unmarshal(&s, Map(nil))
+	require.NoError(t, err)
+	require.Equal(t, S{"localhost", &port, "old-name", Inner{"debug"}}, s)
+}
+
+func TestDefaults(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host string `env:"HOST=localhost"`
+		Port int    `env:"PORT=80"`
+		Name string `env:"NAME"`
+		URL  string `env:"URL"`
+	}
+
+	var s S
+	var r Result
+	defaults := Defaults(map[string]string{"HOST": "test-host", "NAME": "n1", "URL": "http://${HOST}"})
+	err := Unmarshal(&s, Map(map[string]string{"HOST": "env-host"}), defaults,
+		Defaults(map[string]string{"NAME": "n2"}), Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, S{"env-host", 80, "n2", "http://env-host"}, s)
+	require.Equal(t, SourceEnv, r.Field("Host").Source)
+	require.Equal(t, SourceDefault, r.Field("Name").Source)
+
+	s = S{}
+	err = Unmarshal(&s, Map(nil), defaults)
+	require.NoError(t, err)
+	require.Equal(t, S{"test-host", 80, "n1", "http://"}, s)
+}
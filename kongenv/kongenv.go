@@ -0,0 +1,85 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package kongenv adds flags for tagged struct fields to kong command-line
+// parsers, so that command-line flags override values set from the
+// environment.
+package kongenv
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/alecthomas/kong"
+	"github.com/alfred-landrum/fromenv"
+)
+
+// Embed calls fromenv.Flags with in and options, and returns a kong.Option
+// that adds a flag to the root of the parser for each Flag, with the Flag's
+// name, shorthand, and usage. The flags are not given kong env tags, since
+// the fields have already been set from the environment per options.
+//
+//	var cfg Config
+//	var cli CLI
+//	embed, err := kongenv.Embed(&cfg)
+//	...
+//	kong.Parse(&cli, embed)
+func Embed(in interface{}, options ...fromenv.Option) (kong.Option, error) {
+	flags, err := fromenv.Flags(in, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]reflect.StructField, 0, len(flags))
+	opts := make([]kong.Option, 0, len(flags)+1)
+	for i, f := range flags {
+		typ := fmt.Sprintf("fromenv-%d", i)
+		tag := fmt.Sprintf("name:%q help:%q type:%q", f.Name, f.Usage, typ)
+		if f.Shorthand != "" {
+			tag += fmt.Sprintf(" short:%q", f.Shorthand)
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(tag),
+		})
+		opts = append(opts, kong.NamedMapper(typ, mapper{f.Value}))
+	}
+
+	// The embedded struct's fields only carry the flag declarations; kong
+	// resets them before parsing, so the values are set by the mappers.
+	strct := reflect.New(reflect.StructOf(fields))
+	opts = append(opts, kong.Embed(strct.Interface()))
+	return kong.OptionFunc(func(k *kong.Kong) error {
+		for _, opt := range opts {
+			if err := opt.Apply(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+// A mapper is a kong.BoolMapper that sets a tagged field.
+type mapper struct {
+	v *fromenv.FlagValue
+}
+
+func (m mapper) Decode(ctx *kong.DecodeContext, _ reflect.Value) error {
+	if m.v.IsBoolFlag() {
+		if ctx.Scan.Peek().Type != kong.FlagValueToken {
+			return m.v.Set("true")
+		}
+		return m.v.Set(fmt.Sprint(ctx.Scan.Pop().Value))
+	}
+	token, err := ctx.Scan.PopValue("value")
+	if err != nil {
+		return err
+	}
+	return m.v.Set(fmt.Sprint(token.Value))
+}
+
+func (m mapper) IsBool() bool {
+	return m.v.IsBoolFlag()
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package kongenv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbed(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		DBURL   string        `env:"FROMENV_KONG_DB_URL=postgres://localhost" envflag:"db-url,d" envdoc:"database URL"`
+		Verbose bool          `env:"FROMENV_KONG_VERBOSE" envflag:"verbose"`
+		Timeout time.Duration `env:"FROMENV_KONG_TIMEOUT=5s" envflag:"timeout"`
+	}
+	var cli struct {
+		Name string `help:"A flag of the application itself."`
+	}
+
+	embed, err := Embed(&cfg, fromenv.Map(map[string]string{"FROMENV_KONG_TIMEOUT": "1m"}), fromenv.CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, cfg.Timeout)
+
+	var help bytes.Buffer
+	parser, err := kong.New(&cli, embed, kong.Writers(&help, &help), kong.Exit(func(int) {}))
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]string{"--name", "n1", "-d", "postgres://db", "--verbose", "--timeout=2m"})
+	require.NoError(t, err)
+	require.Equal(t, "n1", cli.Name)
+	require.Equal(t, "postgres://db", cfg.DBURL)
+	require.True(t, cfg.Verbose)
+	require.Equal(t, 2*time.Minute, cfg.Timeout)
+
+	_, err = parser.Parse([]string{"--timeout", "soon"})
+	require.Error(t, err)
+
+	_, _ = parser.Parse([]string{"--help"})
+	require.Contains(t, help.String(), "-d, --db-url=STRING")
+	require.Contains(t, help.String(), "environment variable FROMENV_KONG_VERBOSE")
+}
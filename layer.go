@@ -0,0 +1,247 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Names of the layers used by Layered.
+const (
+	FlagLayer = "flag"
+	EnvLayer  = "env"
+	FileLayer = "file"
+)
+
+// A Layer is a named lookup function, used by the Layers option.
+type Layer struct {
+	Name   string
+	Lookup LookupEnvFunc
+}
+
+// Layers configures Unmarshal to look up each key in the given layers, in
+// order, and to use the value from the first layer that has one. If no
+// layer has a value, the tag default is used, as usual. A layer named
+// EnvLayer with a nil Lookup uses the function configured via Looker, Map,
+// or DefaultsOnly. Without this option, keys are only looked up in that
+// EnvLayer.
+//
+// A field may override the order with a "source" modifier in its envopt
+// tag, a semicolon separated list of the layers to look up its key in, in
+// order. The list may end with "default", to allow the use of the tag
+// default; otherwise, the default is ignored. For example, a field tagged
+// with `envopt:"source=env;default"` is never set from other layers.
+// Names that aren't of a configured layer, or of a looker configured via
+// NamedLooker, are an error.
+func Layers(layers ...Layer) Option {
+	return func(c *config) {
+		c.layers = append([]Layer(nil), layers...)
+	}
+}
+
+// lookup looks up key in each layer, returning the first value found, and
+// the name of the layer it was found in.
+func (c *config) lookup(key string) (*string, string, error) {
+	return c.lookupLayers(key, c.layerList())
+}
+
+// lookupSources is like lookup, but only looks up key in the named layers,
+// or lookers configured via NamedLooker, in the given order.
+func (c *config) lookupSources(key string, sources []string) (*string, string, error) {
+	all := c.layerList()
+	var layers []Layer
+	for _, name := range sources {
+		found := false
+		for _, l := range all {
+			if l.Name == name {
+				layers = append(layers, l)
+				found = true
+			}
+		}
+		if f, ok := c.lookers[name]; ok && !found {
+			layers = append(layers, Layer{name, f})
+		}
+	}
+	return c.lookupLayers(key, layers)
+}
+
+// layerList returns the configured layers.
+func (c *config) layerList() []Layer {
+	if c.layers == nil {
+		return []Layer{{Name: EnvLayer}}
+	}
+	return c.layers
+}
+
+// lookupLayers looks up key in layers, first with the configured profile,
+// if any, and then without. The lookup policy applies to key; the profile
+// variant of key is skipped if the policy doesn't allow it.
+func (c *config) lookupLayers(key string, layers []Layer) (*string, string, error) {
+	if err := c.checkKey(key); err != nil {
+		c.tracef("%v", err)
+		return nil, "", err
+	}
+	if c.profile != "" {
+		pkey := c.profileKey(key)
+		if err := c.checkKey(pkey); err != nil {
+			c.tracef("%v: skipped", err)
+		} else {
+			v, layer, err := c.lookupKeyLayers(pkey, layers)
+			if err != nil || v != nil {
+				return v, layer, err
+			}
+		}
+	}
+	return c.lookupKeyLayers(key, layers)
+}
+
+func (c *config) lookupKeyLayers(key string, layers []Layer) (*string, string, error) {
+	c.use(key)
+	for _, l := range layers {
+		f := l.Lookup
+		if f == nil {
+			if l.Name != EnvLayer {
+				continue
+			}
+			f = c.looker
+			if c.relaxed != nil {
+				f = c.relaxedLookup
+			}
+		}
+		v, err := f(key)
+		switch {
+		case err != nil:
+			c.tracef("looked up %v in %v: %v", key, l.Name, err)
+		case v != nil:
+			c.tracef("looked up %v in %v: found", key, l.Name)
+		default:
+			c.tracef("looked up %v in %v: not found", key, l.Name)
+		}
+		if err != nil || v != nil {
+			return v, l.Name, err
+		}
+	}
+	return nil, "", nil
+}
+
+// defaultSource is the name for the tag default in a source modifier.
+const defaultSource = "default"
+
+// parseSources parses the value of a source modifier, a semicolon separated
+// list of layer names, optionally ending with defaultSource. It returns the
+// layer names, and whether the tag default may be used.
+func parseSources(s string) ([]string, bool, error) {
+	var names []string
+	parts := strings.Split(s, listSep)
+	for i, name := range parts {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			return nil, false, errors.New("empty source name")
+		case name == defaultSource:
+			if i != len(parts)-1 {
+				return nil, false, errors.New("default must be the last source")
+			}
+			return names, true, nil
+		}
+		names = append(names, name)
+	}
+	return names, false, nil
+}
+
+// checkSources returns an error if any of the names, from a source
+// modifier, isn't the name of a configured layer, or of a looker
+// configured via NamedLooker, so that a misspelled name isn't silently
+// never looked up.
+func (c *config) checkSources(names []string) error {
+	for _, name := range names {
+		if !c.hasLooker(name) {
+			return fmt.Errorf("unknown source %q", name)
+		}
+	}
+	return nil
+}
+
+// Layered sets the tagged fields of in from a fixed precedence of sources.
+// It defines a flag in fs for each field, named as by Flags, and parses
+// args with fs. It then calls Unmarshal, so that each field's value comes
+// from the first of:
+//
+// * The field's flag, if it was set in args.
+//
+// * The lookup function configured by options; by default, the process
+// environment.
+//
+// * The lookup function file, if not nil, such as one reading a
+// configuration file.
+//
+// * The field's tag default.
+//
+// Layered returns an error if a flag name is already defined in fs. The
+// flag defaults shown by fs.PrintDefaults are the tag defaults. The
+// layer that supplied each value is recorded in a Result, as configured
+// via Record, using the names FlagLayer, EnvLayer, and FileLayer.
+func Layered(in interface{}, fs *flag.FlagSet, args []string, file LookupEnvFunc, options ...Option) error {
+	if !isStructPtr(in) {
+		return errors.New("passed non-pointer or nil pointer")
+	}
+	flags, err := flagList(in, newConfig(options))
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]string)
+	for _, f := range flags {
+		if fs.Lookup(f.Name) != nil {
+			return errors.New("flag redefined: " + f.Name)
+		}
+		fs.Var(&layerFlag{f.Key, f.Value.info.defval, f.Value.IsBoolFlag(), set}, f.Name, f.Usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	layers := []Layer{
+		{FlagLayer, lookupMap(set)},
+		{Name: EnvLayer},
+	}
+	if file != nil {
+		layers = append(layers, Layer{FileLayer, file})
+	}
+	return Unmarshal(in, append(options, Layers(layers...))...)
+}
+
+// A layerFlag is a flag.Value that records the values set for a key.
+type layerFlag struct {
+	key    string
+	defval *string
+	isBool bool
+	set    map[string]string
+}
+
+func (f *layerFlag) String() string {
+	if f == nil || f.set == nil {
+		return ""
+	}
+	if v, ok := f.set[f.key]; ok {
+		return v
+	}
+	if f.defval != nil {
+		return *f.defval
+	}
+	return ""
+}
+
+func (f *layerFlag) Set(s string) error {
+	f.set[f.key] = s
+	return nil
+}
+
+func (f *layerFlag) IsBoolFlag() bool {
+	return f.isBool
+}
@@ -0,0 +1,146 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayers(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		A string `env:"A"`
+		B string `env:"B"`
+		C string `env:"C=c-default"`
+	}
+
+	high := lookupMap(map[string]string{"A": "a-high"})
+	low := lookupMap(map[string]string{"A": "a-low", "B": "b-low"})
+
+	var s S
+	var r Result
+	err := Unmarshal(&s, Map(map[string]string{"B": "b-env"}), Record(&r),
+		Layers(Layer{"high", high}, Layer{Name: EnvLayer}, Layer{"low", low}))
+	require.NoError(t, err)
+	require.Equal(t, S{"a-high", "b-env", "c-default"}, s)
+	require.Equal(t, "high", r.Field("A").Layer)
+	require.Equal(t, EnvLayer, r.Field("B").Layer)
+	require.Equal(t, SourceDefault, r.Field("C").Source)
+
+	// Without EnvLayer, the configured looker isn't used.
+	var s2 S
+	err = Unmarshal(&s2, noLookup(), Layers(Layer{"low", low}))
+	require.NoError(t, err)
+	require.Equal(t, S{"a-low", "b-low", "c-default"}, s2)
+
+	failing := func(string) (*string, error) { return nil, errors.New("layer failed") }
+	err = Unmarshal(&s2, Layers(Layer{"failing", failing}))
+	require.EqualError(t, err, "layer failed: field A (string) in struct S")
+}
+
+func TestLayerSources(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Token string `env:"TOKEN=t-default" envopt:"secret,source=env"`
+		Level string `env:"LEVEL=l-default" envopt:"source=low;env;default"`
+		Other string `env:"OTHER=o-default" envopt:"source=default"`
+	}
+
+	file := lookupMap(map[string]string{"TOKEN": "t-file", "LEVEL": "l-file"})
+	layers := Layers(Layer{Name: EnvLayer}, Layer{"low", file})
+
+	var s S
+	var r Result
+	err := Unmarshal(&s, Map(map[string]string{"LEVEL": "l-env"}), layers, Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, S{"", "l-file", "o-default"}, s)
+	require.Equal(t, SourceNone, r.Field("Token").Source)
+	require.Equal(t, "low", r.Field("Level").Layer)
+
+	s = S{}
+	err = Unmarshal(&s, Map(map[string]string{"TOKEN": "t-env"}), layers)
+	require.NoError(t, err)
+	require.Equal(t, S{"t-env", "l-file", "o-default"}, s)
+
+	type Bad1 struct {
+		A string `env:"A" envopt:"source=default;env"`
+	}
+	err = Unmarshal(&Bad1{}, noLookup())
+	require.EqualError(t, err, "default must be the last source: field A (string) in struct Bad1")
+
+	type Bad2 struct {
+		A string `env:"A" envopt:"source=env;;default"`
+	}
+	err = Unmarshal(&Bad2{}, noLookup())
+	require.EqualError(t, err, "empty source name: field A (string) in struct Bad2")
+
+	type Bad3 struct {
+		A string `env:"A" envopt:"source=evn;default"`
+	}
+	err = Unmarshal(&Bad3{}, noLookup())
+	require.EqualError(t, err, `unknown source "evn": field A (string) in struct Bad3`)
+	err = Unmarshal(&Bad3{}, noLookup(), Layers(Layer{"evn", lookupMap(nil)}))
+	require.NoError(t, err)
+}
+
+func TestLayered(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Port  int    `env:"PORT=80"`
+		Debug bool   `env:"DEBUG"`
+		Name  string `env:"NAME"`
+		User  string `env:"USER"`
+	}
+
+	env := Map(map[string]string{
+		"PORT": "8080",
+		"NAME": "env-name",
+	})
+	file := lookupMap(map[string]string{
+		"PORT": "9090",
+		"NAME": "file-name",
+		"USER": "file-user",
+	})
+
+	var s S
+	var r Result
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := Layered(&s, fs, []string{"-debug", "-name", "flag-name"}, file, env, Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, S{"localhost", 8080, true, "flag-name", "file-user"}, s)
+	require.Equal(t, SourceDefault, r.Field("Host").Source)
+	require.Equal(t, EnvLayer, r.Field("Port").Layer)
+	require.Equal(t, FlagLayer, r.Field("Debug").Layer)
+	require.Equal(t, FlagLayer, r.Field("Name").Layer)
+	require.Equal(t, FileLayer, r.Field("User").Layer)
+
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	require.Contains(t, out.String(), "-port value\n    \tenvironment variable PORT (default 80)")
+
+	var s2 S
+	fs2 := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs2.SetOutput(&bytes.Buffer{})
+	err = Layered(&s2, fs2, []string{"-port", "eighty"}, nil, env)
+	require.EqualError(t, err, "strconv.ParseInt: parsing \"eighty\": invalid syntax: field Port (int) in struct S")
+
+	err = Layered(&s2, fs2, nil, nil, env)
+	require.EqualError(t, err, "flag redefined: host")
+
+	fs3 := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs3.SetOutput(&bytes.Buffer{})
+	err = Layered(&s2, fs3, []string{"-bogus"}, nil, env)
+	require.Error(t, err)
+}
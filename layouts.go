@@ -0,0 +1,111 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// namedLayouts maps the names of the time package's layout constants, as
+// used in the layouts modifier, to their layouts.
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// parseLayouts returns the layouts listed in the value of a layouts
+// modifier, separated by semicolons. Each is the name of one of the time
+// package's layout constants, such as RFC3339, or a layout itself.
+func parseLayouts(s string) ([]string, error) {
+	var layouts []string
+	for _, name := range strings.Split(s, listSep) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if layout, ok := namedLayouts[name]; ok {
+			name = layout
+		}
+		layouts = append(layouts, name)
+	}
+	if len(layouts) == 0 {
+		return nil, errors.New("layouts modifier lists no layouts")
+	}
+	return layouts, nil
+}
+
+// timeValue returns value, a time.Time or a pointer to one, as a
+// time.Time, allocating it if it's a nil pointer and alloc is true.
+func timeValue(value reflect.Value, alloc bool) (reflect.Value, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if !alloc {
+				return reflect.Value{}, nil
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if value.Type() != timeType {
+		return reflect.Value{}, errors.New("layouts modifier requires a time.Time field")
+	}
+	return value, nil
+}
+
+// setLayouts sets the time.Time value to str, parsed with the first of the
+// modifier's layouts that it matches.
+func setLayouts(value reflect.Value, mod, str string) error {
+	layouts, err := parseLayouts(mod)
+	if err != nil {
+		return err
+	}
+	value, err = timeValue(value, true)
+	if err != nil {
+		return err
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			value.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	return fmt.Errorf("time matches none of the layouts %v", strings.ReplaceAll(mod, listSep, ", "))
+}
+
+// formatLayouts formats the time.Time value with the first of the
+// modifier's layouts.
+func formatLayouts(value reflect.Value, mod string) (string, error) {
+	layouts, err := parseLayouts(mod)
+	if err != nil {
+		return "", err
+	}
+	value, err = timeValue(value, false)
+	if err != nil || !value.IsValid() {
+		return "", err
+	}
+	return value.Interface().(time.Time).Format(layouts[0]), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutsModifier(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Start time.Time  `env:"START" envopt:"layouts=RFC3339;DateOnly"`
+		End   *time.Time `env:"END" envopt:"layouts=02 Jan 2006 15:04"`
+	}
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"START": "2017-03-04", "END": "05 Mar 2017 10:30"}))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC), s.Start)
+	require.Equal(t, time.Date(2017, 3, 5, 10, 30, 0, 0, time.UTC), *s.End)
+
+	err = Unmarshal(&s, Map(map[string]string{"START": "2017-03-04T05:06:07Z"}))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC), s.Start)
+
+	env, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"START": "2017-03-04T05:06:07Z", "END": "05 Mar 2017 10:30"}, env)
+
+	err = Unmarshal(&s, Map(map[string]string{"START": "03/04/2017"}))
+	require.EqualError(t, err, "time matches none of the layouts RFC3339, DateOnly: field Start (struct) in struct S")
+
+	type Bad struct {
+		When  string    `env:"WHEN" envopt:"layouts=RFC3339"`
+		Empty time.Time `env:"EMPTY" envopt:"layouts=;"`
+	}
+	err = Unmarshal(&Bad{}, Map(map[string]string{"WHEN": "x"}))
+	require.EqualError(t, err, "layouts modifier requires a time.Time field: field When (string) in struct Bad")
+	err = Check(&Bad{})
+	require.EqualError(t, err, "2 errors\ntop level (2):\n"+
+		"    layouts modifier requires a time.Time field: field When (string) in struct Bad\n"+
+		"    layouts modifier lists no layouts: field Empty (struct) in struct Bad")
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A Lazy is a struct field type for values that are only looked up when
+// first used. Rather than looking up a Lazy field's key, Unmarshal arranges
+// for the first call of its Get method to look it up and parse the value,
+// with the options passed to Unmarshal. This suits large configurations in
+// which each value comes from a slow source, such as a secrets manager, and
+// only some are used by a given program.
+//
+//	type Config struct {
+//		DBPassword fromenv.Lazy[string] `env:"DB_PASSWORD" envopt:"secret"`
+//	}
+//
+// Copies of a Lazy share its value. Lazy fields are not recorded by
+// Record, prefetched by ConcurrentLookups, or compared by Diff, and are
+// omitted by Marshal.
+type Lazy[T any] struct {
+	s *lazyState[T]
+}
+
+type lazyState[T any] struct {
+	once    sync.Once
+	resolve func(reflect.Value) error
+	val     T
+	err     error
+}
+
+// Get returns the value of the field, looking it up on the first call.
+// Later calls return the same value and error. If the key isn't found and
+// there's no tag default, or the Lazy wasn't set by Unmarshal, Get returns
+// the zero value of T.
+func (l Lazy[T]) Get() (T, error) {
+	if l.s == nil {
+		var zero T
+		return zero, nil
+	}
+	l.s.once.Do(func() {
+		l.s.err = l.s.resolve(reflect.ValueOf(&l.s.val).Elem())
+		l.s.resolve = nil
+	})
+	return l.s.val, l.s.err
+}
+
+func (l *Lazy[T]) bindLazy(resolve func(reflect.Value) error) {
+	l.s = &lazyState[T]{resolve: resolve}
+}
+
+// A lazyBinder is a pointer to a Lazy.
+type lazyBinder interface {
+	bindLazy(resolve func(reflect.Value) error)
+}
+
+// isLazy returns the field value v as a lazyBinder, if it's a Lazy.
+func isLazy(v reflect.Value) (lazyBinder, bool) {
+	if v.Kind() != reflect.Struct || !v.CanAddr() || !v.CanInterface() {
+		return nil, false
+	}
+	l, ok := v.Addr().Interface().(lazyBinder)
+	return l, ok
+}
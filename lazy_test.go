@@ -0,0 +1,106 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Eager    string       `env:"EAGER"`
+		Password Lazy[string] `env:"PASSWORD" envopt:"secret"`
+		Port     Lazy[int]    `env:"PORT=80"`
+		Bad      Lazy[int]    `env:"BAD"`
+		Missing  Lazy[string] `env:"MISSING"`
+	}
+
+	env := map[string]string{"EAGER": "e", "PASSWORD": "p1", "BAD": "x"}
+	calls := make(map[string]int)
+	looker := func(key string) (*string, error) {
+		calls[key]++
+		return lookupMap(env)(key)
+	}
+
+	var s S
+	err := Unmarshal(&s, Looker(looker), ConcurrentLookups(2))
+	require.NoError(t, err)
+	require.Equal(t, "e", s.Eager)
+	require.Equal(t, map[string]int{"EAGER": 1}, calls)
+
+	// Values are looked up once, on first use.
+	env["PASSWORD"] = "p2"
+	copied := s
+	for i := 0; i < 2; i++ {
+		v, err := copied.Password.Get()
+		require.NoError(t, err)
+		require.Equal(t, "p2", v)
+	}
+	v, err := s.Password.Get()
+	require.NoError(t, err)
+	require.Equal(t, "p2", v)
+	require.Equal(t, 1, calls["PASSWORD"])
+
+	port, err := s.Port.Get()
+	require.NoError(t, err)
+	require.Equal(t, 80, port)
+
+	_, err = s.Bad.Get()
+	require.EqualError(t, err, `strconv.ParseInt: parsing "x": invalid syntax: field Bad (struct) in struct S`)
+
+	missing, err := s.Missing.Get()
+	require.NoError(t, err)
+	require.Equal(t, "", missing)
+
+	var zero Lazy[int]
+	n, err := zero.Get()
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	// Lazy fields are left out of Marshal.
+	m, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"EAGER": "e"}, m)
+}
+
+func TestLazyConcurrentGet(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		A Lazy[string] `env:"A"`
+		B Lazy[string] `env:"B"`
+		C Lazy[int]    `env:"C"`
+	}
+
+	var s S
+	env := Map(map[string]string{"a": "1", "b": "2", "c": "3"})
+	require.NoError(t, Unmarshal(&s, env, Relaxed()))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i, get := range []func() error{
+		func() error { _, err := s.A.Get(); return err },
+		func() error { _, err := s.B.Get(); return err },
+		func() error { _, err := s.C.Get(); return err },
+	} {
+		wg.Add(1)
+		go func(i int, get func() error) {
+			defer wg.Done()
+			errs[i] = get()
+		}(i, get)
+	}
+	wg.Wait()
+	require.Equal(t, make([]error, 3), errs)
+
+	a, _ := s.A.Get()
+	c, _ := s.C.Get()
+	require.Equal(t, "1", a)
+	require.Equal(t, 3, c)
+}
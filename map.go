@@ -0,0 +1,126 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mapSeparators are the separators of the entries of a map value, of each
+// entry's key and value, and of the elements of a slice value.
+type mapSeparators struct {
+	entry, key, value string
+}
+
+var defaultMapSeparators = mapSeparators{",", ":", "|"}
+
+// MapSeparators configures the separators used to parse and format map
+// fields, such as a map[string][]string set from
+// "admin:read|write,user:read": entry separates the map's entries, key
+// separates each entry's key from its value, and value separates the
+// elements of slice values. The defaults are ",", ":", and "|". Each
+// separator must be non-empty and differ from the others.
+func MapSeparators(entry, key, value string) Option {
+	return func(c *config) {
+		c.mapSeps = &mapSeparators{entry, key, value}
+	}
+}
+
+// mapSeparators returns the configured map separators.
+func (c *config) mapSeparators() (mapSeparators, error) {
+	if c.mapSeps == nil {
+		return defaultMapSeparators, nil
+	}
+	s := *c.mapSeps
+	if s.entry == "" || s.key == "" || s.value == "" || s.entry == s.key || s.entry == s.value || s.key == s.value {
+		return s, errors.New("map separators must be non-empty and distinct")
+	}
+	return s, nil
+}
+
+// setMap sets the map value to a new map holding the entries of str. Keys
+// and values are set as setValue would set fields of the key and element
+// types; slice values are split into elements, and the elements of a
+// repeated key's slice values are appended.
+func setMap(cfg *config, value reflect.Value, key, str string) error {
+	seps, err := cfg.mapSeparators()
+	if err != nil {
+		return err
+	}
+	t := value.Type()
+	m := reflect.MakeMap(t)
+	for _, entry := range strings.Split(str, seps.entry) {
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, seps.key, 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("map entry %q has no %q separator", entry, seps.key)
+		}
+		k := reflect.New(t.Key()).Elem()
+		if err := setValue(cfg, k, key, kv[0], nil); err != nil {
+			return err
+		}
+		prev := m.MapIndex(k)
+		v := reflect.New(t.Elem()).Elem()
+		if t.Elem().Kind() == reflect.Slice {
+			var elems []string
+			if kv[1] != "" {
+				elems = strings.Split(kv[1], seps.value)
+			}
+			if err := setSlice(cfg, v, key, elems); err != nil {
+				return err
+			}
+			if prev.IsValid() {
+				v = reflect.AppendSlice(prev, v)
+			}
+		} else {
+			if prev.IsValid() {
+				return fmt.Errorf("duplicate map key %q", kv[0])
+			}
+			if err := setValue(cfg, v, key, kv[1], nil); err != nil {
+				return err
+			}
+		}
+		m.SetMapIndex(k, v)
+	}
+	value.Set(m)
+	return nil
+}
+
+// formatMap formats the map value, or a pointer to one, with its entries
+// sorted by their formatted keys, so that setMap would set an equal map.
+func formatMap(cfg *config, value reflect.Value) (string, error) {
+	seps, err := cfg.mapSeparators()
+	if err != nil {
+		return "", err
+	}
+	value = reflect.Indirect(value)
+	entries := make([]string, 0, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		k, err := formatValue(iter.Key())
+		if err != nil {
+			return "", err
+		}
+		var v string
+		if iter.Value().Kind() == reflect.Slice {
+			elems, err := formatSlice(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			v = strings.Join(elems, seps.value)
+		} else if v, err = formatValue(iter.Value()); err != nil {
+			return "", err
+		}
+		entries = append(entries, k+seps.key+v)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, seps.entry), nil
+}
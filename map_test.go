@@ -0,0 +1,62 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFields(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Roles  map[string][]string `env:"ROLES"`
+		Limits map[string]int      `env:"LIMITS=api:10,web:20"`
+		Ports  *map[int][]int      `env:"PORTS"`
+	}
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{
+		"ROLES": "admin:read|write,user:read,guest:,user:list",
+		"PORTS": "1:80|443",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"admin": {"read", "write"},
+		"user":  {"read", "list"},
+		"guest": {},
+	}, s.Roles)
+	require.Equal(t, map[string]int{"api": 10, "web": 20}, s.Limits)
+	require.Equal(t, map[int][]int{1: {80, 443}}, *s.Ports)
+
+	env, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"ROLES":  "admin:read|write,guest:,user:read|list",
+		"LIMITS": "api:10,web:20",
+		"PORTS":  "1:80|443",
+	}, env)
+
+	for val, msg := range map[string]string{
+		"api":         `map entry "api" has no ":" separator`,
+		"api:x":       `strconv.ParseInt: parsing "x": invalid syntax`,
+		"api:1,api:2": `duplicate map key "api"`,
+	} {
+		err = Unmarshal(&s, Map(map[string]string{"LIMITS": val}))
+		require.EqualError(t, err, msg+": field Limits (map) in struct S", val)
+	}
+
+	var t2 S
+	options := []Option{MapSeparators(";", "=", "+"), Map(map[string]string{"ROLES": "admin=read+write;user=read", "LIMITS": "api=1"})}
+	require.NoError(t, Unmarshal(&t2, options...))
+	require.Equal(t, map[string][]string{"admin": {"read", "write"}, "user": {"read"}}, t2.Roles)
+	env, err = Marshal(&t2, options...)
+	require.NoError(t, err)
+	require.Equal(t, "admin=read+write;user=read", env["ROLES"])
+
+	err = Unmarshal(&t2, MapSeparators(",", ",", "|"), Map(map[string]string{"ROLES": "a,b"}))
+	require.EqualError(t, err, "map separators must be non-empty and distinct: field Roles (map) in struct S")
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Marshaler can format itself as an environment value. It's the
+// counterpart to the Set function used by Unmarshal, and should return a
+// string that Set accepts.
+type Marshaler interface {
+	MarshalEnv() (string, error)
+}
+
+// A Secreter is a type whose values are sensitive. Fields of a type
+// implementing Secreter, or of a pointer to one, are treated as if they had
+// the secret modifier when SecretEnv returns true.
+type Secreter interface {
+	SecretEnv() bool
+}
+
+// Marshal takes a pointer to a struct, and returns the desired values of its
+// tagged fields, keyed by their environment keys, such that Unmarshal would
+// set the fields to their current values. Values are formatted by
+// formatValue, described below. Fields that are nil pointers are omitted, as
+// are secret fields, unless the IncludeSecrets option is given. Fields with
+// the encrypted modifier are always omitted, since Marshal can't encrypt
+// their values.
+//
+// Interface fields configured via Implementations are formatted as the name
+// of the implementation whose function returns a value of the field's
+// dynamic type.
+//
+// Marshal returns an error if a field can't be formatted, or if two fields
+// with the same key have different values.
+func Marshal(in interface{}, options ...Option) (map[string]string, error) {
+	if !isStructPtr(in) {
+		return nil, errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(options)
+
+	env := make(map[string]string)
+	err := visit(in, "", func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		key := c.info.key
+		if len(key) == 0 {
+			return nil
+		}
+		if c.info.secret && !config.includeSecrets {
+			return nil
+		}
+		if _, ok := c.info.mods["encrypted"]; ok {
+			return nil
+		}
+		if c.value.Kind() == reflect.Ptr && c.value.IsNil() {
+			return nil
+		}
+		if _, ok := isLazy(c.value); ok {
+			return errSkipField
+		}
+
+		if _, ok := c.info.mods["oneof"]; ok {
+			name, err := selectedVariant(c.value)
+			if err != nil {
+				return config.fieldError(CodeFormatFailure, err, c)
+			}
+			if name != "" {
+				env[key] = name
+			}
+			return nil
+		}
+		if _, ok := c.info.mods["envmap"]; ok {
+			entries, err := config.marshalEnvMap(c)
+			if err != nil {
+				return config.fieldError(CodeFormatFailure, err, c)
+			}
+			for k, str := range entries {
+				if prev, ok := env[k]; ok && prev != str {
+					return config.fieldError(CodeFormatFailure, fmt.Errorf("conflicting values for key %v", k), c)
+				}
+				env[k] = str
+			}
+			return nil
+		}
+
+		str, err := config.marshalField(c.info, c.value)
+		if err != nil {
+			return config.fieldError(CodeFormatFailure, err, c)
+		}
+		if prev, ok := env[key]; ok && prev != str {
+			return config.fieldError(CodeFormatFailure, fmt.Errorf("conflicting values for key %v", key), c)
+		}
+		env[key] = str
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Environ returns base, in the "KEY=value" form of os.Environ, with the
+// values from Marshal merged over it. Entries in base whose keys Marshal
+// returns are replaced in place, and the remaining keys are appended in
+// sorted order. The result is suitable for exec.Cmd's Env field, as in:
+//
+//	cmd.Env, err = fromenv.Environ(&cfg, os.Environ())
+func Environ(in interface{}, base []string, options ...Option) ([]string, error) {
+	env, err := Marshal(in, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	environ := make([]string, 0, len(base)+len(env))
+	merged := make(map[string]struct{})
+	for _, kv := range base {
+		key := strings.SplitN(kv, tagSep, 2)[0]
+		if v, ok := env[key]; ok {
+			if _, dup := merged[key]; dup {
+				continue
+			}
+			kv = key + tagSep + v
+			merged[key] = struct{}{}
+		}
+		environ = append(environ, kv)
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		if _, ok := merged[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		environ = append(environ, key+tagSep+env[key])
+	}
+	return environ, nil
+}
+
+// IncludeSecrets configures Marshal to include the values of secret fields.
+func IncludeSecrets() Option {
+	return func(c *config) {
+		c.includeSecrets = true
+	}
+}
+
+// marshalField formats the value of the field, by marshalValue, unless one
+// of its modifiers gives the format of the value.
+func (config *config) marshalField(info *fieldInfo, value reflect.Value) (string, error) {
+	if _, ok := info.mods["shellwords"]; ok {
+		return formatShellWords(value)
+	}
+	if _, ok := info.mods["csv"]; ok {
+		return formatCSV(value)
+	}
+	if layouts, ok := info.mods["layouts"]; ok {
+		return formatLayouts(value, layouts)
+	}
+	return marshalValue(config, value)
+}
+
+// marshalValue formats value, handling interfaces configured via
+// Implementations, and maps separated as configured by MapSeparators.
+func marshalValue(config *config, value reflect.Value) (string, error) {
+	if value.Kind() != reflect.Interface {
+		s, err := formatValue(value)
+		if errors.Is(err, errUnsupportedType) && reflect.Indirect(value).Kind() == reflect.Map {
+			return formatMap(config, value)
+		}
+		return s, err
+	}
+	impls, ok := config.impls[value.Type()]
+	if !ok || value.IsNil() {
+		return formatValue(value)
+	}
+	t := value.Elem().Type()
+	for name, fn := range impls {
+		if x := reflect.ValueOf(fn()); x.IsValid() && x.Type() == t {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no implementation of type %v", t.String())
+}
+
+// formatValue returns value formatted as an environment value, by whichever
+// method matches first:
+//
+// * If the value satisfies Marshaler, then its MarshalEnv function.
+//
+// * If the value satisfies encoding.TextMarshaler, then its MarshalText function.
+//
+// * If the value satisfies fmt.Stringer, then its String function.
+//
+// * If the value is a boolean, numeric, or string type, then the appropriate
+// strconv function.
+//
+// Methods with pointer receivers are used if value is addressable. A nil
+// pointer is formatted as the empty string.
+func formatValue(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	candidates := []reflect.Value{value}
+	if value.CanAddr() {
+		candidates = append(candidates, value.Addr())
+	}
+	// Marshaler takes precedence over the other interfaces, whichever
+	// receiver each is implemented with.
+	for _, v := range candidates {
+		if !v.CanInterface() {
+			continue
+		}
+		if m, ok := v.Interface().(Marshaler); ok {
+			return m.MarshalEnv()
+		}
+	}
+	for _, v := range candidates {
+		if !v.CanInterface() {
+			continue
+		}
+		switch i := v.Interface().(type) {
+		case encoding.TextMarshaler:
+			b, err := i.MarshalText()
+			return string(b), err
+		case fmt.Stringer:
+			return i.String(), nil
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+
+	case reflect.Float64, reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits()), nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	}
+
+	return "", fmt.Errorf("%w: %v", errUnsupportedType, value.Type().String())
+}
@@ -0,0 +1,199 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testMarshaler struct {
+	s string
+}
+
+func (tm *testMarshaler) Set(s string) error {
+	tm.s = s
+	return nil
+}
+
+func (tm *testMarshaler) MarshalEnv() (string, error) {
+	if tm.s == "" {
+		return "", errors.New("empty testMarshaler")
+	}
+	return tm.s, nil
+}
+
+func (tm *testMarshaler) String() string {
+	return "not-used"
+}
+
+// testLevel has a MarshalEnv method with a pointer receiver, and a String
+// method with a value receiver.
+type testLevel int
+
+func (l *testLevel) MarshalEnv() (string, error) {
+	return strconv.Itoa(int(*l)), nil
+}
+
+func (l testLevel) String() string {
+	return "level-name"
+}
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+
+	var s struct {
+		Str   string
+		Int   int8
+		Uint  uint
+		F32   float32
+		F64   float64
+		Bool  bool
+		D     time.Duration
+		T     time.Time
+		IP    net.IP
+		M     testMarshaler
+		Mptr  *testMarshaler
+		Level testLevel
+		Iface interface{}
+	}
+	s.Str = "str"
+	s.Int = -8
+	s.Uint = 8
+	s.F32 = 1.1
+	s.F64 = 0.25
+	s.Bool = true
+	s.D = 90 * time.Second
+	s.T = time.Date(2021, 3, 23, 10, 0, 0, 0, time.UTC)
+	s.IP = net.ParseIP("10.0.0.1")
+	s.M.s = "marshaled"
+	s.Level = 3
+
+	v := reflect.ValueOf(&s).Elem()
+	format := func(name string) string {
+		str, err := formatValue(v.FieldByName(name))
+		require.NoError(t, err)
+		return str
+	}
+	require.Equal(t, "str", format("Str"))
+	require.Equal(t, "-8", format("Int"))
+	require.Equal(t, "8", format("Uint"))
+	require.Equal(t, "1.1", format("F32"))
+	require.Equal(t, "0.25", format("F64"))
+	require.Equal(t, "true", format("Bool"))
+	require.Equal(t, "1m30s", format("D"))
+	require.Equal(t, "2021-03-23T10:00:00Z", format("T"))
+	require.Equal(t, "10.0.0.1", format("IP"))
+	require.Equal(t, "marshaled", format("M"))
+	require.Equal(t, "", format("Mptr"))
+	require.Equal(t, "3", format("Level"))
+
+	env, err := Marshal(&struct {
+		Level testLevel `env:"LEVEL"`
+	}{3})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"LEVEL": "3"}, env)
+
+	s.Mptr = &testMarshaler{}
+	_, err = formatValue(v.FieldByName("Mptr"))
+	require.EqualError(t, err, "empty testMarshaler")
+
+	_, err = formatValue(v.FieldByName("Iface"))
+	require.EqualError(t, err, "unsupported type: interface {}")
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Level string `env:"LEVEL"`
+	}
+	type S struct {
+		Host     string        `env:"HOST=localhost"`
+		Port     int           `env:"PORT"`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Password string        `env:"PASSWORD" envopt:"secret"`
+		Opt      *int          `env:"OPT"`
+		Inner    Inner
+		InnerPtr *Inner
+		Storage  testStorage    `env:"STORAGE"`
+		M        *testMarshaler `env:"M"`
+		notag    int
+	}
+
+	impls := Implementations((*testStorage)(nil), map[string]func() interface{}{
+		"disk": func() interface{} { return &testDiskStorage{} },
+		"s3":   func() interface{} { return &testS3Storage{} },
+	})
+
+	in := S{
+		Host:     "h1",
+		Port:     80,
+		Timeout:  time.Minute,
+		Password: "hunter2",
+		Inner:    Inner{"debug"},
+		Storage:  &testS3Storage{Bucket: "b1"},
+		M:        &testMarshaler{"m1"},
+	}
+	env, err := Marshal(&in, impls)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"HOST":      "h1",
+		"PORT":      "80",
+		"TIMEOUT":   "1m0s",
+		"LEVEL":     "debug",
+		"STORAGE":   "s3",
+		"S3_BUCKET": "b1",
+		"M":         "m1",
+	}, env)
+
+	env, err = Marshal(&in, impls, IncludeSecrets())
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", env["PASSWORD"])
+
+	// Round trip back through Unmarshal.
+	var out S
+	err = Unmarshal(&out, Map(env), impls, CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, in, out)
+
+	in.InnerPtr = &Inner{"info"}
+	_, err = Marshal(&in, impls)
+	require.EqualError(t, err, "conflicting values for key LEVEL: field Level (string) in struct Inner")
+
+	in.InnerPtr = nil
+	_, err = Marshal(&in)
+	require.EqualError(t, err, "unsupported type: fromenv.testStorage: field Storage (interface) in struct S")
+
+	_, err = Marshal(in)
+	require.EqualError(t, err, "passed non-pointer or nil pointer")
+}
+
+func TestEnviron(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+		Name string `env:"NAME"`
+	}
+
+	in := S{"h1", 80, "n1"}
+	base := []string{"PATH=/bin", "PORT=8080", "HOME=/root", "PORT=9090"}
+	environ, err := Environ(&in, base)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PATH=/bin", "PORT=80", "HOME=/root", "HOST=h1", "NAME=n1"}, environ)
+	require.Equal(t, []string{"PATH=/bin", "PORT=8080", "HOME=/root", "PORT=9090"}, base)
+
+	environ, err = Environ(&in, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"HOST=h1", "NAME=n1", "PORT=80"}, environ)
+}
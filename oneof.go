@@ -0,0 +1,162 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A unionVariant is a variant field of a struct with the oneof modifier.
+type unionVariant struct {
+	name  string
+	field int
+}
+
+// unionVariants returns the variants of the union struct type t, or of
+// the type t points to: its exported fields that are pointers to structs,
+// named by their variant modifier, or by their lowercased field name.
+func unionVariants(t reflect.Type) ([]unionVariant, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.New("oneof modifier requires a struct field")
+	}
+	var variants []unionVariant
+	names := make(map[string]string)
+	info := cachedStruct(t)
+	for i := range info.fields {
+		f := &info.fields[i]
+		if f.field.PkgPath != "" {
+			continue
+		}
+		if f.field.Type.Kind() != reflect.Ptr || f.field.Type.Elem().Kind() != reflect.Struct {
+			if _, ok := f.mods["variant"]; ok {
+				return nil, fmt.Errorf("variant field %v isn't a pointer to a struct", f.field.Name)
+			}
+			continue
+		}
+		name, ok := f.mods["variant"]
+		if !ok || name == "" {
+			name = strings.ToLower(f.field.Name)
+		}
+		if prev, ok := names[name]; ok {
+			return nil, fmt.Errorf("variant %q used by fields %v and %v", name, prev, f.field.Name)
+		}
+		names[name] = f.field.Name
+		variants = append(variants, unionVariant{name, i})
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("oneof field has no variants")
+	}
+	return variants, nil
+}
+
+// variantNames returns the names of the variants, separated by commas.
+func variantNames(variants []unionVariant) string {
+	names := make([]string, len(variants))
+	for i, v := range variants {
+		names[i] = v.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectVariant sets the variant of the union struct value named by sel to
+// a new struct, unless it's already set, and the other variants to nil, so
+// that only the selected variant is decoded, and returns the index of the
+// selected variant's field. If sel is nil, every variant is set to nil,
+// unless value is a nil pointer, and the index is -1.
+func selectVariant(value reflect.Value, sel *string) (int, error) {
+	variants, err := unionVariants(value.Type())
+	if err != nil {
+		return -1, err
+	}
+	selected := -1
+	if sel != nil {
+		for _, v := range variants {
+			if v.name == *sel {
+				selected = v.field
+			}
+		}
+		if selected < 0 {
+			return -1, fmt.Errorf("unknown variant %q (want one of %v)", *sel, variantNames(variants))
+		}
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if sel == nil {
+				return -1, nil
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	for _, v := range variants {
+		f := value.Field(v.field)
+		if v.field != selected {
+			f.Set(reflect.Zero(f.Type()))
+		} else if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+	}
+	return selected, nil
+}
+
+// selectedVariant returns the name of the set variant of the union struct
+// value, or the empty string if none is set.
+func selectedVariant(value reflect.Value) (string, error) {
+	variants, err := unionVariants(value.Type())
+	if err != nil {
+		return "", err
+	}
+	value = reflect.Indirect(value)
+	if !value.IsValid() {
+		return "", nil
+	}
+	name := ""
+	for _, v := range variants {
+		if value.Field(v.field).IsNil() {
+			continue
+		}
+		if name != "" {
+			return "", fmt.Errorf("variants %v and %v are both set", name, v.name)
+		}
+		name = v.name
+	}
+	return name, nil
+}
+
+// decodeUnion selects the variant of the union struct field at the cursor,
+// which has the oneof modifier, named by the value of its key, and decodes
+// it. It returns the number of fields set, including the union field.
+func (config *config) decodeUnion(c *cursor, allocating []reflect.Type) (int, error) {
+	if _, err := unionVariants(c.field.Type); err != nil {
+		return 0, config.fieldError(CodeInvalidTag, err, c)
+	}
+	val, source, layer, err := config.resolveField(c, c.info)
+	if err != nil {
+		return 0, config.fieldError(CodeLookupFailure, err, c)
+	}
+	selected, err := selectVariant(c.value, val)
+	if err != nil {
+		return 0, config.parseError(err, c, *val)
+	}
+	if val == nil {
+		config.tracef("not found, and no default: no variant selected")
+		config.record(c, c.info.key, SourceNone, "", "")
+		return 0, nil
+	}
+	config.tracef("selected variant %q", *val)
+	config.record(c, c.info.key, source, layer, *val)
+
+	union := reflect.Indirect(c.value)
+	path := joinPath(c.path, union.Type().Field(selected).Name)
+	n, err := decode(config, union.Field(selected).Interface(), path, allocating)
+	return n + 1, err
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneofModifier(t *testing.T) {
+	t.Parallel()
+
+	type S3 struct {
+		Bucket string `env:"S3_BUCKET" envopt:"required"`
+	}
+	type GCS struct {
+		Bucket string `env:"GCS_BUCKET" envopt:"required"`
+	}
+	type Disk struct {
+		Dir string `env:"DISK_DIR=/var/lib/app"`
+	}
+	type Storage struct {
+		S3   *S3
+		GCS  *GCS
+		Disk *Disk `envopt:"variant=local"`
+	}
+	type Config struct {
+		Storage Storage `env:"STORAGE_DRIVER=local" envopt:"oneof"`
+	}
+
+	var cfg Config
+	var result Result
+	require.NoError(t, Unmarshal(&cfg, Map(map[string]string{"STORAGE_DRIVER": "s3", "S3_BUCKET": "b"}), Record(&result)))
+	require.Equal(t, "Storage", result.Fields[0].Path)
+	require.Equal(t, "Storage.S3.Bucket", result.Fields[1].Path)
+	require.Equal(t, &S3{"b"}, cfg.Storage.S3)
+	require.Nil(t, cfg.Storage.GCS)
+	require.Nil(t, cfg.Storage.Disk)
+
+	require.NoError(t, Unmarshal(&cfg, Map(nil)))
+	require.Nil(t, cfg.Storage.S3)
+	require.Equal(t, &Disk{"/var/lib/app"}, cfg.Storage.Disk)
+
+	env, err := Marshal(&cfg)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"STORAGE_DRIVER": "local", "DISK_DIR": "/var/lib/app"}, env)
+
+	err = Unmarshal(&cfg, Map(map[string]string{"STORAGE_DRIVER": "gcs"}))
+	require.EqualError(t, err, "missing required key: field Bucket (string) in struct GCS")
+
+	err = Unmarshal(&cfg, Map(map[string]string{"STORAGE_DRIVER": "ftp"}))
+	require.EqualError(t, err, `unknown variant "ftp" (want one of s3, gcs, local): field Storage (struct) in struct Config`)
+
+	var buf bytes.Buffer
+	require.NoError(t, Usage(&buf, &Config{}))
+	require.Contains(t, buf.String(), "(one of s3, gcs, local)")
+
+	type Optional struct {
+		Storage *Storage `env:"STORAGE_DRIVER" envopt:"oneof"`
+	}
+	var opt Optional
+	require.NoError(t, Unmarshal(&opt, Map(nil), AllocateNested()))
+	require.Nil(t, opt.Storage)
+	require.NoError(t, Unmarshal(&opt, Map(map[string]string{"STORAGE_DRIVER": "gcs", "GCS_BUCKET": "g"}), AllocateNested()))
+	require.Equal(t, &Storage{GCS: &GCS{"g"}}, opt.Storage)
+
+	cfg.Storage = Storage{S3: &S3{}, Disk: &Disk{}}
+	_, err = Marshal(&cfg)
+	require.EqualError(t, err, "variants s3 and local are both set: field Storage (struct) in struct Config")
+
+	type Dup struct {
+		A *S3 `envopt:"variant=x"`
+		B *S3 `envopt:"variant=x"`
+	}
+	type Bad struct {
+		Name  string `env:"NAME" envopt:"oneof"`
+		Dup   Dup    `env:"DUP" envopt:"oneof"`
+		Empty struct {
+			N int
+		} `env:"EMPTY" envopt:"oneof"`
+	}
+	require.EqualError(t, Check(&Bad{}), "3 errors\ntop level (3):\n"+
+		"    oneof modifier requires a struct field: field Name (string) in struct Bad\n"+
+		`    variant "x" used by fields A and B: field Dup (struct) in struct Bad`+"\n"+
+		"    oneof field has no variants: field Empty (struct) in struct Bad")
+}
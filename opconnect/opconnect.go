@@ -0,0 +1,187 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package opconnect looks up fromenv keys with a 1Password Connect server
+// (https://developer.1password.com/docs/connect), either by resolving
+// secret references like "op://vault/item/field" found in environment
+// values, or by serving the fields of an item as keys.
+package opconnect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// RefPrefix starts a secret reference.
+const RefPrefix = "op://"
+
+// A Client makes requests to a 1Password Connect server. Items are fetched
+// once per Client, and then kept.
+type Client struct {
+	// URL is the Connect server's URL.
+	URL string
+	// Token is a Connect access token.
+	Token string
+	// HTTPClient makes requests; if nil, http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	items map[[2]string]map[string]string
+}
+
+// References returns a lookup function that looks up keys with next, or
+// in the process environment if next is nil, and resolves values that
+// are secret references, of the form "op://vault/item/field", with
+// Resolve. Other values are returned as found.
+func (c *Client) References(ctx context.Context, next fromenv.LookupEnvFunc) fromenv.LookupEnvFunc {
+	return func(key string) (*string, error) {
+		var v *string
+		if next != nil {
+			var err error
+			if v, err = next(key); err != nil {
+				return nil, err
+			}
+		} else if s, ok := os.LookupEnv(key); ok {
+			v = &s
+		}
+		if v == nil || !strings.HasPrefix(*v, RefPrefix) {
+			return v, nil
+		}
+		s, err := c.Resolve(ctx, *v)
+		if err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+}
+
+// Item returns a lookup function that looks up keys as the labels of the
+// fields of the item named item in the vault named vault.
+func (c *Client) Item(ctx context.Context, vault, item string) (fromenv.LookupEnvFunc, error) {
+	fields, err := c.fields(ctx, vault, item)
+	if err != nil {
+		return nil, err
+	}
+	return func(key string) (*string, error) {
+		if v, ok := fields[key]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	}, nil
+}
+
+// Resolve returns the value of the field referred to by ref, a secret
+// reference of the form "op://vault/item/field".
+func (c *Client) Resolve(ctx context.Context, ref string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(ref, RefPrefix), "/")
+	if !strings.HasPrefix(ref, RefPrefix) || len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("invalid secret reference: %q", ref)
+	}
+	fields, err := c.fields(ctx, parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+	v, ok := fields[parts[2]]
+	if !ok {
+		return "", fmt.Errorf("1password: no field %q in item %q", parts[2], parts[1])
+	}
+	return v, nil
+}
+
+// fields returns the values of the fields of an item, by label.
+func (c *Client) fields(ctx context.Context, vault, item string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fields, ok := c.items[[2]string{vault, item}]; ok {
+		return fields, nil
+	}
+
+	var vaults []struct {
+		ID string `json:"id"`
+	}
+	q := url.Values{"filter": {fmt.Sprintf("name eq %q", vault)}}
+	if err := c.get(ctx, "/v1/vaults?"+q.Encode(), &vaults); err != nil {
+		return nil, err
+	}
+	if len(vaults) == 0 {
+		return nil, fmt.Errorf("1password: no vault %q", vault)
+	}
+
+	var items []struct {
+		ID string `json:"id"`
+	}
+	q = url.Values{"filter": {fmt.Sprintf("title eq %q", item)}}
+	if err := c.get(ctx, "/v1/vaults/"+vaults[0].ID+"/items?"+q.Encode(), &items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("1password: no item %q in vault %q", item, vault)
+	}
+
+	var full struct {
+		Fields []struct {
+			Label string `json:"label"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := c.get(ctx, "/v1/vaults/"+vaults[0].ID+"/items/"+items[0].ID, &full); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	for _, f := range full.Fields {
+		fields[f.Label] = f.Value
+	}
+	if c.items == nil {
+		c.items = make(map[[2]string]map[string]string)
+	}
+	c.items[[2]string{vault, item}] = fields
+	return fields, nil
+}
+
+// get makes a request to the Connect server, decoding the JSON response
+// into v.
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	if c.URL == "" {
+		return errors.New("1password: no Connect server URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		if body.Message != "" {
+			return fmt.Errorf("1password: %s: %s", resp.Status, body.Message)
+		}
+		return fmt.Errorf("1password: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("1password: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package opconnect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T, requests *int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"message":"Invalid token signature"}`))
+			return
+		}
+		filter := r.URL.Query().Get("filter")
+		switch {
+		case r.URL.Path == "/v1/vaults" && filter == `name eq "prod"`:
+			_, _ = w.Write([]byte(`[{"id":"v1"}]`))
+		case r.URL.Path == "/v1/vaults":
+			_, _ = w.Write([]byte(`[]`))
+		case r.URL.Path == "/v1/vaults/v1/items" && filter == `title eq "db"`:
+			_, _ = w.Write([]byte(`[{"id":"i1"}]`))
+		case r.URL.Path == "/v1/vaults/v1/items":
+			_, _ = w.Write([]byte(`[]`))
+		case r.URL.Path == "/v1/vaults/v1/items/i1":
+			_, _ = w.Write([]byte(`{"id":"i1","fields":[{"label":"password","value":"hunter2"},{"label":"DB_USER","value":"admin"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReferences(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	c := &Client{URL: testServer(t, &requests).URL, Token: "token"}
+
+	type S struct {
+		Password  string `env:"DB_PASSWORD" envopt:"secret"`
+		Password2 string `env:"DB_PASSWORD2"`
+		Host      string `env:"DB_HOST"`
+	}
+	env := map[string]string{
+		"DB_PASSWORD":  "op://prod/db/password",
+		"DB_PASSWORD2": "op://prod/db/password",
+		"DB_HOST":      "db.internal",
+	}
+	next := func(key string) (*string, error) {
+		if v, ok := env[key]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Looker(c.References(ctx, next)))
+	require.NoError(t, err)
+	require.Equal(t, S{"hunter2", "hunter2", "db.internal"}, s)
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	for ref, msg := range map[string]string{
+		"op://prod/db":         `invalid secret reference: "op://prod/db"`,
+		"op://prod/db/missing": `1password: no field "missing" in item "db"`,
+		"op://prod/cache/pw":   `1password: no item "cache" in vault "prod"`,
+		"op://staging/db/pw":   `1password: no vault "staging"`,
+	} {
+		_, err := c.Resolve(ctx, ref)
+		require.EqualError(t, err, msg)
+	}
+
+	bad := &Client{URL: c.URL, Token: "bad"}
+	_, err = bad.Resolve(ctx, "op://prod/db/password")
+	require.EqualError(t, err, "1password: 401 Unauthorized: Invalid token signature")
+}
+
+func TestItem(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	c := &Client{URL: testServer(t, &requests).URL, Token: "token"}
+
+	f, err := c.Item(context.Background(), "prod", "db")
+	require.NoError(t, err)
+	var s struct {
+		User string `env:"DB_USER"`
+		Port int    `env:"DB_PORT=5432"`
+	}
+	require.NoError(t, fromenv.Unmarshal(&s, fromenv.Looker(f)))
+	require.Equal(t, "admin", s.User)
+	require.Equal(t, 5432, s.Port)
+}
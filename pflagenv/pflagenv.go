@@ -0,0 +1,52 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package pflagenv binds tagged struct fields to pflag flag sets and cobra
+// commands, so that command-line flags override values set from the
+// environment.
+package pflagenv
+
+import (
+	"errors"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// BindFlags defines a flag in fs for each Flag returned by fromenv.Flags,
+// with the Flag's shorthand and usage, so that parsing fs overrides the
+// values that in's fields were set to from the environment. BindFlags
+// returns an error if a flag name or shorthand is already defined in fs.
+func BindFlags(fs *pflag.FlagSet, in interface{}, options ...fromenv.Option) error {
+	flags, err := fromenv.Flags(in, options...)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range flags {
+		if fs.Lookup(f.Name) != nil {
+			return errors.New("flag redefined: " + f.Name)
+		}
+		if f.Shorthand != "" && fs.ShorthandLookup(f.Shorthand) != nil {
+			return errors.New("flag shorthand redefined: " + f.Shorthand)
+		}
+		pf := fs.VarPF(f.Value, f.Name, f.Shorthand, f.Usage)
+		if f.Value.IsBoolFlag() {
+			pf.NoOptDefVal = "true"
+		}
+	}
+	return nil
+}
+
+// BindCommand is like BindFlags, defining the flags in cmd's flag set.
+func BindCommand(cmd *cobra.Command, in interface{}, options ...fromenv.Option) error {
+	return BindFlags(cmd.Flags(), in, options...)
+}
+
+// BindPersistent is like BindFlags, defining the flags in cmd's persistent
+// flag set, so that they're also available to cmd's subcommands.
+func BindPersistent(cmd *cobra.Command, in interface{}, options ...fromenv.Option) error {
+	return BindFlags(cmd.PersistentFlags(), in, options...)
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package pflagenv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	DBURL   string        `env:"DB_URL=postgres://localhost" envflag:",d" envdoc:"database URL"`
+	Verbose bool          `env:"VERBOSE" envflag:"verbose,v"`
+	Timeout time.Duration `env:"TIMEOUT=5s"`
+}
+
+func TestBindFlags(t *testing.T) {
+	t.Parallel()
+
+	var c config
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	err := BindFlags(fs, &c, fromenv.Map(map[string]string{"TIMEOUT": "1m"}), fromenv.CommonTypes())
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, c.Timeout)
+
+	usage := fs.FlagUsages()
+	require.Contains(t, usage, "-d, --db-url string")
+	require.Contains(t, usage, "database URL (environment variable DB_URL) (default \"postgres://localhost\")")
+	require.Contains(t, usage, "-v, --verbose")
+
+	err = fs.Parse([]string{"-d", "postgres://db", "-v", "--timeout=2m"})
+	require.NoError(t, err)
+	require.Equal(t, config{"postgres://db", true, 2 * time.Minute}, c)
+
+	var c2 config
+	err = BindFlags(fs, &c2, fromenv.Map(nil), fromenv.CommonTypes())
+	require.EqualError(t, err, "flag redefined: db-url")
+}
+
+func TestBindCommand(t *testing.T) {
+	t.Parallel()
+
+	var c config
+	var ran bool
+	cmd := &cobra.Command{
+		Use: "test",
+		RunE: func(*cobra.Command, []string) error {
+			ran = true
+			return nil
+		},
+	}
+	err := BindPersistent(cmd, &c, fromenv.Map(map[string]string{"VERBOSE": "true"}), fromenv.CommonTypes())
+	require.NoError(t, err)
+	require.True(t, c.Verbose)
+
+	cmd.SetArgs([]string{"--verbose=false", "--db-url", "postgres://db"})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+	require.True(t, ran)
+	require.False(t, c.Verbose)
+	require.Equal(t, "postgres://db", c.DBURL)
+
+	var c2 config
+	cmd2 := &cobra.Command{Use: "test"}
+	require.NoError(t, BindCommand(cmd2, &c2, fromenv.Map(nil), fromenv.CommonTypes()))
+	require.NotNil(t, cmd2.Flags().Lookup("timeout"))
+}
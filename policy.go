@@ -0,0 +1,60 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"path"
+)
+
+// A PolicyError is returned for a tagged field whose key may not be looked
+// up, as configured via AllowKeys and DenyKeys.
+type PolicyError struct {
+	Key string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("key %v not allowed by lookup policy", e.Key)
+}
+
+// AllowKeys restricts the keys that may be looked up to those matching one
+// of the given patterns, which use the syntax of path.Match; for example,
+// "APP_*". A field whose key doesn't match is an error, rather than being
+// looked up. Repeated uses of AllowKeys add to the allowed patterns.
+func AllowKeys(patterns ...string) Option {
+	return func(c *config) {
+		c.allowKeys = append(append([]string{}, c.allowKeys...), patterns...)
+	}
+}
+
+// DenyKeys forbids looking up keys matching any of the given patterns,
+// which use the syntax of path.Match, even if they're allowed by
+// AllowKeys. A field whose key matches is an error, rather than being
+// looked up. It's intended for embedding structs from other packages,
+// to ensure they can't read keys such as "AWS_*".
+func DenyKeys(patterns ...string) Option {
+	return func(c *config) {
+		c.denyKeys = append(append([]string{}, c.denyKeys...), patterns...)
+	}
+}
+
+// checkKey returns a PolicyError if key may not be looked up.
+func (c *config) checkKey(key string) error {
+	if matchAny(c.denyKeys, key) || (c.allowKeys != nil && !matchAny(c.allowKeys, key)) {
+		return &PolicyError{key}
+	}
+	return nil
+}
+
+// matchAny returns whether key matches any of patterns. Malformed patterns
+// match nothing.
+func matchAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	t.Parallel()
+
+	type Vendor struct {
+		Region string `env:"AWS_REGION=us-east-1"`
+		Secret string `env:"AWS_SECRET_ACCESS_KEY"`
+	}
+	type S struct {
+		Port   int `env:"APP_PORT"`
+		Vendor Vendor
+	}
+
+	var mu sync.Mutex
+	var looked []string
+	looker := Looker(func(key string) (*string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		looked = append(looked, key)
+		v := "1"
+		return &v, nil
+	})
+
+	var s S
+	err := Unmarshal(&s, looker, AllowKeys("APP_*", "AWS_REGION"))
+	require.EqualError(t, err, "key AWS_SECRET_ACCESS_KEY not allowed by lookup policy: field Secret (string) in struct Vendor")
+	var perr *PolicyError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, "AWS_SECRET_ACCESS_KEY", perr.Key)
+	require.Equal(t, []string{"APP_PORT", "AWS_REGION"}, looked)
+
+	looked = nil
+	err = Unmarshal(&s, looker, DenyKeys("AWS_SECRET_*"), ConcurrentLookups(2))
+	require.EqualError(t, err, "key AWS_SECRET_ACCESS_KEY not allowed by lookup policy: field Secret (string) in struct Vendor")
+	require.ElementsMatch(t, []string{"APP_PORT", "AWS_REGION"}, looked)
+
+	looked = nil
+	s = S{}
+	err = Unmarshal(&s, looker, AllowKeys("*"), DenyKeys("AWS_SECRET_*"), SkipTypes(reflect.TypeOf(Vendor{})))
+	require.NoError(t, err)
+	require.Equal(t, 1, s.Port)
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import "strings"
+
+// defaultProfileFormat is the format of profile keys used by Profile.
+const defaultProfileFormat = "{key}_{profile}"
+
+// Profile configures Unmarshal to look up each key with the given profile
+// name before looking it up as is, so that a struct can have values that
+// differ between environments without duplicating its tags. By default,
+// the key looked up first is the key and profile joined by an underscore;
+// for example, with Profile("PROD"), DB_URL is looked up as DB_URL_PROD,
+// and then as DB_URL. An empty name disables profiles.
+func Profile(name string) Option {
+	return func(c *config) {
+		c.profile = name
+	}
+}
+
+// ProfileFormat sets the format of the keys looked up for Profile, in
+// which "{key}" and "{profile}" are replaced by the key and profile name;
+// for example, "{profile}_{key}" looks up PROD_DB_URL.
+func ProfileFormat(format string) Option {
+	return func(c *config) {
+		c.profileFormat = format
+	}
+}
+
+// profileKey returns the key looked up for key with the configured
+// profile.
+func (c *config) profileKey(key string) string {
+	format := c.profileFormat
+	if format == "" {
+		format = defaultProfileFormat
+	}
+	return strings.NewReplacer("{key}", key, "{profile}", c.profile).Replace(format)
+}
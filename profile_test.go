@@ -0,0 +1,62 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		URL   string `env:"DB_URL"`
+		Level string `env:"LEVEL=info"`
+		Name  string `env:"NAME"`
+	}
+
+	env := Map(map[string]string{
+		"DB_URL":      "db-default",
+		"DB_URL_PROD": "db-prod",
+		"PROD_LEVEL":  "warn",
+		"NAME":        "n",
+	})
+
+	var s S
+	require.NoError(t, Unmarshal(&s, env))
+	require.Equal(t, S{"db-default", "info", "n"}, s)
+
+	s = S{}
+	var r Result
+	require.NoError(t, Unmarshal(&s, env, Profile("PROD"), Record(&r)))
+	require.Equal(t, S{"db-prod", "info", "n"}, s)
+	require.Equal(t, "db-prod", r.Field("URL").Value)
+
+	s = S{}
+	require.NoError(t, Unmarshal(&s, env, Profile("PROD"), ProfileFormat("{profile}_{key}")))
+	require.Equal(t, S{"db-default", "warn", "n"}, s)
+
+	// Profile keys are tried in every layer before the plain key.
+	low := lookupMap(map[string]string{"NAME_PROD": "n-low"})
+	s = S{}
+	require.NoError(t, Unmarshal(&s, env, Profile("PROD"), Layers(Layer{Name: EnvLayer}, Layer{"low", low})))
+	require.Equal(t, S{"db-prod", "info", "n-low"}, s)
+
+	// The lookup policy applies to the field's key; profile keys it
+	// doesn't allow are skipped.
+	type P struct {
+		X string `env:"APP_X"`
+	}
+	var p P
+	penv := Map(map[string]string{"APP_X": "x", "PROD_APP_X": "prod-x"})
+	require.NoError(t, Unmarshal(&p, penv, AllowKeys("APP_*"), Profile("PROD"), ProfileFormat("{profile}_{key}")))
+	require.Equal(t, P{"x"}, p)
+	require.NoError(t, Unmarshal(&p, penv, AllowKeys("APP_*", "PROD_*"), Profile("PROD"), ProfileFormat("{profile}_{key}")))
+	require.Equal(t, P{"prod-x"}, p)
+	err := Unmarshal(&p, penv, AllowKeys("PROD_*"), Profile("PROD"), ProfileFormat("{profile}_{key}"))
+	require.Error(t, err)
+}
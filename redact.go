@@ -0,0 +1,114 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A Redactor replaces the value of a secret field wherever it would
+// otherwise be shown: in errors from Unmarshal, in the Values recorded by
+// Record, in the changes reported by Diff and Watchers, and in the fields
+// served by DebugHandler and Publish.
+type Redactor interface {
+	Redact(key, value string) string
+}
+
+// The RedactorFunc type is an adapter to allow the use of ordinary
+// functions as Redactors.
+type RedactorFunc func(key, value string) string
+
+// Redact returns f(key, value).
+func (f RedactorFunc) Redact(key, value string) string {
+	return f(key, value)
+}
+
+// Mask is the default Redactor, which replaces every value with
+// "[REDACTED]".
+var Mask Redactor = RedactorFunc(func(key, value string) string {
+	return redacted
+})
+
+// Hash is a Redactor that replaces values with a "sha256:" prefixed string
+// holding the start of their hash, so that equal values can be recognized.
+var Hash Redactor = RedactorFunc(func(key, value string) string {
+	return hashValue(value)
+})
+
+// ShowLast returns a Redactor that shows only the last n characters of
+// values, replacing the rest with asterisks. Values of n characters or
+// fewer are entirely masked.
+func ShowLast(n int) Redactor {
+	return RedactorFunc(func(key, value string) string {
+		r := []rune(value)
+		if len(r) <= n {
+			return strings.Repeat("*", len(r))
+		}
+		return strings.Repeat("*", len(r)-n) + string(r[len(r)-n:])
+	})
+}
+
+// Redaction configures the Redactor used for the values of secret fields.
+// To set a policy for the whole process, pass it to SetDefaultOptions.
+func Redaction(r Redactor) Option {
+	return func(c *config) {
+		c.redactor = r
+	}
+}
+
+// redact returns value redacted by the configured Redactor.
+func (c *config) redact(key, value string) string {
+	if c.redactor == nil {
+		return Mask.Redact(key, value)
+	}
+	return c.redactor.Redact(key, value)
+}
+
+// redactValue returns v, a field's value, formatted and then redacted.
+func (c *config) redactValue(key string, v interface{}) string {
+	s, err := formatValue(reflect.ValueOf(v))
+	if err != nil {
+		s = fmt.Sprint(v)
+	}
+	return c.redact(key, s)
+}
+
+// redactError returns err with value, the value of a secret field, left
+// out of its message. The value of a *strconv.NumError in err's chain is
+// redacted; if the message still holds the value, as is or quoted, it's
+// replaced by a message without it. The returned error wraps err.
+func (c *config) redactError(err error, key, value string) error {
+	if value == "" {
+		return err
+	}
+	msg, rest := err.Error(), err.Error()
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		clean := &strconv.NumError{Func: ne.Func, Num: c.redact(key, ne.Num), Err: ne.Err}
+		msg = strings.Replace(msg, ne.Error(), clean.Error(), 1)
+		rest = strings.Replace(msg, clean.Error(), "", 1)
+	}
+	quoted := strconv.Quote(value)
+	if strings.Contains(rest, value) || strings.Contains(rest, quoted[1:len(quoted)-1]) {
+		msg = fmt.Sprintf("invalid value %q", c.redact(key, value))
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg, err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,74 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedaction(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Token string `env:"TOKEN" envopt:"secret"`
+		PIN   int    `env:"PIN" envopt:"secret"`
+	}
+
+	require.Equal(t, "[REDACTED]", Mask.Redact("K", "secret"))
+	require.Equal(t, "**cret", ShowLast(4).Redact("K", "secret"))
+	require.Equal(t, "***", ShowLast(4).Redact("K", "abc"))
+	require.Equal(t, hashValue("secret"), Hash.Redact("K", "secret"))
+
+	// Values are redacted from errors.
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"PIN": "12x4"}))
+	require.EqualError(t, err, `strconv.ParseInt: parsing "[REDACTED]": invalid syntax: field PIN (int) in struct S`)
+
+	err = Unmarshal(&s, Map(map[string]string{"PIN": "12x4"}), Redaction(ShowLast(1)))
+	require.EqualError(t, err, `strconv.ParseInt: parsing "***4": invalid syntax: field PIN (int) in struct S`)
+
+	err = Unmarshal(&s, Map(map[string]string{"PIN": `hunter"2\`}))
+	require.EqualError(t, err, `strconv.ParseInt: parsing "[REDACTED]": invalid syntax: field PIN (int) in struct S`)
+
+	type Key struct {
+		Key redactKey `env:"KEY" envopt:"secret"`
+	}
+	err = Unmarshal(&Key{}, Map(map[string]string{"KEY": `a"b`}))
+	require.EqualError(t, err, `invalid value "[REDACTED]": field Key (string) in struct Key`)
+	err = Unmarshal(&Key{}, Map(map[string]string{"KEY": "k"}))
+	require.EqualError(t, err, `invalid value "[REDACTED]": field Key (string) in struct Key`)
+
+	var r Result
+	opt := Redaction(ShowLast(2))
+	err = Unmarshal(&s, Map(map[string]string{"TOKEN": "abcdef", "PIN": "1234"}), Record(&r), opt)
+	require.NoError(t, err)
+	require.Equal(t, "****ef", r.Field("Token").Value)
+	require.Equal(t, "**34", r.Field("PIN").Value)
+
+	changes := Diff(&S{"abcdef", 1}, &S{"abcxyz", 1}, opt)
+	require.Equal(t, []FieldChange{{Path: "Token", Key: "TOKEN", Old: "****ef", New: "****yz", Secret: true}}, changes)
+
+	rec := httptest.NewRecorder()
+	snap := SnapshotFunc(func() (interface{}, *Result) { return &s, &r })
+	DebugHandler(snap, opt).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config", nil))
+	var got struct {
+		Fields []map[string]interface{} `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Equal(t, "****ef", got.Fields[0]["value"])
+	require.Equal(t, "**34", got.Fields[1]["value"])
+}
+
+// redactKey is a type whose Set errors quote the value.
+type redactKey string
+
+func (k *redactKey) Set(s string) error {
+	return fmt.Errorf("bad key %q", s)
+}
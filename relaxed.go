@@ -0,0 +1,61 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Relaxed configures Unmarshal to match keys loosely: if a key isn't found
+// as is, it matches any variable whose name differs from it only in case,
+// or in using '.', '-', or '_' as separators. For example, the tag key
+// MY_APP_PORT is satisfied by any of MY_APP_PORT, my.app.port, and
+// my-app-port. It's an error if more than one such variable is present.
+//
+// Relaxed matching applies to the process environment, to maps set via
+// Map, and to functions set via Looker that can list their keys, as
+// configured via ListKeys; otherwise keys only match exactly.
+func Relaxed() Option {
+	return func(c *config) {
+		c.relaxed = make(map[string][]string)
+	}
+}
+
+// relaxedKey returns the form of key used for relaxed matching.
+func relaxedKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-':
+			return '_'
+		}
+		return r
+	}, strings.ToUpper(key))
+}
+
+// relaxedLookup looks up key with the configured lookup function, and if
+// it's not found, looks up the listed key that it relaxedly matches.
+func (c *config) relaxedLookup(key string) (*string, error) {
+	v, err := c.looker(key)
+	if err != nil || v != nil || c.keys == nil {
+		return v, err
+	}
+	if len(c.relaxed) == 0 {
+		for _, k := range c.keys() {
+			rk := relaxedKey(k)
+			c.relaxed[rk] = append(c.relaxed[rk], k)
+		}
+	}
+	switch matches := c.relaxed[relaxedKey(key)]; len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return c.looker(matches[0])
+	default:
+		sort.Strings(matches)
+		return nil, errors.New("ambiguous keys: " + strings.Join(matches, ", "))
+	}
+}
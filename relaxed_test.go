@@ -0,0 +1,51 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaxed(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Port int    `env:"MY_APP_PORT"`
+		Host string `env:"my.app.host=localhost"`
+	}
+
+	for _, key := range []string{"MY_APP_PORT", "my.app.port", "my-app-port", "My_App.Port"} {
+		var c config
+		err := Unmarshal(&c, Map(map[string]string{key: "80", "MY-APP-HOST": "h1"}), Relaxed())
+		require.NoError(t, err, key)
+		require.Equal(t, 80, c.Port, key)
+		require.Equal(t, "h1", c.Host, key)
+	}
+
+	// Without the option, keys match exactly.
+	var c config
+	err := Unmarshal(&c, Map(map[string]string{"my.app.port": "80"}))
+	require.NoError(t, err)
+	require.Equal(t, 0, c.Port)
+	require.Equal(t, "localhost", c.Host)
+
+	// An exact match is preferred.
+	c = config{}
+	err = Unmarshal(&c, Map(map[string]string{"MY_APP_PORT": "80", "my.app.port": "81"}), Relaxed())
+	require.NoError(t, err)
+	require.Equal(t, 80, c.Port)
+
+	c = config{}
+	err = Unmarshal(&c, Map(map[string]string{"my-app-port": "80", "my.app.port": "81"}), Relaxed())
+	require.EqualError(t, err, "ambiguous keys: my-app-port, my.app.port: field Port (int) in struct config")
+
+	// Lookers can't list their keys.
+	c = config{}
+	err = Unmarshal(&c, Looker(lookupMap(map[string]string{"my.app.port": "80"})), Relaxed())
+	require.NoError(t, err)
+	require.Equal(t, 0, c.Port)
+}
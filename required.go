@@ -0,0 +1,108 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+)
+
+// MissingRequired returns the keys of the fields reachable from the struct
+// pointer in that have the "required" modifier, but aren't found with the
+// lookup function f and the given options, in field order. Fields disabled
+// by their "if" modifier aren't required, and of the variants of a oneof
+// field, only the fields of the one its key selects are. It doesn't modify
+// in. It's intended for health checks and init containers that check an
+// environment before starting a service.
+func MissingRequired(in interface{}, f LookupEnvFunc, options ...Option) ([]string, error) {
+	if !isStructPtr(in) {
+		return nil, errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(append(append([]Option(nil), options...), Looker(f)))
+
+	var missing []string
+	seen := make(map[string]struct{})
+	if err := config.collectMissing(in, "", seen, &missing); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// collectMissing appends the keys of the required fields reachable from
+// the struct pointer in, at path, that aren't found and not yet seen, to
+// missing.
+func (config *config) collectMissing(in interface{}, path string, seen map[string]struct{}, missing *[]string) error {
+	return visit(in, path, func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if c.info.modErr != nil {
+			return config.fieldError(CodeInvalidTag, c.info.modErr, c)
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		if on, err := config.enabled(c); err != nil {
+			return config.fieldError(CodeLookupFailure, err, c)
+		} else if !on {
+			return errSkipField
+		}
+		if c.info.key == "" {
+			return nil
+		}
+		if _, ok := c.info.mods["required"]; ok {
+			if _, ok := seen[c.info.key]; !ok {
+				val, _, _, err := config.lookupField(c.info)
+				if err != nil {
+					return config.fieldError(CodeLookupFailure, err, c)
+				}
+				if val == nil {
+					seen[c.info.key] = struct{}{}
+					*missing = append(*missing, c.info.key)
+				}
+			}
+		}
+		if _, ok := c.info.mods["oneof"]; ok {
+			return config.missingVariant(c, seen, missing)
+		}
+		return nil
+	})
+}
+
+// missingVariant is like collectMissing, for the variant selected by the
+// key of the union struct field at the cursor, which has the oneof
+// modifier. It returns errSkipField, so that the other variants aren't
+// visited.
+func (config *config) missingVariant(c *cursor, seen map[string]struct{}, missing *[]string) error {
+	variants, err := unionVariants(c.field.Type)
+	if err != nil {
+		return config.fieldError(CodeInvalidTag, err, c)
+	}
+	val, _, _, err := config.resolveField(c, c.info)
+	if err != nil {
+		return config.fieldError(CodeLookupFailure, err, c)
+	}
+	if val == nil {
+		return errSkipField
+	}
+	for _, v := range variants {
+		if v.name != *val {
+			continue
+		}
+		union := reflect.Indirect(c.value)
+		if !union.IsValid() {
+			union = reflect.New(c.field.Type.Elem()).Elem()
+		}
+		variant := union.Field(v.field)
+		if variant.IsNil() {
+			variant = reflect.New(variant.Type().Elem())
+		}
+		path := joinPath(c.path, union.Type().Field(v.field).Name)
+		if err := config.collectMissing(variant.Interface(), path, seen, missing); err != nil {
+			return err
+		}
+	}
+	return errSkipField
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequired(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		URL string `env:"DB_URL" envopt:"required,secret"`
+	}
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Port  int    `env:"PORT=80" envopt:"required"`
+		Token string `env:"TOKEN" envopt:"required"`
+		DB    DB
+		Other *DB
+	}
+
+	env := map[string]string{"TOKEN": "t", "PORT": "8080"}
+	var s S
+	err := Unmarshal(&s, Map(env))
+	require.EqualError(t, err, "missing required key: field URL (string) in struct DB")
+
+	env["DB_URL"] = "postgres://"
+	s = S{}
+	err = Unmarshal(&s, Map(env))
+	require.NoError(t, err)
+	require.Equal(t, 8080, s.Port)
+
+	// The tag default doesn't satisfy a required field.
+	delete(env, "PORT")
+	err = Unmarshal(&S{}, Map(env))
+	require.EqualError(t, err, "missing required key: field Port (int) in struct S")
+
+	var lazy struct {
+		L Lazy[string] `env:"LAZY" envopt:"required"`
+	}
+	err = Unmarshal(&lazy, DefaultsOnly())
+	require.NoError(t, err)
+	_, err = lazy.L.Get()
+	require.EqualError(t, err, "missing required key: field L (struct) in struct ")
+}
+
+func TestMissingRequired(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		URL string `env:"DB_URL" envopt:"required"`
+	}
+	type S struct {
+		Port  int    `env:"PORT=80" envopt:"required"`
+		Token string `env:"TOKEN" envopt:"required"`
+		Host  string `env:"HOST"`
+		DB    DB
+		DB2   DB
+		Other *DB
+	}
+
+	s := S{Port: 1}
+	missing, err := MissingRequired(&s, lookupMap(map[string]string{"TOKEN": "t"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"PORT", "DB_URL"}, missing)
+	require.Equal(t, S{Port: 1}, s)
+
+	missing, err = MissingRequired(&s, lookupMap(map[string]string{"TOKEN": "t", "PORT": "x", "DB_URL": "u"}))
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	_, err = MissingRequired(s, nil)
+	require.EqualError(t, err, "passed non-pointer or nil pointer")
+}
+
+func TestMissingRequiredOneof(t *testing.T) {
+	t.Parallel()
+
+	type S3 struct {
+		Bucket string `env:"S3_BUCKET" envopt:"required"`
+	}
+	type Disk struct {
+		Path string `env:"DISK_PATH" envopt:"required"`
+	}
+	type Storage struct {
+		S3   *S3
+		Disk *Disk
+	}
+	type S struct {
+		Storage Storage `env:"STORAGE_DRIVER=disk" envopt:"oneof"`
+	}
+
+	env := lookupMap(map[string]string{"STORAGE_DRIVER": "s3"})
+	missing, err := MissingRequired(&S{}, env)
+	require.NoError(t, err)
+	require.Equal(t, []string{"S3_BUCKET"}, missing)
+	require.Error(t, Unmarshal(&S{}, Looker(env)))
+
+	s := S{Storage{S3: &S3{}, Disk: &Disk{}}}
+	missing, err = MissingRequired(&s, env)
+	require.NoError(t, err)
+	require.Equal(t, []string{"S3_BUCKET"}, missing)
+	require.Equal(t, S{Storage{S3: &S3{}, Disk: &Disk{}}}, s)
+
+	missing, err = MissingRequired(&S{}, lookupMap(nil))
+	require.NoError(t, err)
+	require.Equal(t, []string{"DISK_PATH"}, missing)
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+// A Source identifies where the value of a tagged field came from.
+type Source int
+
+const (
+	// SourceNone means the field wasn't set.
+	SourceNone Source = iota
+	// SourceEnv means the field was set from the environment.
+	SourceEnv
+	// SourceDefault means the field was set from its tag default.
+	SourceDefault
+	// SourceExisting means the field kept the value it had before
+	// Unmarshal; see KeepExisting.
+	SourceExisting
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceNone:
+		return "none"
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	case SourceExisting:
+		return "existing"
+	}
+	return "unknown"
+}
+
+// A Result records how Unmarshal resolved each tagged field.
+type Result struct {
+	// Fields are in the order Unmarshal visits them: depth first, in
+	// declaration order.
+	Fields []FieldResult
+	// Warnings holds the problems reported as by Warnings.
+	Warnings []Warning
+}
+
+// A FieldResult records how Unmarshal resolved a tagged field.
+type FieldResult struct {
+	// Path is the dot separated list of field names leading to the field,
+	// as used by UnmarshalPath.
+	Path string
+	// Key is the environment key from the field's tag.
+	Key string
+	// Source is where the field's value came from.
+	Source Source
+	// Layer is the name of the layer that supplied the field's value, if
+	// its Source is SourceEnv; see Layers.
+	Layer string
+	// Value is the string the field was set from, or empty if its Source
+	// is SourceNone. The values of secret fields are redacted; see
+	// Redaction.
+	Value string
+	// Secret is true if the field has the secret modifier.
+	Secret bool
+}
+
+// Field returns the FieldResult for the field at path, or nil if r has no
+// record of the field.
+func (r *Result) Field(path string) *FieldResult {
+	for i := range r.Fields {
+		if r.Fields[i].Path == path {
+			return &r.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Record configures Unmarshal to append a FieldResult to r for each tagged
+// field it visits.
+func Record(r *Result) Option {
+	return func(c *config) {
+		c.result = r
+	}
+}
+
+// record appends the resolution of the field at the cursor to the
+// configured Result, if any.
+func (c *config) record(cur *cursor, key string, source Source, layer string, value string) {
+	if c.result == nil {
+		return
+	}
+	if cur.info.secret && source != SourceNone {
+		value = c.redact(key, value)
+	}
+	c.result.Fields = append(c.result.Fields, FieldResult{
+		Path:   cur.path,
+		Key:    key,
+		Source: source,
+		Layer:  layer,
+		Value:  value,
+		Secret: cur.info.secret,
+	})
+}
+
+// recorded returns the number of fields in the configured Result.
+func (c *config) recorded() int {
+	if c.result == nil {
+		return 0
+	}
+	return len(c.result.Fields)
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Token string `env:"TOKEN" envopt:"secret"`
+	}
+	type Unset struct {
+		Str1 string `env:"k1"`
+	}
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Port  int    `env:"PORT"`
+		Name  string `env:"NAME"`
+		Inner Inner
+		Unset *Unset
+	}
+
+	env := map[string]string{
+		"PORT":  "80",
+		"TOKEN": "t1",
+	}
+	var s S
+	var r Result
+	err := Unmarshal(&s, Map(env), Record(&r), AllocateNested())
+	require.NoError(t, err)
+	require.Equal(t, []FieldResult{
+		{Path: "Host", Key: "HOST", Source: SourceDefault, Value: "localhost"},
+		{Path: "Port", Key: "PORT", Source: SourceEnv, Layer: "env", Value: "80"},
+		{Path: "Name", Key: "NAME", Source: SourceNone},
+		{Path: "Inner.Token", Key: "TOKEN", Source: SourceEnv, Layer: "env", Value: "[REDACTED]", Secret: true},
+	}, r.Fields)
+
+	require.Equal(t, SourceEnv, r.Field("Port").Source)
+	require.Nil(t, r.Field("Unset.Str1"))
+	require.Equal(t, "default", SourceDefault.String())
+}
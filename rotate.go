@@ -0,0 +1,115 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"time"
+)
+
+// An ExpiryFunc returns when the current value of key expires, such as
+// the end of a Vault lease, or the expiry of a credential read from a
+// file, or the zero Time if the value doesn't expire.
+type ExpiryFunc func(key string) time.Time
+
+// expiryRetry is the least time WatchExpiry waits between refreshes of a
+// key, so that a value whose expiry doesn't move far enough past the
+// refresh isn't refreshed continually.
+const expiryRetry = time.Second
+
+// WatchExpiry calls Refresh with the keys of the current struct whose
+// values expire, as reported by expiry, early before they expire, until
+// Stop is called. Only the fields tagged with those keys are looked up
+// again, so a rotated credential is picked up without looking up every
+// key. Expiry times are checked again after every change to the current
+// struct, including those made by Poll.
+func (w *Watcher) WatchExpiry(expiry ExpiryFunc, early time.Duration) {
+	if expiry == nil {
+		panic(errors.New("nil expiry function"))
+	}
+
+	changed := make(chan struct{}, 1)
+	w.OnChange(func([]FieldChange) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.spawn(func(stop <-chan struct{}) {
+		refreshed := make(map[string]time.Time)
+		for {
+			due := w.expiring(expiry, early, refreshed)
+
+			var next time.Time
+			for _, at := range due {
+				if next.IsZero() || at.Before(next) {
+					next = at
+				}
+			}
+			// With nothing due, wait on a nil channel, which never
+			// receives.
+			var timer *time.Timer
+			var fire <-chan time.Time
+			if !next.IsZero() {
+				timer = time.NewTimer(time.Until(next))
+				fire = timer.C
+			}
+
+			select {
+			case <-fire:
+				now := time.Now()
+				var keys []string
+				for key, at := range due {
+					if !at.After(now) {
+						keys = append(keys, key)
+						refreshed[key] = now
+					}
+				}
+				_ = w.Refresh(keys...)
+			case <-changed:
+			case <-stop:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	})
+}
+
+// expiring returns when each key of the current struct whose value
+// expires is due to be refreshed, given when keys were last refreshed.
+// Keys aren't due until expiryRetry after their last refresh.
+func (w *Watcher) expiring(expiry ExpiryFunc, early time.Duration, refreshed map[string]time.Time) map[string]time.Time {
+	w.mu.Lock()
+	keys := make([]string, 0, len(w.result.Fields))
+	for _, f := range w.result.Fields {
+		keys = append(keys, f.Key)
+	}
+	w.mu.Unlock()
+
+	due := make(map[string]time.Time)
+	for _, key := range keys {
+		if _, ok := due[key]; ok {
+			continue
+		}
+		exp := expiry(key)
+		if exp.IsZero() {
+			continue
+		}
+		at := exp.Add(-early)
+		if last, ok := refreshed[key]; ok && at.Before(last.Add(expiryRetry)) {
+			at = last.Add(expiryRetry)
+		}
+		due[key] = at
+	}
+	return due
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchExpiry(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password string `env:"DB_PASSWORD" envopt:"secret"`
+		Host     string `env:"DB_HOST"`
+	}
+
+	env := &testEnv{}
+	env.set("DB_PASSWORD", "v1")
+	env.set("DB_HOST", "db1")
+
+	var mu sync.Mutex
+	leases := map[string]time.Time{"DB_PASSWORD": time.Now().Add(150 * time.Millisecond)}
+	expiry := func(key string) time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return leases[key]
+	}
+
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	changes := make(chan []FieldChange, 1)
+	w.OnChange(func(c []FieldChange) { changes <- c })
+
+	// The rotated password is read before its lease expires, but the host
+	// isn't looked up again.
+	env.set("DB_PASSWORD", "v2")
+	env.set("DB_HOST", "db2")
+	w.WatchExpiry(expiry, 100*time.Millisecond)
+	defer w.Stop()
+
+	select {
+	case c := <-changes:
+		require.Len(t, c, 1)
+		require.Equal(t, "DB_PASSWORD", c[0].Key)
+		require.Equal(t, redacted, c[0].New)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no refresh before expiry")
+	}
+	cur := w.Current().(*S)
+	require.Equal(t, "v2", cur.Password)
+	require.Equal(t, "db1", cur.Host)
+
+	require.Panics(t, func() { w.WatchExpiry(nil, 0) })
+}
+
+func TestWatchExpiryShortLease(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Token string `env:"TOKEN"`
+	}
+
+	var mu sync.Mutex
+	lookups := 0
+	looker := Looker(func(key string) (*string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		lookups++
+		v := "t"
+		return &v, nil
+	})
+
+	var s S
+	w, err := NewWatcher(&s, looker)
+	require.NoError(t, err)
+
+	// Each lease is renewed for less than early, so the key is always due;
+	// it's still only refreshed once per expiryRetry.
+	w.WatchExpiry(func(string) time.Time { return time.Now().Add(10 * time.Millisecond) }, time.Minute)
+	time.Sleep(expiryRetry / 2)
+	w.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, lookups)
+}
+
+func TestWatchExpiryNoneDue(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host string `env:"DB_HOST"`
+	}
+
+	env := &testEnv{}
+	env.set("DB_HOST", "db0")
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	// With no values expiring, only the polls made here are counted; no
+	// refresh is made after each change.
+	w.WatchExpiry(func(string) time.Time { return time.Time{} }, time.Minute)
+	defer w.Stop()
+	for i := 1; i <= 50; i++ {
+		env.set("DB_HOST", "db"+strconv.Itoa(i))
+		require.NoError(t, w.Poll())
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, int64(50), w.Stats().Polls)
+}
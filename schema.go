@@ -0,0 +1,338 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Schema describes the environment variables that a configuration
+// struct reads: its contract with the environment it's deployed in.
+type Schema struct {
+	Vars []SchemaVar `json:"vars"`
+}
+
+// A VarKind is the general kind of value an environment variable holds,
+// for tools that generate typed definitions from a Schema.
+type VarKind string
+
+const (
+	// KindString is text, including values parsed by a Set method or a
+	// SetFunc, such as durations and URLs.
+	KindString VarKind = "string"
+	// KindBool is a boolean.
+	KindBool VarKind = "bool"
+	// KindNumber is an integer or floating point number.
+	KindNumber VarKind = "number"
+	// KindList is a list, such as a slice with the shellwords modifier.
+	KindList VarKind = "list"
+	// KindMap is a map.
+	KindMap VarKind = "map"
+)
+
+// A SchemaVar describes an environment variable read by a tagged field.
+type SchemaVar struct {
+	// Key is the variable's name. If Prefix is true, it's instead the
+	// prefix of the names of the variables collected by an envmap field,
+	// including its separator, such as "LABEL_".
+	Key string `json:"key"`
+	// Path is the dot separated list of field names leading to the field.
+	Path string `json:"path"`
+	// Type is the Go type of the field, such as "time.Duration".
+	Type string `json:"type"`
+	// Kind is the general kind of the variable's value.
+	Kind VarKind `json:"kind"`
+	// Default is the field's default, from the Defaults option or its tag,
+	// if it has one. It's left unset for secret fields.
+	Default *string `json:"default,omitempty"`
+	// Required is true if the field has the required modifier.
+	Required bool `json:"required,omitempty"`
+	// Secret is true if the field is secret.
+	Secret bool `json:"secret,omitempty"`
+	// Deprecated is true if the field has the deprecated modifier.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// If is the key named by the field's if modifier, if it has one.
+	If string `json:"if,omitempty"`
+	// Prefix is true if the field has the envmap modifier.
+	Prefix bool `json:"prefix,omitempty"`
+	// Values are the allowed values, such as the variants of a field with
+	// the oneof modifier.
+	Values []string `json:"values,omitempty"`
+	// Description is the field's envdoc tag.
+	Description string `json:"description,omitempty"`
+}
+
+// Var returns the SchemaVar for key, or nil if s has none.
+func (s *Schema) Var(key string) *SchemaVar {
+	for i := range s.Vars {
+		if s.Vars[i].Key == key {
+			return &s.Vars[i]
+		}
+	}
+	return nil
+}
+
+// fixedDefault returns v's default, unless it has "${KEY}" references.
+// Unmarshal only expands those when v's key isn't found, so generators
+// that would set the key to its default treat v as having none.
+func (v SchemaVar) fixedDefault() *string {
+	if v.Default == nil || strings.Contains(*v.Default, "${") {
+		return nil
+	}
+	return v.Default
+}
+
+// SchemaOf returns the Schema of the struct type pointed to by in, with
+// keys rewritten as configured by options, in the order Unmarshal visits
+// their fields. It describes every field reachable from the type, as if
+// every struct pointer were allocated, so it doesn't depend on in's
+// values; the fields of every variant of a oneof field are included. A
+// key tagged on several fields is described once, by its first field.
+func SchemaOf(in interface{}, options ...Option) (*Schema, error) {
+	if !isStructPtr(in) {
+		return nil, errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(options)
+
+	full := reflect.New(reflect.TypeOf(in).Elem())
+	allocateAll(full.Elem(), make(map[reflect.Type]bool))
+
+	s := &Schema{Vars: []SchemaVar{}}
+	seen := make(map[string]struct{})
+	err := visit(full.Interface(), "", func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if c.info.modErr != nil {
+			return config.fieldError(CodeInvalidTag, c.info.modErr, c)
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		key := c.info.key
+		if key == "" {
+			return nil
+		}
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		s.Vars = append(s.Vars, config.schemaVar(c))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// schemaVar returns the SchemaVar of the field at the cursor.
+func (config *config) schemaVar(c *cursor) SchemaVar {
+	t := c.field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v := SchemaVar{
+		Key:         c.info.key,
+		Path:        c.path,
+		Type:        t.String(),
+		Kind:        config.varKind(c.info, t),
+		Secret:      c.info.secret,
+		Description: c.field.Tag.Get(docTagName),
+	}
+	if c.info.defval != nil {
+		def := *c.info.defval
+		v.Default = &def
+	}
+	if def, ok := config.defaults[v.Key]; ok {
+		v.Default = &def
+	}
+	if v.Secret {
+		v.Default = nil
+	}
+	_, v.Required = c.info.mods["required"]
+	_, v.Deprecated = c.info.mods["deprecated"]
+	v.If = c.info.mods["if"]
+	if sep, ok := envMapSep(c.info); ok {
+		v.Key, v.Prefix, v.Default = v.Key+sep, true, nil
+	}
+	if _, ok := c.info.mods["oneof"]; ok {
+		if variants, err := unionVariants(t); err == nil {
+			for _, variant := range variants {
+				v.Values = append(v.Values, variant.name)
+			}
+		}
+	}
+	if impls, ok := config.impls[t]; ok {
+		for name := range impls {
+			v.Values = append(v.Values, name)
+		}
+		sort.Strings(v.Values)
+	}
+	return v
+}
+
+// varKind returns the kind of value of a field of type t, which isn't a
+// pointer.
+func (config *config) varKind(info *fieldInfo, t reflect.Type) VarKind {
+	if _, ok := info.mods["shellwords"]; ok {
+		return KindList
+	}
+	if _, ok := config.setFuncs[t]; ok {
+		return KindString
+	}
+	if _, ok := registeredSetter(t); ok {
+		return KindString
+	}
+	if k := typeSetterKinds(t); k.setter || k.contextSetter {
+		return KindString
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return KindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return KindNumber
+	case reflect.Map:
+		return KindMap
+	}
+	return KindString
+}
+
+// allocateAll sets each nil struct pointer field reachable from the struct
+// value v to a new struct, except for struct types already being
+// allocated, which would recurse without end.
+func allocateAll(v reflect.Value, allocating map[reflect.Type]bool) {
+	allocating[v.Type()] = true
+	defer delete(allocating, v.Type())
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch {
+		case f.Kind() == reflect.Struct:
+			allocateAll(f, allocating)
+		case f.Kind() == reflect.Ptr && f.Type().Elem().Kind() == reflect.Struct && !allocating[f.Type().Elem()]:
+			f.Set(reflect.New(f.Type().Elem()))
+			allocateAll(f.Elem(), allocating)
+		}
+	}
+}
+
+// ReadSchema reads a Schema in the JSON format written by PrintSchema.
+func ReadSchema(r io.Reader) (*Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return &s, nil
+}
+
+// WriteJSON writes s as indented JSON to w.
+func (s *Schema) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// schemaRegistry holds the structs registered by RegisterSchema.
+var schemaRegistry struct {
+	sync.Mutex
+	entries []registration
+}
+
+// A registration is a configuration struct registered by RegisterSchema.
+type registration struct {
+	in      interface{}
+	options []Option
+}
+
+// RegisterSchema registers the configuration struct type pointed to by in,
+// with the options the program decodes it with, so that RegisteredSchema
+// and PrintSchema can describe the program's environment variables at
+// runtime, such as for deploy tooling querying a binary for its contract.
+// A program with several configuration structs may register each of them.
+func RegisterSchema(in interface{}, options ...Option) {
+	schemaRegistry.Lock()
+	defer schemaRegistry.Unlock()
+	schemaRegistry.entries = append(schemaRegistry.entries, registration{in, options})
+}
+
+// RegisteredSchema returns the Schema of the structs registered by
+// RegisterSchema, in the order they were registered. A key read by
+// several structs is described once, by the first.
+func RegisteredSchema() (*Schema, error) {
+	schemaRegistry.Lock()
+	entries := append([]registration(nil), schemaRegistry.entries...)
+	schemaRegistry.Unlock()
+
+	s := &Schema{Vars: []SchemaVar{}}
+	for _, e := range entries {
+		one, err := SchemaOf(e.in, e.options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range one.Vars {
+			if s.Var(v.Key) == nil {
+				s.Vars = append(s.Vars, v)
+			}
+		}
+	}
+	return s, nil
+}
+
+// PrintSchema writes the RegisteredSchema to w as indented JSON.
+func PrintSchema(w io.Writer) error {
+	s, err := RegisteredSchema()
+	if err != nil {
+		return err
+	}
+	return s.WriteJSON(w)
+}
+
+// SchemaFlag is the command-line flag that HandleSchemaFlag looks for.
+const SchemaFlag = "print-env-schema"
+
+// HandleSchemaFlag checks the program's arguments for SchemaFlag, given
+// as "--print-env-schema" or "-print-env-schema", before any flags are
+// parsed. If it's present, HandleSchemaFlag writes the RegisteredSchema to
+// standard output, and exits the program, with status 0 on success, and 1
+// if the schema can't be written. It's intended to be called early in
+// main, after the program's configuration structs are registered.
+func HandleSchemaFlag() {
+	handled, err := handleSchemaFlag(os.Args[1:], os.Stdout)
+	if !handled {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// handleSchemaFlag writes the RegisteredSchema to w if args, which stop at
+// "--", hold SchemaFlag, and returns whether they do.
+func handleSchemaFlag(args []string, w io.Writer) (bool, error) {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+SchemaFlag || arg == "-"+SchemaFlag {
+			return true, PrintSchema(w)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type schemaRedis struct {
+	Addr string        `env:"REDIS_ADDR=localhost:6379" envdoc:"Redis address"`
+	TTL  time.Duration `env:"REDIS_TTL=1m"`
+}
+
+type schemaS3 struct {
+	Bucket string `env:"S3_BUCKET" envopt:"required"`
+}
+
+type schemaStorage struct {
+	S3   *schemaS3
+	Disk *struct {
+		Dir string `env:"DISK_DIR"`
+	}
+}
+
+type schemaConfig struct {
+	Name     string            `env:"NAME" envopt:"required"`
+	Token    string            `env:"TOKEN=hunter2" envopt:"secret"`
+	Port     int               `env:"PORT=8080"`
+	Debug    bool              `env:"DEBUG" envopt:"if=DEV"`
+	Args     []string          `env:"ARGS" envopt:"shellwords"`
+	Labels   map[string]string `env:"LABEL" envopt:"envmap"`
+	Old      string            `env:"OLD_NAME" envopt:"deprecated=NAME"`
+	Redis    *schemaRedis
+	Storage  schemaStorage `env:"STORAGE=disk" envopt:"oneof"`
+	Again    string        `env:"PORT=9090"`
+	internal string
+}
+
+func TestSchemaOf(t *testing.T) {
+	t.Parallel()
+
+	def := func(s string) *string { return &s }
+	s, err := SchemaOf(&schemaConfig{}, CommonTypes(), Defaults(map[string]string{"PORT": "80"}))
+	require.NoError(t, err)
+	require.Equal(t, []SchemaVar{
+		{Key: "NAME", Path: "Name", Type: "string", Kind: KindString, Required: true},
+		{Key: "TOKEN", Path: "Token", Type: "string", Kind: KindString, Secret: true},
+		{Key: "PORT", Path: "Port", Type: "int", Kind: KindNumber, Default: def("80")},
+		{Key: "DEBUG", Path: "Debug", Type: "bool", Kind: KindBool, If: "DEV"},
+		{Key: "ARGS", Path: "Args", Type: "[]string", Kind: KindList},
+		{Key: "LABEL_", Path: "Labels", Type: "map[string]string", Kind: KindMap, Prefix: true},
+		{Key: "OLD_NAME", Path: "Old", Type: "string", Kind: KindString, Deprecated: true},
+		{Key: "REDIS_ADDR", Path: "Redis.Addr", Type: "string", Kind: KindString, Default: def("localhost:6379"), Description: "Redis address"},
+		{Key: "REDIS_TTL", Path: "Redis.TTL", Type: "time.Duration", Kind: KindString, Default: def("1m")},
+		{Key: "STORAGE", Path: "Storage", Type: "fromenv.schemaStorage", Kind: KindString, Default: def("disk"), Values: []string{"s3", "disk"}},
+		{Key: "S3_BUCKET", Path: "Storage.S3.Bucket", Type: "string", Kind: KindString, Required: true},
+		{Key: "DISK_DIR", Path: "Storage.Disk.Dir", Type: "string", Kind: KindString},
+	}, s.Vars)
+	require.Equal(t, "Redis.TTL", s.Var("REDIS_TTL").Path)
+	require.Nil(t, s.Var("NOPE"))
+
+	var buf bytes.Buffer
+	require.NoError(t, s.WriteJSON(&buf))
+	read, err := ReadSchema(&buf)
+	require.NoError(t, err)
+	require.Equal(t, s, read)
+
+	_, err = ReadSchema(bytes.NewBufferString("{"))
+	require.Error(t, err)
+	_, err = SchemaOf(schemaConfig{})
+	require.Error(t, err)
+}
+
+func TestRegisteredSchema(t *testing.T) {
+	type Extra struct {
+		Name  string `env:"NAME=other"`
+		Level string `env:"LEVEL=info"`
+	}
+	RegisterSchema(&schemaConfig{}, CommonTypes())
+	RegisterSchema(&Extra{}, KeyFunc(func(key string, _ FieldInfo) string {
+		if key == "LEVEL" {
+			return "LOG_" + key
+		}
+		return key
+	}))
+
+	s, err := RegisteredSchema()
+	require.NoError(t, err)
+	require.Len(t, s.Vars, 13)
+	require.True(t, s.Var("NAME").Required)
+	require.Equal(t, "info", *s.Var("LOG_LEVEL").Default)
+
+	var buf bytes.Buffer
+	handled, err := handleSchemaFlag([]string{"-v", "--print-env-schema"}, &buf)
+	require.True(t, handled)
+	require.NoError(t, err)
+	printed, err := ReadSchema(&buf)
+	require.NoError(t, err)
+	require.Equal(t, s, printed)
+
+	for _, args := range [][]string{nil, {"-v"}, {"--", "--print-env-schema"}, {"---print-env-schema"}} {
+		handled, err = handleSchemaFlag(args, &buf)
+		require.False(t, handled)
+		require.NoError(t, err)
+	}
+	handled, _ = handleSchemaFlag([]string{"-print-env-schema"}, &buf)
+	require.True(t, handled)
+}
+
+func TestSchemaTemplateDefaults(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Addr string `env:"REDIS_ADDR=${REDIS_HOST}:6379"`
+		Port int    `env:"PORT=8080"`
+	}
+	s, err := SchemaOf(&Config{})
+	require.NoError(t, err)
+	require.Equal(t, "${REDIS_HOST}:6379", *s.Var("REDIS_ADDR").Default)
+
+	// Generators leave template defaults for Unmarshal to expand.
+	require.Equal(t, "export PORT=8080\n", string(s.ShellDefaults()))
+
+	k8s, err := s.KubernetesEnv("secrets", "app")
+	require.NoError(t, err)
+	require.Contains(t, string(k8s), "  - name: REDIS_ADDR\n    valueFrom:\n      configMapKeyRef:\n        name: app\n        key: REDIS_ADDR\n        optional: true\n")
+
+	values, err := s.HelmValues()
+	require.NoError(t, err)
+	require.Contains(t, string(values), `REDIS_ADDR: ""`)
+	require.Contains(t, string(s.HelmTemplate("app.env")), `{{- with (index .Values.env "REDIS_ADDR") }}`)
+
+	require.Contains(t, string(s.TerraformVariables()), "variable \"redis_addr\" {\n  type        = string\n  default     = null\n}\n")
+
+	for _, out := range [][]byte{s.ShellDefaults(), k8s, values, s.HelmTemplate("app.env"), s.TerraformVariables()} {
+		require.NotContains(t, string(out), "REDIS_HOST")
+	}
+}
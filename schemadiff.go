@@ -0,0 +1,90 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A SchemaDiff describes the changes to a program's environment contract
+// between two Schemas, such as those printed by two releases of a binary
+// given SchemaFlag.
+type SchemaDiff struct {
+	// Added are the variables only in the new schema.
+	Added []SchemaVar `json:"added,omitempty"`
+	// Removed are the variables only in the old schema.
+	Removed []SchemaVar `json:"removed,omitempty"`
+	// Retyped are the variables whose type, kind, or whether they're a
+	// prefix, differ between the schemas.
+	Retyped []VarChange `json:"retyped,omitempty"`
+	// NewlyRequired are the variables in both schemas that are required
+	// only in the new schema.
+	NewlyRequired []SchemaVar `json:"newlyRequired,omitempty"`
+}
+
+// A VarChange describes a variable in both of two Schemas.
+type VarChange struct {
+	Old SchemaVar `json:"old"`
+	New SchemaVar `json:"new"`
+}
+
+// DiffSchemas returns the changes from the old schema to the new one.
+// Variables are matched by key, and listed in the order of the schema
+// they're from, preferring the new one.
+func DiffSchemas(old, new *Schema) *SchemaDiff {
+	d := &SchemaDiff{}
+	for _, v := range new.Vars {
+		prev := old.Var(v.Key)
+		switch {
+		case prev == nil:
+			d.Added = append(d.Added, v)
+			continue
+		case prev.Type != v.Type || prev.Kind != v.Kind || prev.Prefix != v.Prefix:
+			d.Retyped = append(d.Retyped, VarChange{Old: *prev, New: v})
+		}
+		if v.Required && !prev.Required {
+			d.NewlyRequired = append(d.NewlyRequired, v)
+		}
+	}
+	for _, v := range old.Vars {
+		if new.Var(v.Key) == nil {
+			d.Removed = append(d.Removed, v)
+		}
+	}
+	return d
+}
+
+// Empty returns whether d has no changes.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Retyped) == 0 && len(d.NewlyRequired) == 0
+}
+
+// String returns d as lines suitable for release notes, such as:
+//
+//	added CACHE_SIZE (int, required)
+//	removed LEGACY_MODE (bool)
+//	retyped TIMEOUT from int to time.Duration
+//	newly required REGION
+func (d *SchemaDiff) String() string {
+	var b strings.Builder
+	for _, v := range d.Added {
+		if v.Required {
+			fmt.Fprintf(&b, "added %s (%s, required)\n", v.Key, v.Type)
+		} else {
+			fmt.Fprintf(&b, "added %s (%s)\n", v.Key, v.Type)
+		}
+	}
+	for _, v := range d.Removed {
+		fmt.Fprintf(&b, "removed %s (%s)\n", v.Key, v.Type)
+	}
+	for _, c := range d.Retyped {
+		fmt.Fprintf(&b, "retyped %s from %s to %s\n", c.New.Key, c.Old.Type, c.New.Type)
+	}
+	for _, v := range d.NewlyRequired {
+		fmt.Fprintf(&b, "newly required %s\n", v.Key)
+	}
+	return b.String()
+}
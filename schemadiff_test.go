@@ -0,0 +1,52 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	t.Parallel()
+
+	type V1 struct {
+		Region  string            `env:"REGION"`
+		Timeout int               `env:"TIMEOUT=5"`
+		Legacy  bool              `env:"LEGACY_MODE"`
+		Labels  map[string]string `env:"LABEL"`
+		Name    string            `env:"NAME" envopt:"required"`
+	}
+	type V2 struct {
+		Name    string            `env:"NAME" envopt:"required"`
+		Region  string            `env:"REGION" envopt:"required"`
+		Timeout time.Duration     `env:"TIMEOUT=5s" envopt:"required"`
+		Size    int               `env:"CACHE_SIZE" envopt:"required"`
+		Debug   bool              `env:"DEBUG"`
+		Labels  map[string]string `env:"LABEL" envopt:"envmap"`
+	}
+	old, err := SchemaOf(&V1{})
+	require.NoError(t, err)
+	new, err := SchemaOf(&V2{})
+	require.NoError(t, err)
+
+	d := DiffSchemas(old, new)
+	require.False(t, d.Empty())
+	require.Equal(t, `added CACHE_SIZE (int, required)
+added DEBUG (bool)
+added LABEL_ (map[string]string)
+removed LEGACY_MODE (bool)
+removed LABEL (map[string]string)
+retyped TIMEOUT from int to time.Duration
+newly required REGION
+newly required TIMEOUT
+`, d.String())
+	require.Equal(t, "int", d.Retyped[0].Old.Type)
+
+	require.True(t, DiffSchemas(new, new).Empty())
+	require.Equal(t, "", DiffSchemas(new, new).String())
+}
@@ -0,0 +1,47 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShellDefaults returns a shell script, such as a defaults.sh to source
+// when bootstrapping a developer's shell or a CI job, that exports each
+// variable described by s that has a default, set to its default. Each
+// export is preceded by the variable's description, if it has one, as a
+// comment. Secret and deprecated variables are omitted, as are those
+// whose keys aren't valid shell names, and those whose defaults have
+// "${KEY}" references, which are only expanded if the key isn't set.
+func (s *Schema) ShellDefaults() []byte {
+	var b strings.Builder
+	for _, v := range s.Vars {
+		if v.fixedDefault() == nil || v.Secret || v.Deprecated || !isShellName(v.Key) {
+			continue
+		}
+		if v.Description != "" {
+			for _, line := range strings.Split(v.Description, "\n") {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", v.Key, quoteShellWord(*v.Default))
+	}
+	return []byte(b.String())
+}
+
+// isShellName returns whether key is a valid shell variable name: letters,
+// digits, and underscores, not starting with a digit.
+func isShellName(key string) bool {
+	for i, c := range key {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return key != ""
+}
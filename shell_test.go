@@ -0,0 +1,47 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type shellConfig struct {
+	Host    string   `env:"HOST=localhost" envdoc:"Listen host"`
+	Port    int      `env:"PORT=8080"`
+	Greet   string   `env:"GREETING=it's a $HOME"`
+	Empty   string   `env:"EMPTY="`
+	Args    []string `env:"ARGS=-v 'a b'" envopt:"shellwords"`
+	Token   string   `env:"TOKEN=dev" envopt:"secret"`
+	Old     string   `env:"OLD_HOST=x" envopt:"deprecated=HOST"`
+	Dotted  string   `env:"APP.MODE=dev"`
+	NoValue string   `env:"NO_VALUE"`
+}
+
+func TestShellDefaults(t *testing.T) {
+	t.Parallel()
+
+	s, err := SchemaOf(&shellConfig{})
+	require.NoError(t, err)
+	script := string(s.ShellDefaults())
+	require.Equal(t, `# Listen host
+export HOST=localhost
+export PORT=8080
+export GREETING='it'\''s a $HOME'
+export EMPTY=''
+export ARGS='-v '\''a b'\'''
+`, script)
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	out, err := exec.Command(sh, "-c", script+`printf '%s|' "$HOST" "$PORT" "$GREETING" "$EMPTY" "$ARGS"`).Output()
+	require.NoError(t, err)
+	require.Equal(t, "localhost|8080|it's a $HOME||-v 'a b'|", string(out))
+}
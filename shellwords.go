@@ -0,0 +1,164 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// splitShellWords splits s into words as a POSIX shell would, without
+// expanding variables or globs: words are separated by unquoted
+// whitespace, single quotes preserve every character up to the closing
+// quote, double quotes preserve every character but backslash escapes of
+// `"`, `\`, `$`, and "`", and an unquoted backslash escapes the next
+// character.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '\\':
+			i++
+			if i == len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+			}
+			inWord = true
+		case ch == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// joinShellWords returns words joined by spaces, each quoted if needed so
+// that splitShellWords returns them unchanged.
+func joinShellWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quoteShellWord(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteShellWord returns w unchanged if it holds only characters that a
+// shell doesn't treat specially, and otherwise in single quotes.
+func quoteShellWord(w string) string {
+	if w == "" {
+		return "''"
+	}
+	if strings.Trim(w, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return w
+	}
+	return "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+}
+
+// isSliceType returns whether t is a slice type, or a pointer to one.
+func isSliceType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice
+}
+
+// setShellWords sets the slice value to the words of str, each set as
+// setValue would set a field of the slice's element type.
+func setShellWords(cfg *config, value reflect.Value, key, str string) error {
+	words, err := splitShellWords(str)
+	if err != nil {
+		return err
+	}
+	return setSlice(cfg, value, key, words)
+}
+
+// setSlice sets value, a slice or a pointer to one, to a new slice holding
+// elems, each set as setValue would set a field of the element type.
+func setSlice(cfg *config, value reflect.Value, key string, elems []string) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice {
+		return errors.New("modifier requires a slice field")
+	}
+	s := reflect.MakeSlice(value.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := setValue(cfg, s.Index(i), key, elem, nil); err != nil {
+			return err
+		}
+	}
+	value.Set(s)
+	return nil
+}
+
+// formatShellWords formats the slice value as the words of a shell
+// command line, each element formatted by formatValue.
+func formatShellWords(value reflect.Value) (string, error) {
+	words, err := formatSlice(value)
+	if err != nil {
+		return "", err
+	}
+	return joinShellWords(words), nil
+}
+
+// formatSlice returns the elements of value, a slice or a pointer to one,
+// each formatted by formatValue.
+func formatSlice(value reflect.Value) ([]string, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice {
+		return nil, errors.New("modifier requires a slice field")
+	}
+	elems := make([]string, value.Len())
+	for i := range elems {
+		s, err := formatValue(value.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = s
+	}
+	return elems, nil
+}
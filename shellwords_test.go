@@ -0,0 +1,78 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string][]string{
+		``:                         nil,
+		`  `:                       nil,
+		`-v --name "my app"`:       {"-v", "--name", "my app"},
+		`a'b c'd`:                  {"ab cd"},
+		`'' ""`:                    {"", ""},
+		`"a \"q\" \$x \n"`:         {`a "q" $x \n`},
+		`'it'\''s'`:                {"it's"},
+		`a\ b \\ c`:                {"a b", `\`, "c"},
+		"one\ttwo\nthree":          {"one", "two", "three"},
+		`--opt='x y' --flag=$HOME`: {"--opt=x y", "--flag=$HOME"},
+	} {
+		words, err := splitShellWords(in)
+		require.NoError(t, err, in)
+		require.Equal(t, out, words, in)
+	}
+
+	for in, msg := range map[string]string{
+		`'abc`: "unterminated single quote",
+		`"abc`: "unterminated double quote",
+		`ab\`:  "trailing backslash",
+	} {
+		_, err := splitShellWords(in)
+		require.EqualError(t, err, msg, in)
+	}
+
+	words := []string{"-v", "", "my app", "it's", "a=b,c", `$HOME`}
+	joined := joinShellWords(words)
+	require.Equal(t, `-v '' 'my app' 'it'\''s' a=b,c '$HOME'`, joined)
+	split, err := splitShellWords(joined)
+	require.NoError(t, err)
+	require.Equal(t, words, split)
+}
+
+func TestShellWordsModifier(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Args  []string `env:"ARGS" envopt:"shellwords"`
+		Ports *[]int   `env:"PORTS=80 443" envopt:"shellwords"`
+	}
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"ARGS": `-v --name "my app"`}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"-v", "--name", "my app"}, s.Args)
+	require.Equal(t, []int{80, 443}, *s.Ports)
+
+	env, err := Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ARGS": `-v --name 'my app'`, "PORTS": "80 443"}, env)
+
+	err = Unmarshal(&s, Map(map[string]string{"ARGS": `"oops`}))
+	require.EqualError(t, err, "unterminated double quote: field Args (slice) in struct S")
+
+	err = Unmarshal(&s, Map(map[string]string{"PORTS": "80 http"}))
+	require.Error(t, err)
+
+	type Bad struct {
+		Name string `env:"NAME" envopt:"shellwords"`
+	}
+	err = Unmarshal(&Bad{}, Map(map[string]string{"NAME": "x"}))
+	require.EqualError(t, err, "modifier requires a slice field: field Name (string) in struct Bad")
+}
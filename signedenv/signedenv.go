@@ -0,0 +1,171 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package signedenv looks up fromenv keys in a configuration document
+// whose signature is verified before any value is served, so that a
+// tampered document fails closed.
+//
+// A document is a JSON object of string values, keyed by environment key.
+// Its signature is kept alongside it, in a file or at a URL with ".sig"
+// appended to its path, holding the base64 encoded HMAC-SHA256 or ed25519
+// signature of the document's bytes.
+//
+//	f, err := signedenv.File("/etc/app/config.json", signedenv.Ed25519(pub))
+//	...
+//	err = fromenv.Unmarshal(&cfg, fromenv.Looker(f))
+package signedenv
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/alfred-landrum/fromenv"
+)
+
+// SigSuffix is appended to a document's path, or its URL's path, to find
+// its signature.
+const SigSuffix = ".sig"
+
+// ErrBadSignature is returned when a document's signature doesn't verify.
+var ErrBadSignature = errors.New("signedenv: invalid signature")
+
+// A Verifier checks the signature of a document.
+type Verifier interface {
+	Verify(doc, sig []byte) error
+}
+
+type hmacVerifier []byte
+
+// HMAC returns a Verifier of HMAC-SHA256 signatures made with key. If
+// key is empty, which anyone could sign with, no signature verifies.
+func HMAC(key []byte) Verifier {
+	return hmacVerifier(append([]byte(nil), key...))
+}
+
+func (key hmacVerifier) Verify(doc, sig []byte) error {
+	if len(key) == 0 {
+		return errors.New("signedenv: empty HMAC key")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(doc)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+type ed25519Verifier ed25519.PublicKey
+
+// Ed25519 returns a Verifier of ed25519 signatures made with the private
+// key of pub.
+func Ed25519(pub ed25519.PublicKey) Verifier {
+	return ed25519Verifier(pub)
+}
+
+func (pub ed25519Verifier) Verify(doc, sig []byte) error {
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(ed25519.PublicKey(pub), doc, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Load verifies sig, the base64 encoded signature of doc, with v, and then
+// returns a lookup function serving the values of doc.
+func Load(doc, sig []byte, v Verifier) (fromenv.LookupEnvFunc, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return nil, fmt.Errorf("signedenv: invalid signature encoding: %v", err)
+	}
+	if err := v.Verify(doc, raw); err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(doc, &values); err != nil {
+		return nil, fmt.Errorf("signedenv: %v", err)
+	}
+	return func(key string) (*string, error) {
+		if v, ok := values[key]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	}, nil
+}
+
+// File reads the document at path, and its signature at path with
+// SigSuffix appended, and returns the result of Load.
+func File(path string, v Verifier) (fromenv.LookupEnvFunc, error) {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := os.ReadFile(path + SigSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return Load(doc, sig, v)
+}
+
+// URL fetches the document at docURL, and its signature at docURL with
+// SigSuffix appended to its path, keeping any query, using client, or
+// http.DefaultClient if nil, and returns the result of Load.
+func URL(ctx context.Context, client *http.Client, docURL string, v Verifier) (fromenv.LookupEnvFunc, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	sigURL, err := sigURL(docURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := fetch(ctx, client, docURL)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := fetch(ctx, client, sigURL)
+	if err != nil {
+		return nil, err
+	}
+	return Load(doc, sig, v)
+}
+
+// sigURL returns docURL with SigSuffix appended to its path.
+func sigURL(docURL string) (string, error) {
+	u, err := url.Parse(docURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path += SigSuffix
+	if u.RawPath != "" {
+		u.RawPath += SigSuffix
+	}
+	return u.String(), nil
+}
+
+// fetch returns the body of a successful GET request for rawURL.
+func fetch(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signedenv: %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
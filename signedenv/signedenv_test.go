@@ -0,0 +1,107 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package signedenv
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT=80"`
+}
+
+var doc = []byte(`{"HOST": "db.internal", "PORT": "5432"}`)
+
+func TestHMAC(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("shared key")
+	mac := hmac.New(sha256.New, key)
+	mac.Write(doc)
+	sig := []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)) + "\n")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, doc, 0o600))
+	require.NoError(t, os.WriteFile(path+SigSuffix, sig, 0o600))
+
+	f, err := File(path, HMAC(key))
+	require.NoError(t, err)
+	var c config
+	require.NoError(t, fromenv.Unmarshal(&c, fromenv.Looker(f)))
+	require.Equal(t, config{"db.internal", 5432}, c)
+
+	_, err = File(path, HMAC([]byte("other key")))
+	require.True(t, errors.Is(err, ErrBadSignature))
+
+	tampered := []byte(`{"HOST": "evil.example", "PORT": "5432"}`)
+	require.NoError(t, os.WriteFile(path, tampered, 0o600))
+	_, err = File(path, HMAC(key))
+	require.True(t, errors.Is(err, ErrBadSignature))
+
+	_, err = Load(doc, []byte("not base64!"), HMAC(key))
+	require.Error(t, err)
+
+	// A signature made with an empty key doesn't verify.
+	mac = hmac.New(sha256.New, nil)
+	mac.Write(doc)
+	sig = []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	_, err = Load(doc, sig, HMAC(nil))
+	require.EqualError(t, err, "signedenv: empty HMAC key")
+}
+
+func TestEd25519(t *testing.T) {
+	t.Parallel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, doc))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			_, _ = w.Write(doc)
+		case "/config.json" + SigSuffix:
+			if r.URL.Query().Get("token") != "abc" {
+				http.Error(w, "no token", http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(sig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	f, err := URL(ctx, nil, srv.URL+"/config.json?token=abc", Ed25519(pub))
+	require.NoError(t, err)
+	var c config
+	require.NoError(t, fromenv.Unmarshal(&c, fromenv.Looker(f)))
+	require.Equal(t, config{"db.internal", 5432}, c)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = URL(ctx, nil, srv.URL+"/config.json?token=abc", Ed25519(other))
+	require.True(t, errors.Is(err, ErrBadSignature))
+
+	_, err = URL(ctx, nil, srv.URL+"/missing.json", Ed25519(pub))
+	require.EqualError(t, err, "signedenv: "+srv.URL+"/missing.json: 404 Not Found")
+}
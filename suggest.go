@@ -0,0 +1,93 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListKeys configures the function used to list the keys in the
+// environment, for lookup functions set via Looker that can enumerate
+// their keys. The list is used by Relaxed, and to suggest the intended key
+// when a required key is missing. It must be given after Looker.
+func ListKeys(fn func() []string) Option {
+	return func(c *config) {
+		c.keys = fn
+	}
+}
+
+// missingRequired returns the error for the missing required key, with a
+// suggestion of a similarly named key in the environment, if any.
+func (c *config) missingRequired(key string) error {
+	if c.keys == nil {
+		return errMissingRequired
+	}
+	if s, ok := suggestKey(key, c.keys()); ok {
+		return fmt.Errorf("%w (did you mean %v?)", errMissingRequired, s)
+	}
+	return errMissingRequired
+}
+
+// suggestKey returns the key of keys closest to key, if any is close
+// enough to be a likely misspelling: within an edit distance of 2, or of
+// 1 for keys of 4 characters or fewer, ignoring case.
+func suggestKey(key string, keys []string) (string, bool) {
+	limit := 2
+	if len(key) <= 4 {
+		limit = 1
+	}
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+	best, bestDist := "", limit+1
+	for _, k := range keys {
+		if k == key {
+			continue
+		}
+		if d := editDistance(strings.ToUpper(key), strings.ToUpper(k)); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the optimal string alignment distance between a
+// and b: the number of insertions, deletions, substitutions, and
+// transpositions of adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
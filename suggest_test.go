@@ -0,0 +1,42 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestKey(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password string `env:"DB_PASSWORD" envopt:"required"`
+	}
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"DB_PASSWROD": "x", "DB_USER": "u"}))
+	require.EqualError(t, err, "missing required key (did you mean DB_PASSWROD?): field Password (string) in struct S")
+	require.True(t, errors.Is(err, errMissingRequired))
+
+	err = Unmarshal(&s, Map(map[string]string{"db_password": "x"}))
+	require.EqualError(t, err, "missing required key (did you mean db_password?): field Password (string) in struct S")
+
+	err = Unmarshal(&s, Map(map[string]string{"DB_PASS": "x"}))
+	require.EqualError(t, err, "missing required key: field Password (string) in struct S")
+
+	// Lookup functions can't suggest keys unless they can list them.
+	env := map[string]string{"DB_PASSWORDS": "x"}
+	err = Unmarshal(&s, Looker(lookupMap(env)))
+	require.EqualError(t, err, "missing required key: field Password (string) in struct S")
+	err = Unmarshal(&s, Looker(lookupMap(env)), ListKeys(func() []string { return []string{"DB_PASSWORDS"} }))
+	require.EqualError(t, err, "missing required key (did you mean DB_PASSWORDS?): field Password (string) in struct S")
+
+	require.Equal(t, 1, editDistance("PASSWORD", "PASSWROD"))
+	require.Equal(t, 3, editDistance("kitten", "sitting"))
+	require.Equal(t, 0, editDistance("", ""))
+}
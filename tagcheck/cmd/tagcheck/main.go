@@ -0,0 +1,18 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// The tagcheck command reports contradictory fromenv struct tags, as
+// described by the tagcheck package. It's run by go vet:
+//
+//	go vet -vettool=$(which tagcheck) ./...
+package main
+
+import (
+	"github.com/alfred-landrum/fromenv/tagcheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(tagcheck.Analyzer)
+}
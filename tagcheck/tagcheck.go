@@ -0,0 +1,232 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package tagcheck defines an Analyzer that reports contradictory env and
+// envopt struct tags at their positions in the source. It reports the
+// problems found by fromenv.Check that don't depend on options or on the
+// other structs a field is reachable from; the tagcheck command runs it
+// with go vet:
+//
+//	go install github.com/alfred-landrum/fromenv/tagcheck/cmd/tagcheck@latest
+//	go vet -vettool=$(which tagcheck) ./...
+package tagcheck
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports problems with the env and envopt tags of each struct
+// field: unknown modifiers, and combinations of tags that contradict each
+// other, such as a required field with a tag default, or the envmap
+// modifier on a field that isn't a map. Fields of a struct sharing a key,
+// but with different tag defaults, are reported at the later field.
+var Analyzer = &analysis.Analyzer{
+	Name: "tagcheck",
+	Doc:  "report contradictory fromenv struct tags",
+	Run:  run,
+}
+
+// modifiers lists the names allowed in an envopt tag, as by fromenv.
+var modifiers = map[string]struct{}{
+	"csv":        {},
+	"deprecated": {},
+	"encrypted":  {},
+	"envmap":     {},
+	"from":       {},
+	"if":         {},
+	"layouts":    {},
+	"oneof":      {},
+	"required":   {},
+	"secret":     {},
+	"shellwords": {},
+	"source":     {},
+	"variant":    {},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if st, ok := n.(*ast.StructType); ok {
+				checkStruct(pass, st)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// checkStruct reports the problems with the tags of the struct's fields.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	defaults := make(map[string]*string)
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		envTag, hasEnv := reflect.StructTag(tag).Lookup("env")
+		optTag, hasOpt := reflect.StructTag(tag).Lookup("envopt")
+		if !hasEnv && !hasOpt {
+			continue
+		}
+		fail := func(format string, args ...interface{}) {
+			pass.Reportf(field.Tag.Pos(), format, args...)
+		}
+
+		mods, err := parseModifiers(optTag)
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		key, defval := parseTag(envTag)
+		if key == "" {
+			continue
+		}
+		problems := checkField(pass.TypesInfo.TypeOf(field.Type), defval, mods)
+		for _, p := range problems {
+			fail("%s", p)
+		}
+
+		if prev, ok := defaults[key]; ok {
+			if (prev == nil) != (defval == nil) || (prev != nil && *prev != *defval) {
+				fail("key %v has a different default than another field", key)
+			}
+		} else {
+			defaults[key] = defval
+		}
+	}
+}
+
+// checkField returns the problems with the tags of a field of type t, with
+// the tag default defval, if any, and the envopt modifiers mods.
+func checkField(t types.Type, defval *string, mods map[string]string) []string {
+	var problems []string
+	fail := func(msg string) {
+		problems = append(problems, msg)
+	}
+
+	_, required := mods["required"]
+	if required && defval != nil {
+		fail("required field has a default, which is never used")
+	}
+	if sources, ok := mods["source"]; ok {
+		useDefault, err := parseSources(sources)
+		if err != nil {
+			fail(err.Error())
+		} else if !useDefault && defval != nil && !required {
+			fail("source modifier doesn't allow the default, which is never used")
+		}
+	}
+	if _, ok := mods["deprecated"]; ok && required {
+		fail("required field is deprecated")
+	}
+	if t == nil {
+		return problems
+	}
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if _, ok := mods["shellwords"]; ok {
+		if _, ok := t.Underlying().(*types.Slice); !ok {
+			fail("shellwords modifier requires a slice field")
+		}
+	}
+	if _, ok := mods["csv"]; ok {
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			fail("csv modifier requires a struct field")
+		}
+	}
+	if layouts, ok := mods["layouts"]; ok {
+		if !isTime(t) {
+			fail("layouts modifier requires a time.Time field")
+		} else if strings.TrimSpace(strings.ReplaceAll(layouts, ";", "")) == "" {
+			fail("layouts modifier lists no layouts")
+		}
+	}
+	if _, ok := mods["envmap"]; ok {
+		if _, ok := t.Underlying().(*types.Map); !ok {
+			fail("envmap modifier requires a map field")
+		} else if defval != nil {
+			fail("envmap field has a default, which is never used")
+		}
+	}
+	if cond, ok := mods["if"]; ok && cond == "" {
+		fail("if modifier has no key")
+	}
+	if _, ok := mods["from"]; ok {
+		if _, ok := mods["source"]; ok {
+			fail("from and source modifiers both given")
+		}
+	}
+	return problems
+}
+
+// isTime returns true if t is time.Time.
+func isTime(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time"
+}
+
+// parseTag returns the key and default encoded in an env tag, as by
+// fromenv.
+func parseTag(tag string) (string, *string) {
+	s := strings.SplitN(tag, "=", 2)
+	if len(s) == 1 {
+		return s[0], nil
+	}
+	return s[0], &s[1]
+}
+
+// parseModifiers returns the modifiers encoded in an envopt tag, as by
+// fromenv.
+func parseModifiers(tag string) (map[string]string, error) {
+	mods := make(map[string]string)
+	if tag == "" {
+		return mods, nil
+	}
+	for _, m := range strings.Split(tag, ",") {
+		s := strings.SplitN(strings.TrimSpace(m), "=", 2)
+		if _, ok := modifiers[s[0]]; !ok {
+			return nil, fmt.Errorf("unknown modifier: %q", s[0])
+		}
+		if len(s) == 1 {
+			mods[s[0]] = ""
+		} else {
+			mods[s[0]] = s[1]
+		}
+	}
+	return mods, nil
+}
+
+// parseSources returns whether the list of sources in a source modifier
+// allows the tag default, as by fromenv.
+func parseSources(s string) (bool, error) {
+	parts := strings.Split(s, ";")
+	for i, name := range parts {
+		switch strings.TrimSpace(name) {
+		case "":
+			return false, errors.New("empty source name")
+		case "default":
+			if i != len(parts)-1 {
+				return false, errors.New("default must be the last source")
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package tagcheck
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+)
+
+// analyze runs Analyzer on the source of a package, and returns its
+// diagnostics, each prefixed by its line.
+func analyze(t *testing.T, src string) []string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	require.NoError(t, err)
+	files := []*ast.File{file}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("a", fset, files, info)
+	require.NoError(t, err)
+
+	var diags []string
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report: func(d analysis.Diagnostic) {
+			diags = append(diags, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+		},
+	}
+	_, err = Analyzer.Run(pass)
+	require.NoError(t, err)
+	return diags
+}
+
+func TestAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	diags := analyze(t, `package a
+
+import "time"
+
+type Good struct {
+	Host   string            `+"`"+`env:"HOST=localhost"`+"`"+`
+	Token  string            `+"`"+`env:"TOKEN" envopt:"secret,required"`+"`"+`
+	Level  string            `+"`"+`env:"LEVEL=info" envopt:"source=env;default"`+"`"+`
+	Args   []string          `+"`"+`env:"ARGS" envopt:"shellwords"`+"`"+`
+	At     *time.Time        `+"`"+`env:"AT" envopt:"layouts=RFC3339"`+"`"+`
+	Labels map[string]string `+"`"+`env:"LABEL" envopt:"envmap"`+"`"+`
+	Other  string            `+"`"+`json:"other"`+"`"+`
+}
+
+type Bad struct {
+	Name   string            `+"`"+`env:"NAME=n" envopt:"required"`+"`"+`
+	Level  string            `+"`"+`env:"LEVEL=info" envopt:"source=env"`+"`"+`
+	Old    string            `+"`"+`env:"OLD" envopt:"required,deprecated"`+"`"+`
+	Bogus  string            `+"`"+`env:"BOGUS" envopt:"bogus"`+"`"+`
+	Args   string            `+"`"+`env:"ARGS" envopt:"shellwords"`+"`"+`
+	Row    string            `+"`"+`env:"ROW" envopt:"csv"`+"`"+`
+	At     string            `+"`"+`env:"AT" envopt:"layouts=RFC3339"`+"`"+`
+	When   time.Time         `+"`"+`env:"WHEN" envopt:"layouts=;"`+"`"+`
+	Labels []string          `+"`"+`env:"LABEL" envopt:"envmap"`+"`"+`
+	Tags   map[string]string `+"`"+`env:"TAG=x" envopt:"envmap"`+"`"+`
+	Debug  bool              `+"`"+`env:"DEBUG" envopt:"if"`+"`"+`
+	Pass   string            `+"`"+`env:"PASS" envopt:"from=vault,source=env"`+"`"+`
+	Src    string            `+"`"+`env:"SRC" envopt:"source=default;env"`+"`"+`
+	Again  string            `+"`"+`env:"NAME=m"`+"`"+`
+}
+`)
+	require.Equal(t, []string{
+		"16: required field has a default, which is never used",
+		"17: source modifier doesn't allow the default, which is never used",
+		"18: required field is deprecated",
+		`19: unknown modifier: "bogus"`,
+		"20: shellwords modifier requires a slice field",
+		"21: csv modifier requires a struct field",
+		"22: layouts modifier requires a time.Time field",
+		"23: layouts modifier lists no layouts",
+		"24: envmap modifier requires a map field",
+		"25: envmap field has a default, which is never used",
+		"26: if modifier has no key",
+		"27: from and source modifiers both given",
+		"28: default must be the last source",
+		"29: key NAME has a different default than another field",
+	}, diags)
+}
+
+func TestModifiers(t *testing.T) {
+	t.Parallel()
+
+	// Each modifier the analyzer allows is allowed by fromenv.
+	for name := range modifiers {
+		typ := reflect.StructOf([]reflect.StructField{{
+			Name: "F",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`env:"F" envopt:"` + name + `"`),
+		}})
+		err := fromenv.Check(reflect.New(typ).Interface())
+		var list fromenv.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				require.NotContains(t, e.Error(), "unknown modifier", name)
+			}
+		}
+	}
+}
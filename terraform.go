@@ -0,0 +1,116 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TerraformVariables returns Terraform variable blocks for the variables
+// described by s, so that infrastructure code declares the same contract
+// as the program. Each block is named by its variable's key in lowercase,
+// with characters that can't appear in a Terraform name replaced by
+// underscores, and has the variable's type, default, description, and
+// whether it's sensitive. Variables with a kind of list or map have the
+// types list(string) or map(string); a list's default is split as by the
+// shellwords modifier. Defaults that don't parse as their variable's kind
+// make the variable a string. Variables that aren't required, and have no
+// default, default to null, as do those whose defaults have "${KEY}"
+// references, so that Unmarshal expands them if no value is given. Secret
+// variables' defaults are never written. Deprecated variables are omitted.
+func (s *Schema) TerraformVariables() []byte {
+	var b strings.Builder
+	for _, v := range s.Vars {
+		if v.Deprecated {
+			continue
+		}
+		typ, def := terraformType(v)
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "variable %q {\n", terraformName(v.Key))
+		fmt.Fprintf(&b, "  type        = %s\n", typ)
+		switch {
+		case def != "":
+			fmt.Fprintf(&b, "  default     = %s\n", def)
+		case !v.Required:
+			fmt.Fprintf(&b, "  default     = null\n")
+		}
+		if v.Description != "" {
+			fmt.Fprintf(&b, "  description = %s\n", hclString(v.Description))
+		}
+		if v.Secret {
+			fmt.Fprintf(&b, "  sensitive   = true\n")
+		}
+		b.WriteString("}\n")
+	}
+	return []byte(b.String())
+}
+
+// terraformType returns the Terraform type of v, and its default as an
+// HCL expression, or the empty string if it has none.
+func terraformType(v SchemaVar) (string, string) {
+	if v.Prefix {
+		return "map(string)", ""
+	}
+	if v.Secret || v.fixedDefault() == nil {
+		switch v.Kind {
+		case KindBool, KindNumber:
+			return string(v.Kind), ""
+		case KindList:
+			return "list(string)", ""
+		}
+		return "string", ""
+	}
+
+	def := *v.Default
+	switch v.Kind {
+	case KindBool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return "bool", strconv.FormatBool(b)
+		}
+	case KindNumber:
+		if _, err := strconv.ParseFloat(def, 64); err == nil {
+			return "number", def
+		}
+	case KindList:
+		if words, err := splitShellWords(def); err == nil {
+			elems := make([]string, len(words))
+			for i, w := range words {
+				elems[i] = hclString(w)
+			}
+			return "list(string)", "[" + strings.Join(elems, ", ") + "]"
+		}
+	}
+	return "string", hclString(def)
+}
+
+// terraformName returns key as a Terraform name: in lowercase, without a
+// trailing separator, and with characters other than letters, digits,
+// underscores, and dashes replaced by underscores. A name that doesn't
+// start with a letter or underscore is prefixed by an underscore.
+func terraformName(key string) string {
+	key = strings.TrimRight(strings.ToLower(key), "_-.")
+	name := []byte(key)
+	for i, c := range name {
+		switch {
+		case 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '_', c == '-':
+		default:
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 || ('0' <= name[0] && name[0] <= '9') || name[0] == '-' {
+		name = append([]byte{'_'}, name...)
+	}
+	return string(name)
+}
+
+// hclString returns s as a quoted HCL string, with template sequences
+// escaped.
+func hclString(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(strconv.Quote(s))
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerraformVariables(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Name    string            `env:"NAME" envopt:"required" envdoc:"Service \"name\""`
+		Token   string            `env:"TOKEN" envopt:"secret,required"`
+		Port    int               `env:"PORT=8080"`
+		Ratio   float64           `env:"RATIO=x"`
+		Debug   bool              `env:"DEBUG=1"`
+		Verbose bool              `env:"APP.VERBOSE"`
+		Args    []string          `env:"ARGS=-v 'a b'" envopt:"shellwords"`
+		Tmpl    string            `env:"TMPL=${HOME}" envdoc:"Defaults to ${HOME}"`
+		Timeout time.Duration     `env:"TIMEOUT=5s"`
+		Old     string            `env:"OLD_NAME" envopt:"deprecated=NAME"`
+		Labels  map[string]string `env:"LABEL" envopt:"envmap"`
+	}
+	s, err := SchemaOf(&Config{})
+	require.NoError(t, err)
+	require.Equal(t, `variable "name" {
+  type        = string
+  description = "Service \"name\""
+}
+
+variable "token" {
+  type        = string
+  sensitive   = true
+}
+
+variable "port" {
+  type        = number
+  default     = 8080
+}
+
+variable "ratio" {
+  type        = string
+  default     = "x"
+}
+
+variable "debug" {
+  type        = bool
+  default     = true
+}
+
+variable "app_verbose" {
+  type        = bool
+  default     = null
+}
+
+variable "args" {
+  type        = list(string)
+  default     = ["-v", "a b"]
+}
+
+variable "tmpl" {
+  type        = string
+  default     = null
+  description = "Defaults to $${HOME}"
+}
+
+variable "timeout" {
+  type        = string
+  default     = "5s"
+}
+
+variable "label" {
+  type        = map(string)
+  default     = null
+}
+`, string(s.TerraformVariables()))
+
+	s, err = SchemaOf(&struct {
+		Key string `env:"API_KEY=hunter2" envopt:"secret"`
+	}{})
+	require.NoError(t, err)
+	require.Equal(t, `variable "api_key" {
+  type        = string
+  default     = null
+  sensitive   = true
+}
+`, string(s.TerraformVariables()))
+
+	require.Equal(t, "_9lives", terraformName("9LIVES"))
+	require.Empty(t, (&Schema{}).TerraformVariables())
+}
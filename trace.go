@@ -0,0 +1,77 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Trace configures Unmarshal to write an explanation of how it handled
+// each field to w: the keys it looked up and in which layers, the default
+// it used, if any, the value it set, and why it skipped or left the field
+// unchanged. The values of secret fields are redacted.
+func Trace(w io.Writer) Option {
+	return func(c *config) {
+		c.trace = w
+	}
+}
+
+// traced returns visitor wrapped to collect the trace lines for each field
+// and write them to the configured writer.
+func (c *config) traced(visitor func(*cursor) error) func(*cursor) error {
+	return func(cur *cursor) error {
+		c.traceLines = []string{}
+		err := visitor(cur)
+		if err != nil && err != errSkipField {
+			msg := err.Error()
+			if fe, ok := err.(*FieldError); ok {
+				msg = fe.Err.Error()
+			}
+			c.tracef("error: %v", msg)
+		}
+
+		lines := c.traceLines
+		c.traceLines = nil
+		if cur.info.key == "" && len(lines) == 0 {
+			return err
+		}
+		var b strings.Builder
+		b.WriteString(cur.path)
+		if cur.info.key != "" {
+			fmt.Fprintf(&b, " (%v)", cur.info.key)
+		}
+		b.WriteString(":\n")
+		for _, line := range lines {
+			b.WriteString("    " + line + "\n")
+		}
+		_, _ = io.WriteString(c.trace, b.String())
+		return err
+	}
+}
+
+// tracef adds a line to the trace of the field being decoded, if tracing.
+func (c *config) tracef(format string, args ...interface{}) {
+	if c.traceLines != nil {
+		c.traceLines = append(c.traceLines, fmt.Sprintf(format, args...))
+	}
+}
+
+// traceSet adds the value the field at the cursor was set to to its trace.
+func (c *config) traceSet(cur *cursor) {
+	if c.traceLines == nil {
+		return
+	}
+	s, err := c.marshalField(cur.info, cur.value)
+	if err != nil {
+		c.tracef("set")
+		return
+	}
+	if cur.info.secret {
+		s = c.redact(cur.info.key, s)
+	}
+	c.tracef("set to %q", s)
+}
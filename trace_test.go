@@ -0,0 +1,79 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Level string `env:"LEVEL=info"`
+	}
+	type S struct {
+		Host  string `env:"HOST=localhost"`
+		Port  int    `env:"PORT"`
+		Token string `env:"TOKEN" envopt:"secret"`
+		Name  string `env:"NAME"`
+		Inner Inner
+	}
+
+	var buf bytes.Buffer
+	var s S
+	env := Map(map[string]string{"PORT": "8080", "HOST_PROD": "prod-host", "TOKEN": "t0ken"})
+	err := Unmarshal(&s, env, Trace(&buf), Profile("PROD"))
+	require.NoError(t, err)
+	require.Equal(t, `Host (HOST):
+    looked up HOST_PROD in env: found
+    set to "prod-host"
+Port (PORT):
+    looked up PORT_PROD in env: not found
+    looked up PORT in env: found
+    set to "8080"
+Token (TOKEN):
+    looked up TOKEN_PROD in env: not found
+    looked up TOKEN in env: found
+    set to "[REDACTED]"
+Name (NAME):
+    looked up NAME_PROD in env: not found
+    looked up NAME in env: not found
+    not found, and no default: left unchanged
+Inner.Level (LEVEL):
+    looked up LEVEL_PROD in env: not found
+    looked up LEVEL in env: not found
+    not found: using tag default "info"
+    set to "info"
+`, buf.String())
+
+	buf.Reset()
+	err = Unmarshal(&s, Map(map[string]string{"NAME": ""}), Trace(&buf), EmptyValues(EmptyIsUnset), Only("Name"))
+	require.NoError(t, err)
+	require.Equal(t, `Host (HOST):
+    skipped: outside the decoded path
+Port (PORT):
+    skipped: outside the decoded path
+Token (TOKEN):
+    skipped: outside the decoded path
+Name (NAME):
+    looked up NAME in env: found
+    empty value treated as unset
+    not found, and no default: left unchanged
+Inner:
+    skipped: outside the decoded path
+`, buf.String())
+
+	buf.Reset()
+	err = Unmarshal(&s, Map(map[string]string{"PORT": "eighty"}), Trace(&buf), Only("Port"))
+	require.Error(t, err)
+	require.Contains(t, buf.String(), `Port (PORT):
+    looked up PORT in env: found
+    error: strconv.ParseInt: parsing "eighty": invalid syntax
+`)
+}
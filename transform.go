@@ -0,0 +1,51 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+// Transform configures Unmarshal to pass each value found by looking up a
+// field's key to fn, and to set the field from the string fn returns,
+// allowing changes that apply across types, such as stripping byte order
+// marks, or mapping legacy sentinel values like "none" to the empty
+// string. Tag defaults aren't transformed. An error from fn fails the
+// field, as a parse failure would. Repeated uses of Transform are applied
+// in order.
+func Transform(fn func(f FieldInfo, value string) (string, error)) Option {
+	return func(c *config) {
+		c.transforms = append(append([]func(FieldInfo, string) (string, error)(nil), c.transforms...), fn)
+	}
+}
+
+// A transformError is an error returned by a function configured via
+// Transform.
+type transformError struct {
+	err error
+}
+
+func (e *transformError) Error() string { return e.err.Error() }
+
+func (e *transformError) Unwrap() error { return e.err }
+
+// resolveField resolves the value of the field at the cursor, as resolve
+// does with info, which is the cursor's info or a copy of it with another
+// key, and applies any functions configured via Transform to values from
+// the environment.
+func (config *config) resolveField(c *cursor, info *fieldInfo) (*string, Source, string, error) {
+	val, source, layer, err := config.resolve(info)
+	if err != nil || source != SourceEnv || len(config.transforms) == 0 {
+		return val, source, layer, err
+	}
+	f := newFieldInfo(c)
+	f.Key = info.key
+	s := *val
+	for _, fn := range config.transforms {
+		if s, err = fn(f, s); err != nil {
+			return nil, SourceNone, "", &transformError{err}
+		}
+	}
+	if s != *val {
+		config.tracef("transformed value")
+	}
+	return &s, source, layer, nil
+}
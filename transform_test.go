@@ -0,0 +1,59 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Name  string `env:"NAME"`
+		Port  int    `env:"PORT=8080"`
+		Proxy string `env:"PROXY"`
+	}
+	stripBOM := Transform(func(f FieldInfo, v string) (string, error) {
+		return strings.TrimPrefix(v, "\ufeff"), nil
+	})
+	var keys []string
+	legacy := Transform(func(f FieldInfo, v string) (string, error) {
+		keys = append(keys, f.Key+"@"+f.Path)
+		if v == "none" {
+			return "", nil
+		}
+		return v, nil
+	})
+
+	var s S
+	err := Unmarshal(&s, Map(map[string]string{"NAME": "\ufeffnone", "PROXY": "\ufeffhttp://p"}), stripBOM, legacy)
+	require.NoError(t, err)
+	require.Equal(t, S{"", 8080, "http://p"}, s)
+	require.Equal(t, []string{"NAME@Name", "PROXY@Proxy"}, keys)
+
+	reject := Transform(func(f FieldInfo, v string) (string, error) {
+		return "", errors.New("rejected")
+	})
+	err = Unmarshal(&s, Map(map[string]string{"PORT": "1"}), reject)
+	require.EqualError(t, err, "rejected: field Port (int) in struct S")
+	var ferr *FieldError
+	require.True(t, errors.As(err, &ferr))
+	require.Equal(t, CodeParseFailure, ferr.Code)
+
+	type L struct {
+		Limits map[string]int `env:"LIMIT" envopt:"envmap"`
+	}
+	var l L
+	double := Transform(func(f FieldInfo, v string) (string, error) {
+		return v + "0", nil
+	})
+	require.NoError(t, Unmarshal(&l, Map(map[string]string{"LIMIT_a": "1"}), double))
+	require.Equal(t, map[string]int{"a": 10}, l.Limits)
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A Weekday is a time.Weekday given by its English name, such as "Sunday",
+// by the name's first three letters, such as "sun", or by its number,
+// from 0 for Sunday to 6 for Saturday. Names are matched ignoring ASCII
+// case only, so that matching doesn't depend on the process's locale.
+type Weekday time.Weekday
+
+// Set sets w to the day s.
+func (w *Weekday) Set(s string) error {
+	n, ok := parseCalendar(s, time.Sunday, time.Saturday)
+	if !ok {
+		return fmt.Errorf("invalid weekday: %q", s)
+	}
+	*w = Weekday(n)
+	return nil
+}
+
+// String returns the English name of the day.
+func (w Weekday) String() string {
+	return time.Weekday(w).String()
+}
+
+// A Month is a time.Month given by its English name, such as "January", by
+// the name's first three letters, such as "jan", or by its number, from 1
+// for January to 12 for December. Names are matched ignoring ASCII case
+// only, so that matching doesn't depend on the process's locale.
+type Month time.Month
+
+// Set sets m to the month s.
+func (m *Month) Set(s string) error {
+	n, ok := parseCalendar(s, time.January, time.December)
+	if !ok {
+		return fmt.Errorf("invalid month: %q", s)
+	}
+	*m = Month(n)
+	return nil
+}
+
+// String returns the English name of the month.
+func (m Month) String() string {
+	return time.Month(m).String()
+}
+
+// parseCalendar returns the value from first to last, inclusive, whose
+// number, name, or name's first three letters is s.
+func parseCalendar[T ~int](s string, first, last T) (T, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return T(n), n >= int(first) && n <= int(last)
+	}
+	for v := first; v <= last; v++ {
+		name := fmt.Sprint(v)
+		if equalFoldASCII(s, name) || equalFoldASCII(s, name[:3]) {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// equalFoldASCII returns whether a and b are equal, ignoring the case of
+// ASCII letters.
+func equalFoldASCII(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if lowerASCII(a[i]) != lowerASCII(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeekday(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]time.Weekday{
+		"Sunday":   time.Sunday,
+		"SATURDAY": time.Saturday,
+		"wed":      time.Wednesday,
+		"Thu":      time.Thursday,
+		"0":        time.Sunday,
+		"6":        time.Saturday,
+	} {
+		var w Weekday
+		require.NoError(t, w.Set(in), in)
+		require.Equal(t, Weekday(out), w, in)
+	}
+	for _, in := range []string{"", "7", "-1", "su", "Sundays", "Sonntag", "ſun"} {
+		var w Weekday
+		require.EqualError(t, w.Set(in), "invalid weekday: \""+in+"\"", in)
+	}
+	require.Equal(t, "Friday", Weekday(time.Friday).String())
+}
+
+func TestMonth(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]time.Month{
+		"January": time.January,
+		"dec":     time.December,
+		"SEPT":    0,
+		"1":       time.January,
+		"12":      time.December,
+	} {
+		var m Month
+		err := m.Set(in)
+		if out == 0 {
+			require.EqualError(t, err, "invalid month: \""+in+"\"", in)
+			continue
+		}
+		require.NoError(t, err, in)
+		require.Equal(t, Month(out), m, in)
+	}
+	for _, in := range []string{"0", "13", "Janvier"} {
+		var m Month
+		require.Error(t, m.Set(in), in)
+	}
+
+	type S struct {
+		Day   Weekday `env:"MAINTENANCE_DAY=Sunday"`
+		Month *Month  `env:"FISCAL_START"`
+	}
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"FISCAL_START": "apr"}))
+	require.NoError(t, err)
+	require.Equal(t, time.Sunday, time.Weekday(s.Day))
+	require.Equal(t, time.April, time.Month(*s.Month))
+
+	env, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"MAINTENANCE_DAY": "Sunday", "FISCAL_START": "April"}, env)
+}
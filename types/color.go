@@ -0,0 +1,46 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+)
+
+// A Color is a color given in hex notation, as "#RRGGBB", or with an alpha
+// component, as "#RRGGBBAA". Colors without an alpha component are opaque.
+// Its components aren't alpha-premultiplied, and it satisfies color.Color.
+type Color color.NRGBA
+
+// Set sets c to the color s.
+func (c *Color) Set(s string) error {
+	if len(s) != 7 && len(s) != 9 || s[0] != '#' {
+		return fmt.Errorf("invalid color: %q", s)
+	}
+	b, err := hex.DecodeString(s[1:])
+	if err != nil {
+		return fmt.Errorf("invalid color: %q", s)
+	}
+	if len(b) == 3 {
+		b = append(b, 0xff)
+	}
+	*c = Color{R: b[0], G: b[1], B: b[2], A: b[3]}
+	return nil
+}
+
+// String returns c as "#rrggbb" if it's opaque, and as "#rrggbbaa"
+// otherwise.
+func (c Color) String() string {
+	if c.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// RGBA returns c's alpha-premultiplied components, as color.Color requires.
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColor(t *testing.T) {
+	t.Parallel()
+
+	var c Color
+	require.NoError(t, c.Set("#1A2b3C"))
+	require.Equal(t, Color{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, c)
+	require.Equal(t, "#1a2b3c", c.String())
+
+	require.NoError(t, c.Set("#ff000080"))
+	require.Equal(t, Color{R: 0xff, A: 0x80}, c)
+	require.Equal(t, "#ff000080", c.String())
+
+	var _ color.Color = c
+	r, _, _, a := c.RGBA()
+	require.Equal(t, uint32(0x8080), r)
+	require.Equal(t, uint32(0x8080), a)
+
+	for _, in := range []string{"", "#", "1a2b3c", "#1a2b3", "#1a2b3c4", "#12345g", "#1a2b3c4d5e"} {
+		require.EqualError(t, c.Set(in), "invalid color: \""+in+"\"", in)
+	}
+
+	type S struct {
+		Accent Color  `env:"ACCENT=#336699"`
+		Shadow *Color `env:"SHADOW"`
+	}
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"SHADOW": "#00000040"}))
+	require.NoError(t, err)
+	require.Equal(t, Color{R: 0x33, G: 0x66, B: 0x99, A: 0xff}, s.Accent)
+	require.Equal(t, &Color{A: 0x40}, s.Shadow)
+
+	env, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ACCENT": "#336699", "SHADOW": "#00000040"}, env)
+}
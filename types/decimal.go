@@ -0,0 +1,108 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// A Decimal is an exact decimal number, such as a monetary amount, that
+// would lose precision as a float64. It's a coefficient and a scale, the
+// number of digits after the decimal point, so that "1.50" is 150 with a
+// scale of 2. The zero value is 0.
+type Decimal struct {
+	coef  big.Int
+	scale int
+}
+
+// ParseDecimal parses s, a decimal number such as "-12.50", with an
+// optional sign, and digits on at least one side of an optional decimal
+// point. The scale is the number of digits after the decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	var d Decimal
+	err := d.Set(s)
+	return d, err
+}
+
+// Set sets d to the decimal number s, as parsed by ParseDecimal.
+func (d *Decimal) Set(s string) error {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "+"), "-")
+	whole, frac := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, frac = digits[:i], digits[i+1:]
+	}
+	if whole == "" && frac == "" || !allDigits(whole) || !allDigits(frac) || len(digits) < len(s)-1 {
+		return fmt.Errorf("invalid decimal: %q", s)
+	}
+
+	var coef big.Int
+	if _, ok := coef.SetString(whole+frac, 10); !ok {
+		return fmt.Errorf("invalid decimal: %q", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		coef.Neg(&coef)
+	}
+	// Assign, rather than reuse d.coef's memory, which copies of d share.
+	d.coef = coef
+	d.scale = len(frac)
+	return nil
+}
+
+// allDigits returns whether s holds only the digits 0 through 9.
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns d with its scale's number of digits after the decimal
+// point, such as "1.50".
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(&d.coef).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.coef.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Coefficient returns d's value multiplied by 10 to the power of its
+// scale, such as 150 for "1.50".
+func (d Decimal) Coefficient() *big.Int {
+	return new(big.Int).Set(&d.coef)
+}
+
+// Rat returns d as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(&d.coef, denom)
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares d and e, returning -1 if d is less than e, 0 if they're
+// equal, regardless of scale, and +1 if d is greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.Rat().Cmp(e.Rat())
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]string{
+		"1.50":                    "1.50",
+		"+0.05":                   "0.05",
+		"-.5":                     "-0.5",
+		"7.":                      "7",
+		"0":                       "0",
+		"-0.001":                  "-0.001",
+		"123456789012345678901.1": "123456789012345678901.1",
+	} {
+		d, err := ParseDecimal(in)
+		require.NoError(t, err, in)
+		require.Equal(t, out, d.String(), in)
+	}
+
+	for _, in := range []string{"", ".", "1.2.3", "1e5", "--1", "+-1", "1,000", " 1", "0x10"} {
+		_, err := ParseDecimal(in)
+		require.EqualError(t, err, "invalid decimal: \""+in+"\"", in)
+	}
+
+	a, _ := ParseDecimal("1.50")
+	b, _ := ParseDecimal("1.5")
+	c, _ := ParseDecimal("0.1")
+	require.Equal(t, 0, a.Cmp(b))
+	require.Equal(t, 1, a.Cmp(c))
+	require.Equal(t, -1, c.Cmp(a))
+	require.Equal(t, 2, a.Scale())
+	require.Equal(t, "150", a.Coefficient().String())
+	require.Equal(t, 1.5, a.Float64())
+	require.Equal(t, "0", Decimal{}.String())
+
+	copied := a
+	require.NoError(t, copied.Set("987654321"))
+	require.Equal(t, "1.50", a.String())
+	require.Equal(t, "987654321", copied.String())
+
+	type S struct {
+		Limit Decimal  `env:"LIMIT=0.10"`
+		Max   *Decimal `env:"MAX"`
+	}
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"MAX": "1000000.01"}))
+	require.NoError(t, err)
+	require.Equal(t, "0.10", s.Limit.String())
+	require.Equal(t, "1000000.01", s.Max.String())
+
+	env, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"LIMIT": "0.10", "MAX": "1000000.01"}, env)
+}
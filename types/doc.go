@@ -0,0 +1,7 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+// Package types provides types for struct fields set by the fromenv
+// package, for values that need more than a standard library type.
+package types
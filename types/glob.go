@@ -0,0 +1,94 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// A Glob is a shell file name pattern, with the syntax of path.Match, such
+// as "*.log" for include and exclude lists. Set rejects malformed
+// patterns, so that they're reported when the configuration is loaded
+// rather than when the pattern is first matched.
+type Glob struct {
+	pattern string
+}
+
+// Set sets g to the pattern s.
+func (g *Glob) Set(s string) error {
+	if _, err := path.Match(s, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", s, err)
+	}
+	g.pattern = s
+	return nil
+}
+
+// String returns the pattern.
+func (g Glob) String() string {
+	return g.pattern
+}
+
+// Match returns whether name matches the pattern, as path.Match does.
+func (g Glob) Match(name string) bool {
+	ok, _ := path.Match(g.pattern, name)
+	return ok
+}
+
+// A DoublestarGlob is a Glob whose "**" path elements match zero or more
+// whole elements of a slash-separated name, as in the doublestar and
+// gitignore syntaxes, so that "logs/**/*.gz" matches both "logs/a.gz" and
+// "logs/2017/01/a.gz". Other path elements are matched with path.Match.
+type DoublestarGlob struct {
+	pattern string
+}
+
+// Set sets g to the pattern s.
+func (g *DoublestarGlob) Set(s string) error {
+	for _, elem := range strings.Split(s, "/") {
+		if elem == "**" {
+			continue
+		}
+		if _, err := path.Match(elem, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", s, err)
+		}
+	}
+	g.pattern = s
+	return nil
+}
+
+// String returns the pattern.
+func (g DoublestarGlob) String() string {
+	return g.pattern
+}
+
+// Match returns whether name matches the pattern.
+func (g DoublestarGlob) Match(name string) bool {
+	return matchElems(strings.Split(g.pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchElems returns whether the path elements of name match those of
+// pattern, with "**" matching any number of elements.
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+
+	var g Glob
+	require.NoError(t, g.Set("*.log"))
+	require.True(t, g.Match("app.log"))
+	require.False(t, g.Match("logs/app.log"))
+	require.False(t, g.Match("app.txt"))
+	require.Equal(t, "*.log", g.String())
+
+	require.EqualError(t, g.Set("[a-"), `invalid glob "[a-": syntax error in pattern`)
+	require.Equal(t, "*.log", g.String())
+
+	var d DoublestarGlob
+	require.NoError(t, d.Set("logs/**/*.gz"))
+	for name, ok := range map[string]bool{
+		"logs/a.gz":         true,
+		"logs/2017/01/a.gz": true,
+		"logs/a.txt":        false,
+		"other/a.gz":        false,
+		"logs":              false,
+	} {
+		require.Equal(t, ok, d.Match(name), name)
+	}
+	require.NoError(t, d.Set("**"))
+	require.True(t, d.Match("a/b/c"))
+	require.EqualError(t, d.Set("**/[a-"), `invalid glob "**/[a-": syntax error in pattern`)
+
+	type S struct {
+		Include Glob           `env:"INCLUDE=*.go"`
+		Exclude DoublestarGlob `env:"EXCLUDE"`
+	}
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"EXCLUDE": "**/testdata/**"}))
+	require.NoError(t, err)
+	require.True(t, s.Include.Match("main.go"))
+	require.True(t, s.Exclude.Match("pkg/testdata/x.json"))
+
+	err = fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"INCLUDE": "["}))
+	require.Error(t, err)
+
+	env, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"INCLUDE": "*.go", "EXCLUDE": "**/testdata/**"}, env)
+}
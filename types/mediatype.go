@@ -0,0 +1,38 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"fmt"
+	"mime"
+)
+
+// A MediaType is a MIME media type, such as "text/html; charset=utf-8",
+// validated with mime.ParseMediaType. Type is lowercased, and holds the
+// type and subtype, such as "text/html", and Params holds the parameters,
+// keyed by their lowercased names.
+type MediaType struct {
+	Type   string
+	Params map[string]string
+}
+
+// Set sets m to the media type s.
+func (m *MediaType) Set(s string) error {
+	t, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return fmt.Errorf("invalid media type %q: %w", s, err)
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	*m = MediaType{Type: t, Params: params}
+	return nil
+}
+
+// String returns m formatted with mime.FormatMediaType, with its
+// parameters sorted by name.
+func (m MediaType) String() string {
+	return mime.FormatMediaType(m.Type, m.Params)
+}
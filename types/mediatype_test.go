@@ -0,0 +1,41 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaType(t *testing.T) {
+	t.Parallel()
+
+	var m MediaType
+	require.NoError(t, m.Set("Text/HTML; Charset=utf-8"))
+	require.Equal(t, MediaType{Type: "text/html", Params: map[string]string{"charset": "utf-8"}}, m)
+	require.Equal(t, "text/html; charset=utf-8", m.String())
+
+	require.NoError(t, m.Set("application/json"))
+	require.Equal(t, MediaType{Type: "application/json"}, m)
+
+	for _, in := range []string{"", "text/", "/html", "text/html; charset"} {
+		require.Error(t, m.Set(in), in)
+	}
+	require.EqualError(t, m.Set("text/"), `invalid media type "text/": mime: expected token after slash`)
+
+	type S struct {
+		Accept MediaType `env:"ACCEPT=application/json"`
+	}
+	var s S
+	err := fromenv.Unmarshal(&s, fromenv.Map(map[string]string{"ACCEPT": "text/plain;charset=us-ascii"}))
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", s.Accept.Type)
+
+	env, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"ACCEPT": "text/plain; charset=us-ascii"}, env)
+}
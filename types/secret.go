@@ -0,0 +1,85 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+// redacted is the placeholder shown in place of a Secret's value.
+const redacted = "[REDACTED]"
+
+// A Secret holds a sensitive value, such as a password or API token. Its
+// String method, and so its formatting by the fmt package, returns a
+// placeholder, and fromenv treats fields of type Secret as if they had the
+// secret modifier, so their values are left out of Marshal, Diff, and the
+// debugging output.
+//
+// The value is kept in a byte slice, so that Wipe can zero it once it has
+// been used, such as after opening a connection with it.
+type Secret struct {
+	b []byte
+}
+
+// NewSecret returns a Secret holding s.
+func NewSecret(s string) Secret {
+	return Secret{[]byte(s)}
+}
+
+// Set sets the value to v. The previous value isn't wiped, since copies of
+// the Secret, such as in a struct a Watcher has published, share it.
+func (s *Secret) Set(v string) error {
+	s.b = []byte(v)
+	return nil
+}
+
+// String returns a placeholder, not the value.
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString returns a placeholder, not the value.
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// MarshalText returns a placeholder, not the value, so that encoding a
+// Secret, such as with encoding/json, doesn't reveal it.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// MarshalEnv returns the value, for use by fromenv.Marshal with the
+// IncludeSecrets option.
+func (s Secret) MarshalEnv() (string, error) {
+	return string(s.b), nil
+}
+
+// SecretEnv returns true, marking Secret fields as secret for fromenv.
+func (s Secret) SecretEnv() bool {
+	return true
+}
+
+// Bytes returns the value. The returned slice shares memory with the
+// Secret, so it's zeroed by Wipe.
+func (s Secret) Bytes() []byte {
+	return s.b
+}
+
+// Reveal returns the value as a string. Unlike the slice returned by
+// Bytes, the string isn't zeroed by Wipe.
+func (s Secret) Reveal() string {
+	return string(s.b)
+}
+
+// Empty returns true if the value is empty, or has been wiped.
+func (s Secret) Empty() bool {
+	return len(s.b) == 0
+}
+
+// Wipe zeroes the value's memory, and empties the Secret. Copies of the
+// Secret share its memory, so they hold zeroes afterwards.
+func (s *Secret) Wipe() {
+	for i := range s.b {
+		s.b[i] = 0
+	}
+	s.b = nil
+}
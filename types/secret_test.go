@@ -0,0 +1,77 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/alfred-landrum/fromenv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Name     string  `env:"NAME"`
+		Password Secret  `env:"PASSWORD"`
+		Token    *Secret `env:"TOKEN"`
+	}
+
+	env := fromenv.Map(map[string]string{"NAME": "n", "PASSWORD": "hunter2", "TOKEN": "t0k"})
+	var s S
+	var r fromenv.Result
+	err := fromenv.Unmarshal(&s, env, fromenv.Record(&r))
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", s.Password.Reveal())
+	require.Equal(t, []byte("t0k"), s.Token.Bytes())
+	require.True(t, r.Field("Password").Secret)
+	require.True(t, r.Field("Token").Secret)
+
+	require.Equal(t, "[REDACTED]", s.Password.String())
+	require.Equal(t, "[REDACTED] [REDACTED]", fmt.Sprintf("%v %s", s.Password, s.Token))
+	require.NotContains(t, fmt.Sprintf("%+v %#v", s, s), "hunter2")
+	js, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, `{"Name":"n","Password":"[REDACTED]","Token":"[REDACTED]"}`, string(js))
+
+	m, err := fromenv.Marshal(&s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"NAME": "n"}, m)
+	m, err = fromenv.Marshal(&s, fromenv.IncludeSecrets())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"NAME": "n", "PASSWORD": "hunter2", "TOKEN": "t0k"}, m)
+
+	old := S{Password: NewSecret("a")}
+	changes := fromenv.Diff(&old, &S{Password: NewSecret("b")})
+	require.Len(t, changes, 1)
+	require.True(t, changes[0].Secret)
+
+	b := s.Password.Bytes()
+	s.Password.Wipe()
+	require.Equal(t, make([]byte, 7), b)
+	require.True(t, s.Password.Empty())
+	require.Equal(t, "", s.Password.Reveal())
+}
+
+func TestSecretWatcherRefresh(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password Secret `env:"PASSWORD"`
+	}
+
+	env := map[string]string{"PASSWORD": "hunter2"}
+	w, err := fromenv.NewWatcher(&S{}, fromenv.Map(env))
+	require.NoError(t, err)
+	old := w.Current().(*S)
+
+	env["PASSWORD"] = "swordfish"
+	require.NoError(t, w.Refresh("PASSWORD"))
+	require.Equal(t, "swordfish", w.Current().(*S).Password.Reveal())
+	require.Equal(t, "hunter2", old.Password.Reveal())
+}
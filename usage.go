@@ -0,0 +1,75 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage writes a table describing the tagged fields reachable from the
+// struct pointer in to w, with a line for each key giving its type, tag
+// default, and the field's envdoc tag. Required keys are noted after the
+// description. Keys are listed in the order that Unmarshal visits their
+// fields, which depends only on in's type and on which of its struct
+// pointers are nil, so the output is stable enough to check in and
+// compare. A key tagged on several fields is listed once.
+func Usage(w io.Writer, in interface{}, options ...Option) error {
+	if !isStructPtr(in) {
+		return errors.New("passed non-pointer or nil pointer")
+	}
+	config := newConfig(options)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tDEFAULT\tDESCRIPTION")
+	seen := make(map[string]struct{})
+	err := visit(in, "", func(c *cursor) error {
+		if config.skipped(c.field.Type) {
+			return errSkipField
+		}
+		if err := config.rekey(c); err != nil {
+			return config.fieldError(CodeInvalidTag, err, c)
+		}
+		key := c.info.key
+		if key == "" {
+			return nil
+		}
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+
+		t := c.field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		defval := ""
+		if c.info.defval != nil {
+			defval = *c.info.defval
+		}
+		doc := c.field.Tag.Get(docTagName)
+		if _, ok := c.info.mods["required"]; ok {
+			doc = strings.TrimSpace(doc + " (required)")
+		}
+		if _, ok := c.info.mods["oneof"]; ok {
+			if variants, err := unionVariants(c.field.Type); err == nil {
+				doc = strings.TrimSpace(doc + " (one of " + variantNames(variants) + ")")
+			}
+		}
+		if sep, ok := envMapSep(c.info); ok {
+			key, defval = key+sep+"*", ""
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", key, t, defval, doc)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	type DB struct {
+		URL string `env:"DB_URL" envopt:"required,secret" envdoc:"database URL"`
+	}
+	type S struct {
+		Host    string        `env:"HOST=localhost" envdoc:"host to listen on"`
+		Port    *int          `env:"PORT=80"`
+		Timeout time.Duration `env:"TIMEOUT=5s" envdoc:"request timeout"`
+		DB      DB
+		Backup  *DB
+		Skipped float32        `env:"SKIPPED"`
+		Other   map[string]int `env:"HOST"`
+	}
+
+	var b bytes.Buffer
+	err := Usage(&b, &S{Backup: &DB{}}, SkipTypes(reflect.TypeOf(float32(0))))
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"KEY      TYPE           DEFAULT    DESCRIPTION\n"+
+		"HOST     string         localhost  host to listen on\n"+
+		"PORT     int            80         \n"+
+		"TIMEOUT  time.Duration  5s         request timeout\n"+
+		"DB_URL   string                    database URL (required)\n", b.String())
+
+	err = Usage(&b, S{})
+	require.EqualError(t, err, "passed non-pointer or nil pointer")
+}
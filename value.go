@@ -0,0 +1,89 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"sync/atomic"
+)
+
+// A Value holds a pointer to the current decoded struct of type T. Load
+// never blocks, so the struct may be read from hot paths while Reload or a
+// started Watcher replaces it.
+type Value[T any] struct {
+	p        atomic.Pointer[T]
+	w        *Watcher
+	handlers []func(old, new *T, changes []FieldChange)
+}
+
+// NewValue decodes a new T with Unmarshal and options, and returns a Value
+// holding it.
+func NewValue[T any](options ...Option) (*Value[T], error) {
+	in := new(T)
+	w, err := NewWatcher(in, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Value[T]{w: w}
+	v.p.Store(in)
+	w.OnChange(func(changes []FieldChange) {
+		w.mu.Lock()
+		next := w.current.Interface().(*T)
+		handlers := append(([]func(old, new *T, changes []FieldChange))(nil), v.handlers...)
+		w.mu.Unlock()
+		old := v.p.Swap(next)
+		for _, fn := range handlers {
+			fn(old, next, changes)
+		}
+	})
+	return v, nil
+}
+
+// Load returns the current struct. The struct is shared, and must not be
+// modified.
+func (v *Value[T]) Load() *T {
+	return v.p.Load()
+}
+
+// Snapshot returns the current struct, and the Result of decoding it.
+// Neither must be modified.
+func (v *Value[T]) Snapshot() (interface{}, *Result) {
+	return v.w.Snapshot()
+}
+
+// Stats returns counts of the Value's reloads.
+func (v *Value[T]) Stats() WatcherStats {
+	return v.w.Stats()
+}
+
+// Reload decodes a new T, and replaces the current struct with it if any
+// tagged fields have changed. The current struct is kept if decoding fails.
+func (v *Value[T]) Reload() error {
+	return v.w.Poll()
+}
+
+// OnChange registers fn to be called with the old and new structs, and the
+// changed fields, whenever the current struct is replaced. Functions are
+// called after Load returns the new struct, and must not call Reload.
+func (v *Value[T]) OnChange(fn func(old, new *T, changes []FieldChange)) {
+	v.w.mu.Lock()
+	defer v.w.mu.Unlock()
+	v.handlers = append(v.handlers, fn)
+}
+
+// Validate registers fn to be called with each newly decoded struct. If fn
+// returns an error, the new struct is discarded, and Load continues to
+// return the current struct.
+func (v *Value[T]) Validate(fn func(next *T) error) {
+	v.w.Validate(func(next interface{}) error {
+		return fn(next.(*T))
+	})
+}
+
+// Watcher returns the Watcher that replaces the Value's struct, which may
+// be started to reload it periodically.
+func (v *Value[T]) Watcher() *Watcher {
+	return v.w
+}
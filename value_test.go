@@ -0,0 +1,67 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Level string `env:"LEVEL=info"`
+	}
+
+	env := &testEnv{}
+	v, err := NewValue[S](env.looker())
+	require.NoError(t, err)
+	require.Equal(t, "info", v.Load().Level)
+
+	var olds, news []string
+	v.OnChange(func(old, new *S, changes []FieldChange) {
+		require.Equal(t, new, v.Load())
+		require.Equal(t, []FieldChange{{Path: "Level", Key: "LEVEL", Old: old.Level, New: new.Level}}, changes)
+		olds = append(olds, old.Level)
+		news = append(news, new.Level)
+	})
+
+	require.NoError(t, v.Reload())
+	require.Empty(t, olds)
+
+	env.set("LEVEL", "debug")
+	require.NoError(t, v.Reload())
+	require.Equal(t, "debug", v.Load().Level)
+	require.Equal(t, []string{"info"}, olds)
+	require.Equal(t, []string{"debug"}, news)
+
+	_, err = NewValue[int]()
+	require.EqualError(t, err, "passed non-pointer or nil pointer")
+}
+
+func TestValueValidate(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Level string `env:"LEVEL=info"`
+	}
+
+	env := &testEnv{}
+	v, err := NewValue[S](env.looker())
+	require.NoError(t, err)
+	v.Validate(func(next *S) error {
+		if next.Level == "trace" {
+			return errors.New("trace not allowed")
+		}
+		return nil
+	})
+
+	env.set("LEVEL", "trace")
+	require.EqualError(t, v.Reload(), "trace not allowed")
+	require.Equal(t, "info", v.Load().Level)
+}
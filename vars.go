@@ -0,0 +1,96 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"time"
+)
+
+// Get returns the value of the environment variable named by key, set as
+// a struct field of type T would be by Unmarshal, or def if the variable
+// isn't present.
+func Get[T any](key string, def T, options ...Option) (T, error) {
+	v := def
+	err := NewBinder().Var(&v, key).Apply(options...)
+	if err != nil {
+		return def, err
+	}
+	return v, nil
+}
+
+// MustGet is like Get, but panics if the variable's value can't be set.
+func MustGet[T any](key string, def T, options ...Option) T {
+	v, err := Get(key, def, options...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String returns the value of the environment variable named by key, or
+// def if the variable isn't present.
+func String(key, def string, options ...Option) (string, error) {
+	return Get(key, def, options...)
+}
+
+// MustString is like String, but panics if the variable can't be looked up.
+func MustString(key, def string, options ...Option) string {
+	return MustGet(key, def, options...)
+}
+
+// Int returns the value of the environment variable named by key parsed as
+// an int, or def if the variable isn't present.
+func Int(key string, def int, options ...Option) (int, error) {
+	return Get(key, def, options...)
+}
+
+// MustInt is like Int, but panics if the value can't be parsed.
+func MustInt(key string, def int, options ...Option) int {
+	return MustGet(key, def, options...)
+}
+
+// Bool returns the value of the environment variable named by key parsed
+// as a bool, or def if the variable isn't present.
+func Bool(key string, def bool, options ...Option) (bool, error) {
+	return Get(key, def, options...)
+}
+
+// MustBool is like Bool, but panics if the value can't be parsed.
+func MustBool(key string, def bool, options ...Option) bool {
+	return MustGet(key, def, options...)
+}
+
+// Float64 returns the value of the environment variable named by key
+// parsed as a float64, or def if the variable isn't present.
+func Float64(key string, def float64, options ...Option) (float64, error) {
+	return Get(key, def, options...)
+}
+
+// MustFloat64 is like Float64, but panics if the value can't be parsed.
+func MustFloat64(key string, def float64, options ...Option) float64 {
+	return MustGet(key, def, options...)
+}
+
+// Duration returns the value of the environment variable named by key
+// parsed with time.ParseDuration, or def if the variable isn't present.
+// A function for time.Duration configured via SetFunc takes precedence
+// over time.ParseDuration.
+func Duration(key string, def time.Duration, options ...Option) (time.Duration, error) {
+	v := def
+	err := NewBinder().Duration(&v, key).Apply(options...)
+	if err != nil {
+		return def, err
+	}
+	return v, nil
+}
+
+// MustDuration is like Duration, but panics if the value can't be parsed.
+func MustDuration(key string, def time.Duration, options ...Option) time.Duration {
+	v, err := Duration(key, def, options...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
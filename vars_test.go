@@ -0,0 +1,59 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVars(t *testing.T) {
+	t.Parallel()
+
+	env := Map(map[string]string{
+		"PORT":    "8080",
+		"DEBUG":   "true",
+		"RATIO":   "0.25",
+		"TIMEOUT": "2s",
+		"NAME":    "svc",
+		"BAD":     "bad",
+		"IP":      "10.0.0.1",
+	})
+
+	port, err := Int("PORT", 80, env)
+	require.NoError(t, err)
+	require.Equal(t, 8080, port)
+	require.Equal(t, 80, MustInt("NOPORT", 80, env))
+
+	_, err = Int("BAD", 80, env)
+	require.EqualError(t, err, "strconv.ParseInt: parsing \"bad\": invalid syntax: key BAD (int)")
+	require.Panics(t, func() { MustInt("BAD", 80, env) })
+
+	require.True(t, MustBool("DEBUG", false, env))
+	require.Equal(t, 0.25, MustFloat64("RATIO", 0, env))
+	name, err := String("NAME", "", env)
+	require.NoError(t, err)
+	require.Equal(t, "svc", name)
+	require.Equal(t, "def", MustString("NONAME", "def", env))
+	failing := Looker(func(string) (*string, error) { return nil, errors.New("lookup failed") })
+	_, err = String("NAME", "", failing)
+	require.EqualError(t, err, "lookup failed: key NAME (string)")
+	require.Panics(t, func() { MustString("NAME", "", failing) })
+	require.Equal(t, 2*time.Second, MustDuration("TIMEOUT", time.Second, env))
+	require.Equal(t, time.Second, MustDuration("NOTIMEOUT", time.Second, env))
+	require.Panics(t, func() { MustDuration("BAD", time.Second, env) })
+
+	ipSetter := func(ip *net.IP, s string) error {
+		*ip = net.ParseIP(s)
+		return nil
+	}
+	ip, err := Get[net.IP]("IP", nil, env, SetFunc(ipSetter))
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", ip.String())
+}
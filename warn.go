@@ -0,0 +1,186 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Warning describes a problem that doesn't prevent Unmarshal from
+// setting fields, such as:
+//
+// * A field's key being found, when its envopt tag has the "deprecated"
+// modifier, optionally holding the key to use instead, as in
+// `envopt:"deprecated=NEW_KEY"`.
+//
+// * A value with leading or trailing whitespace, or wrapped in quotes,
+// which is likely a mistake.
+//
+// * An unexported struct field containing tagged fields, which are
+// ignored, unless the Strict option is given.
+//
+// * A variable not used by any field, as configured via WarnUnused.
+//
+// * A field that looks like it holds a credential, but isn't secret, as
+// configured via WarnUnmarkedSecrets.
+type Warning struct {
+	// Path is the dot separated list of field names leading to the field,
+	// as used by UnmarshalPath, or empty if the warning isn't about a
+	// field.
+	Path string
+	// Key is the environment key from the field's tag, or the variable
+	// the warning is about.
+	Key string
+	// Message describes the problem.
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Path == "" {
+		return fmt.Sprintf("variable %v: %v", w.Key, w.Message)
+	}
+	return fmt.Sprintf("field %v (%v): %v", w.Path, w.Key, w.Message)
+}
+
+// Warnings configures Unmarshal to call fn with each Warning, in field
+// order. Warnings are also added to the Result configured via Record.
+func Warnings(fn func(Warning)) Option {
+	return func(c *config) {
+		c.warnings = fn
+	}
+}
+
+// warn reports a Warning for the field at the cursor.
+func (c *config) warn(cur *cursor, format string, args ...interface{}) {
+	c.addWarning(Warning{cur.path, cur.info.key, fmt.Sprintf(format, args...)})
+}
+
+// addWarning reports w to the configured function and Result, if any.
+func (c *config) addWarning(w Warning) {
+	if c.warnings != nil {
+		c.warnings(w)
+	}
+	if c.result != nil {
+		c.result.Warnings = append(c.result.Warnings, w)
+	}
+}
+
+// checkFound warns about the field at the cursor, whose key was found with
+// value val, if the key is deprecated, or the value looks mistaken.
+func (c *config) checkFound(cur *cursor, val string) {
+	if c.warnings == nil && c.result == nil {
+		return
+	}
+	if use, ok := cur.info.mods["deprecated"]; ok {
+		if use != "" {
+			c.warn(cur, "key is deprecated; use %v", use)
+		} else {
+			c.warn(cur, "key is deprecated")
+		}
+	}
+	switch {
+	case strings.TrimSpace(val) != val:
+		c.warn(cur, "value has leading or trailing whitespace")
+	case len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0]:
+		c.warn(cur, "value is wrapped in quotes")
+	}
+}
+
+// WarnUnused configures Unmarshal to warn about each variable in the
+// environment whose name starts with one of the given prefixes, or any
+// variable if none are given, that no field looked up. It's a lenient form
+// of checking for misspelled or obsolete variables. The variables are
+// listed as described by ListKeys.
+func WarnUnused(prefixes ...string) Option {
+	return func(c *config) {
+		c.unused = append([]string{}, prefixes...)
+		c.used = make(map[string]struct{})
+	}
+}
+
+// use records that key was looked up, for WarnUnused.
+func (c *config) use(key string) {
+	if c.used != nil {
+		c.used[key] = struct{}{}
+	}
+}
+
+// checkUnused warns about unused variables, as configured by WarnUnused,
+// and stops recording used keys.
+func (c *config) checkUnused() {
+	used := c.used
+	c.used = nil
+	if used == nil || c.keys == nil {
+		return
+	}
+	keys := append([]string(nil), c.keys()...)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := used[key]; ok {
+			continue
+		}
+		matched := len(c.unused) == 0
+		for _, p := range c.unused {
+			if strings.HasPrefix(key, p) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			c.addWarning(Warning{Key: key, Message: "not used by any field"})
+		}
+	}
+}
+
+// WarnUnmarkedSecrets configures Unmarshal to warn, through the function
+// configured via Warnings, about fields that look like they hold
+// credentials, but aren't secret. A field looks like it holds credentials
+// if a word of its key, such as "TOKEN" in "API_TOKEN", is one of
+// secretWords.
+func WarnUnmarkedSecrets() Option {
+	return func(c *config) {
+		c.warnSecrets = true
+	}
+}
+
+// secretWords are the words of keys that suggest a value is a credential.
+var secretWords = map[string]struct{}{
+	"PASSWORD":    {},
+	"PASSWD":      {},
+	"PASSPHRASE":  {},
+	"SECRET":      {},
+	"TOKEN":       {},
+	"KEY":         {},
+	"APIKEY":      {},
+	"CREDENTIAL":  {},
+	"CREDENTIALS": {},
+}
+
+// looksSecret returns the word of key that suggests its value is a
+// credential, if any.
+func looksSecret(key string) (string, bool) {
+	words := strings.FieldsFunc(strings.ToUpper(key), func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	for _, w := range words {
+		if _, ok := secretWords[w]; ok {
+			return w, true
+		}
+	}
+	return "", false
+}
+
+// checkSecret warns if the field at the cursor looks like it holds a
+// credential, but isn't secret.
+func (c *config) checkSecret(cur *cursor) {
+	if !c.warnSecrets || cur.info.secret {
+		return
+	}
+	if w, ok := looksSecret(cur.info.key); ok {
+		c.warn(cur, "key contains %v, but field isn't secret", w)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarnUnmarkedSecrets(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Password string `env:"DB_PASSWORD"`
+		APIKey   string `env:"API_KEY" envopt:"secret"`
+		Token    string `env:"github-token"`
+		Keyboard string `env:"KEYBOARD_LAYOUT"`
+	}
+
+	var warnings []Warning
+	collect := Warnings(func(w Warning) { warnings = append(warnings, w) })
+
+	var s S
+	require.NoError(t, Unmarshal(&s, Map(nil), collect))
+	require.Empty(t, warnings)
+
+	require.NoError(t, Unmarshal(&s, Map(nil), collect, WarnUnmarkedSecrets()))
+	require.Equal(t, []Warning{
+		{"Password", "DB_PASSWORD", "key contains PASSWORD, but field isn't secret"},
+		{"Token", "github-token", "key contains TOKEN, but field isn't secret"},
+	}, warnings)
+	require.Equal(t, "field Password (DB_PASSWORD): key contains PASSWORD, but field isn't secret", warnings[0].String())
+}
+
+func TestWarnings(t *testing.T) {
+	t.Parallel()
+
+	type hidden struct {
+		A string `env:"HIDDEN"`
+	}
+	type S struct {
+		Old    string    `env:"OLD_HOST" envopt:"deprecated=APP_HOST"`
+		Older  string    `env:"OLDER" envopt:"deprecated"`
+		Name   string    `env:"APP_NAME"`
+		Quoted string    `env:"APP_QUOTED"`
+		Lazy   Lazy[int] `env:"APP_LAZY"`
+		hidden hidden
+	}
+
+	env := Map(map[string]string{
+		"OLD_HOST":   "h",
+		"APP_NAME":   " n ",
+		"APP_QUOTED": `"q"`,
+		"APP_LAZY":   "1",
+		"APP_NAEM":   "typo",
+		"HOME":       "/root",
+	})
+
+	var s S
+	var r Result
+	var warnings []Warning
+	err := Unmarshal(&s, env, Record(&r), WarnUnused("APP_"), Warnings(func(w Warning) { warnings = append(warnings, w) }))
+	require.NoError(t, err)
+	expected := []Warning{
+		{"Old", "OLD_HOST", "key is deprecated; use APP_HOST"},
+		{"Name", "APP_NAME", "value has leading or trailing whitespace"},
+		{"Quoted", "APP_QUOTED", "value is wrapped in quotes"},
+		{"hidden", "", "unexported field contains tagged fields, which are ignored"},
+		{"", "APP_NAEM", "not used by any field"},
+	}
+	require.Equal(t, expected, warnings)
+	require.Equal(t, expected, r.Warnings)
+	require.Equal(t, "variable APP_NAEM: not used by any field", warnings[4].String())
+
+	// Warnings are collected in a Result without a function.
+	r = Result{}
+	require.NoError(t, Unmarshal(&s, Map(map[string]string{"OLDER": "x"}), Record(&r)))
+	require.Equal(t, []Warning{
+		{"Older", "OLDER", "key is deprecated"},
+		{"hidden", "", "unexported field contains tagged fields, which are ignored"},
+	}, r.Warnings)
+}
@@ -0,0 +1,446 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A FieldChange describes a tagged field whose value differs between two
+// decoded structs.
+type FieldChange struct {
+	// Path is the dot separated list of field names leading to the field,
+	// as used by UnmarshalPath.
+	Path string
+	// Key is the environment key from the field's tag.
+	Key string
+	// Old and New are the field's values, or nil if the field wasn't
+	// reachable in the respective struct. The values of secret fields
+	// are replaced by a placeholder string.
+	Old, New interface{}
+	// Secret is true if the field has the secret modifier.
+	Secret bool
+}
+
+// redacted replaces the value of secret fields, by default.
+const redacted = "[REDACTED]"
+
+// Diff returns the tagged fields whose values differ between old and new,
+// which must be pointers to structs of the same type. The values of secret
+// fields are compared, but redacted in the returned changes, by the
+// Redactor given in options, if any.
+func Diff(old, new interface{}, options ...Option) []FieldChange {
+	return diff(newConfig(options), reflect.ValueOf(old), reflect.ValueOf(new))
+}
+
+// A Watcher periodically decodes a struct from the environment, and
+// reports the tagged fields whose values have changed.
+type Watcher struct {
+	typ     reflect.Type
+	options []Option
+
+	// pollMu serializes polls, and the calls of handlers they make. mu
+	// guards the fields below, and isn't held while handlers are called,
+	// so that they may call methods such as Current.
+	pollMu     sync.Mutex
+	mu         sync.Mutex
+	current    reflect.Value
+	result     *Result
+	handlers   []func([]FieldChange)
+	validators []func(interface{}) error
+	onError    []func(error)
+	stats      WatcherStats
+
+	ticking bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewWatcher calls Unmarshal with in and options, and returns a Watcher
+// whose current struct is in. Later polls decode into newly allocated
+// structs of the same type, so in is never modified by the Watcher.
+func NewWatcher(in interface{}, options ...Option) (*Watcher, error) {
+	// Copy the options into a slice without spare capacity, so that
+	// appending Record to them never writes to the caller's slice, or to
+	// the Watcher's.
+	options = append(make([]Option, 0, len(options)), options...)
+	result := &Result{}
+	if err := Unmarshal(in, append(options, Record(result))...); err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		typ:     reflect.TypeOf(in).Elem(),
+		options: options,
+		current: reflect.ValueOf(in),
+		result:  result,
+	}
+	return w, nil
+}
+
+// Current returns a pointer to the most recently decoded struct. The
+// struct is shared, and must not be modified.
+func (w *Watcher) Current() interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current.Interface()
+}
+
+// WatcherStats holds counts of a Watcher's polls.
+type WatcherStats struct {
+	// Polls is the number of polls, including refreshes.
+	Polls int64
+	// Changes is the number of polls that replaced the current struct.
+	Changes int64
+	// Failures is the number of polls where decoding or validation failed.
+	Failures int64
+	// LastChange is when the current struct was last replaced.
+	LastChange time.Time
+	// LastError is the error from the most recent failed poll.
+	LastError string
+}
+
+// Stats returns counts of the Watcher's polls.
+func (w *Watcher) Stats() WatcherStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Snapshot returns a pointer to the most recently decoded struct, and the
+// Result of decoding it. Neither must be modified.
+func (w *Watcher) Snapshot() (interface{}, *Result) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current.Interface(), w.result
+}
+
+// OnChange registers fn to be called with the changed fields whenever a
+// poll replaces the current struct. Functions are called in the order they
+// were registered. They may call methods such as Current and Stats, but
+// must not call Poll or Refresh.
+func (w *Watcher) OnChange(fn func(changes []FieldChange)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// OnFieldChange registers fn to be called with each change to the field
+// named by path, or to fields within it if it's a struct, whenever a poll
+// replaces the current struct. The path is as used by UnmarshalPath.
+func (w *Watcher) OnFieldChange(path string, fn func(change FieldChange)) {
+	w.OnChange(func(changes []FieldChange) {
+		for _, c := range changes {
+			if c.Path == path || strings.HasPrefix(c.Path, path+".") {
+				fn(c)
+			}
+		}
+	})
+}
+
+// Validate registers fn to be called with a pointer to each newly decoded
+// struct. If fn returns an error, the new struct is discarded, and the
+// Watcher keeps its current struct.
+func (w *Watcher) Validate(fn func(next interface{}) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.validators = append(w.validators, fn)
+}
+
+// OnError registers fn to be called with the error from each failed poll,
+// including those made by Start and WatchFiles, and with each error from
+// watching the files given to WatchFiles.
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Poll decodes a new struct, and if any of its tagged fields differ from
+// those of the current struct, makes it the current struct and calls the
+// OnChange functions. The current struct is kept if decoding or
+// validation fails.
+func (w *Watcher) Poll() error {
+	w.pollMu.Lock()
+	defer w.pollMu.Unlock()
+
+	next := reflect.New(w.typ)
+	result := &Result{}
+	err := Unmarshal(next.Interface(), append(w.options, Record(result))...)
+	return w.update(next, result, err)
+}
+
+// update validates next, and makes it and its result current if next
+// differs from the current struct, calling the OnChange functions. If err
+// from decoding next is non-nil, or validation fails, the OnError functions
+// are called instead. It's called with pollMu locked, and w unlocked.
+func (w *Watcher) update(next reflect.Value, result *Result, err error) error {
+	w.mu.Lock()
+	validators := append(([]func(interface{}) error)(nil), w.validators...)
+	w.mu.Unlock()
+	if err == nil {
+		for _, fn := range validators {
+			if err = fn(next.Interface()); err != nil {
+				break
+			}
+		}
+	}
+
+	w.mu.Lock()
+	w.stats.Polls++
+	if err != nil {
+		w.stats.Failures++
+		w.stats.LastError = err.Error()
+		w.mu.Unlock()
+		w.reportError(err)
+		return err
+	}
+
+	changes := diff(newConfig(w.options), w.current, next)
+	if len(changes) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.current, w.result = next, result
+	w.stats.Changes++
+	w.stats.LastChange = time.Now()
+	handlers := append(([]func([]FieldChange))(nil), w.handlers...)
+	w.mu.Unlock()
+	for _, fn := range handlers {
+		fn(changes)
+	}
+	return nil
+}
+
+// reportError calls the OnError functions with err. It's called with w
+// unlocked.
+func (w *Watcher) reportError(err error) {
+	w.mu.Lock()
+	onError := append(([]func(error))(nil), w.onError...)
+	w.mu.Unlock()
+	for _, fn := range onError {
+		fn(err)
+	}
+}
+
+// Refresh is like Poll, but only decodes the fields tagged with the given
+// keys, into a copy of the current struct. It can be used when the
+// changed keys are known, to avoid looking up every key.
+func (w *Watcher) Refresh(keys ...string) error {
+	w.pollMu.Lock()
+	defer w.pollMu.Unlock()
+
+	w.mu.Lock()
+	next := clone(w.current)
+	current := w.result
+	w.mu.Unlock()
+	refreshed := &Result{}
+	err := UnmarshalKeys(next.Interface(), keys, append(w.options, Record(refreshed))...)
+
+	// Merge the refreshed fields into a copy of the current Result.
+	result := &Result{Fields: append([]FieldResult(nil), current.Fields...)}
+	for _, f := range refreshed.Fields {
+		if r := result.Field(f.Path); r != nil {
+			*r = f
+		} else {
+			result.Fields = append(result.Fields, f)
+		}
+	}
+	return w.update(next, result, err)
+}
+
+// Start calls Poll every interval, until Stop is called.
+func (w *Watcher) Start(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ticking {
+		panic(errors.New("watcher already started"))
+	}
+	w.ticking = true
+
+	w.spawn(func(stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = w.Poll()
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// spawn runs fn in a new goroutine, which should return once its stop
+// channel is closed by Stop. It's called with w locked.
+func (w *Watcher) spawn(fn func(stop <-chan struct{})) {
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+	}
+	stop := w.stop
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(stop)
+	}()
+}
+
+// Stop stops a started Watcher, waiting for any in-progress poll.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stop := w.stop
+	w.stop, w.ticking = nil, false
+	w.mu.Unlock()
+	if stop != nil {
+		close(stop)
+	}
+	w.wg.Wait()
+}
+
+type taggedValue struct {
+	key    string
+	value  interface{}
+	secret bool
+}
+
+// taggedValues returns the values of the tagged fields reachable from the
+// struct pointer v, keyed by field path, and the paths in visit order.
+func taggedValues(v reflect.Value) (map[string]taggedValue, []string) {
+	values := make(map[string]taggedValue)
+	var paths []string
+	_ = visit(v.Interface(), "", func(c *cursor) error {
+		key := c.info.key
+		if len(key) == 0 || !c.value.CanInterface() {
+			return nil
+		}
+		if _, ok := isLazy(c.value); ok {
+			return errSkipField
+		}
+		value := c.value
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		values[c.path] = taggedValue{key, value.Interface(), c.info.secret}
+		paths = append(paths, c.path)
+		return nil
+	})
+	return values, paths
+}
+
+// diff returns the tagged fields whose values differ between the struct
+// pointers old and new.
+func diff(config *config, old, new reflect.Value) []FieldChange {
+	oldValues, oldPaths := taggedValues(old)
+	newValues, newPaths := taggedValues(new)
+	return diffValues(config, oldValues, oldPaths, newValues, newPaths)
+}
+
+// diffValues returns the changes between the tagged values returned by
+// taggedValues for two structs.
+func diffValues(config *config, oldValues map[string]taggedValue, oldPaths []string, newValues map[string]taggedValue, newPaths []string) []FieldChange {
+	var changes []FieldChange
+	for _, path := range newPaths {
+		n := newValues[path]
+		o, ok := oldValues[path]
+		if !ok {
+			changes = append(changes, newChange(config, path, nil, &n))
+			continue
+		}
+		if !reflect.DeepEqual(o.value, n.value) {
+			changes = append(changes, newChange(config, path, &o, &n))
+		}
+	}
+	for _, path := range oldPaths {
+		if _, ok := newValues[path]; !ok {
+			o := oldValues[path]
+			changes = append(changes, newChange(config, path, &o, nil))
+		}
+	}
+	return changes
+}
+
+// newChange returns the change between the tagged values old and new,
+// either of which may be nil, redacting the values of secret fields.
+func newChange(config *config, path string, old, new *taggedValue) FieldChange {
+	c := FieldChange{Path: path}
+	for _, t := range []*taggedValue{old, new} {
+		if t != nil {
+			c.Key = t.key
+			c.Secret = c.Secret || t.secret
+		}
+	}
+	if old != nil {
+		c.Old = old.value
+	}
+	if new != nil {
+		c.New = new.value
+	}
+	if c.Secret {
+		if old != nil {
+			c.Old = config.redactValue(c.Key, old.value)
+		}
+		if new != nil {
+			c.New = config.redactValue(c.Key, new.value)
+		}
+	}
+	return c
+}
+
+// clone returns a copy of the struct pointed to by v, such that setting
+// the copy's fields doesn't modify v, or structs reachable from v.
+func clone(v reflect.Value) reflect.Value {
+	return cloneValue(v, make(map[visitedStruct]reflect.Value))
+}
+
+// cloneValue copies v if it's a pointer, or the pointers within v if it's
+// a struct or interface. Copies of pointers are recorded in copies, by the
+// address and type of the values they point to.
+func cloneValue(v reflect.Value, copies map[visitedStruct]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		k := visitedStruct{v.UnsafePointer(), v.Type().Elem()}
+		if c, ok := copies[k]; ok {
+			return c
+		}
+		c := reflect.New(v.Type().Elem())
+		copies[k] = c
+		c.Elem().Set(v.Elem())
+		if c.Elem().Kind() == reflect.Struct {
+			cloneFields(c.Elem(), copies)
+		}
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() || v.Elem().Kind() != reflect.Ptr {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem(), copies))
+		return c
+	}
+	return v
+}
+
+// cloneFields replaces the settable fields of the struct s with copies.
+func cloneFields(s reflect.Value, copies map[visitedStruct]reflect.Value) {
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		if f.Kind() == reflect.Struct {
+			cloneFields(f, copies)
+			continue
+		}
+		f.Set(cloneValue(f, copies))
+	}
+}
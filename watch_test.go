@@ -0,0 +1,358 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv is a Looker backed by a map that may be changed concurrently.
+type testEnv struct {
+	mu  sync.Mutex
+	env map[string]string
+}
+
+func (e *testEnv) set(k, v string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.env == nil {
+		e.env = make(map[string]string)
+	}
+	e.env[k] = v
+}
+
+func (e *testEnv) unset(k string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.env, k)
+}
+
+func (e *testEnv) looker() Option {
+	return Looker(func(k string) (*string, error) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if v, ok := e.env[k]; ok {
+			return &v, nil
+		}
+		return nil, nil
+	})
+}
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Level string `env:"LEVEL=info"`
+	}
+	type S struct {
+		Port  int `env:"PORT"`
+		Inner *Inner
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+
+	var s S
+	w, err := NewWatcher(&s, env.looker(), AllocateNested())
+	require.NoError(t, err)
+	require.Equal(t, &s, w.Current())
+	require.Equal(t, 80, s.Port)
+
+	var got [][]FieldChange
+	w.OnChange(func(changes []FieldChange) {
+		got = append(got, changes)
+	})
+
+	require.NoError(t, w.Poll())
+	require.Empty(t, got)
+
+	env.set("PORT", "8080")
+	env.set("LEVEL", "debug")
+	require.NoError(t, w.Poll())
+	require.Equal(t, [][]FieldChange{{
+		{Path: "Port", Key: "PORT", Old: 80, New: 8080},
+		{Path: "Inner.Level", Key: "LEVEL", Old: "info", New: "debug"},
+	}}, got)
+	require.Equal(t, 8080, w.Current().(*S).Port)
+	require.Equal(t, 80, s.Port)
+
+	// A failed decode keeps the current struct.
+	env.set("PORT", "not-a-port")
+	require.Error(t, w.Poll())
+	require.Equal(t, 8080, w.Current().(*S).Port)
+	require.Len(t, got, 1)
+}
+
+func TestWatcherStart(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Str1 string `env:"k1"`
+	}
+
+	env := &testEnv{}
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	changed := make(chan []FieldChange, 1)
+	w.OnChange(func(changes []FieldChange) {
+		changed <- changes
+	})
+
+	w.Start(time.Millisecond)
+	defer w.Stop()
+	env.set("k1", "k1-val")
+
+	select {
+	case changes := <-changed:
+		require.Equal(t, []FieldChange{{Path: "Str1", Key: "k1", Old: "", New: "k1-val"}}, changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Addr string `env:"ADDR"`
+	}
+	type S struct {
+		User     string `env:"USER"`
+		Password string `env:"PASSWORD" envopt:"secret"`
+		Inner    *Inner
+	}
+
+	old := &S{User: "u1", Password: "p1", Inner: &Inner{"a1"}}
+	new := &S{User: "u1", Password: "p2"}
+	require.Equal(t, []FieldChange{
+		{Path: "Password", Key: "PASSWORD", Old: "[REDACTED]", New: "[REDACTED]", Secret: true},
+		{Path: "Inner.Addr", Key: "ADDR", Old: "a1", New: nil},
+	}, Diff(old, new))
+
+	require.Empty(t, Diff(old, old))
+}
+
+func TestWatcherRefresh(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Token *string `env:"TOKEN"`
+	}
+	type S struct {
+		Port  int `env:"PORT"`
+		Inner *Inner
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	env.set("TOKEN", "t1")
+
+	s := S{Inner: &Inner{}}
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	var got []FieldChange
+	w.OnChange(func(changes []FieldChange) {
+		got = append(got, changes...)
+	})
+
+	env.set("PORT", "8080")
+	env.set("TOKEN", "t2")
+	require.NoError(t, w.Refresh("TOKEN"))
+	require.Equal(t, []FieldChange{{Path: "Inner.Token", Key: "TOKEN", Old: "t1", New: "t2"}}, got)
+
+	cur := w.Current().(*S)
+	require.Equal(t, 80, cur.Port)
+	require.Equal(t, "t2", *cur.Inner.Token)
+	require.Equal(t, "t1", *s.Inner.Token)
+}
+
+func TestWatcherOnFieldChange(t *testing.T) {
+	t.Parallel()
+
+	type Log struct {
+		Level  string `env:"LOG_LEVEL=info"`
+		Format string `env:"LOG_FORMAT=text"`
+	}
+	type S struct {
+		Port int `env:"PORT"`
+		Log  Log
+	}
+
+	env := &testEnv{}
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	var levels []interface{}
+	w.OnFieldChange("Log.Level", func(c FieldChange) {
+		levels = append(levels, c.New)
+	})
+	var logs []string
+	w.OnFieldChange("Log", func(c FieldChange) {
+		logs = append(logs, c.Path)
+	})
+
+	env.set("PORT", "80")
+	require.NoError(t, w.Poll())
+	require.Empty(t, levels)
+	require.Empty(t, logs)
+
+	env.set("LOG_LEVEL", "debug")
+	env.set("LOG_FORMAT", "json")
+	require.NoError(t, w.Poll())
+	require.Equal(t, []interface{}{"debug"}, levels)
+	require.Equal(t, []string{"Log.Level", "Log.Format"}, logs)
+}
+
+func TestWatcherValidate(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port int `env:"PORT"`
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	w.Validate(func(next interface{}) error {
+		if next.(*S).Port == 0 {
+			return errors.New("port required")
+		}
+		return nil
+	})
+	var errs []string
+	w.OnError(func(err error) {
+		errs = append(errs, err.Error())
+	})
+	changes := 0
+	w.OnChange(func([]FieldChange) {
+		changes++
+	})
+
+	env.unset("PORT")
+	require.EqualError(t, w.Poll(), "port required")
+	env.set("PORT", "eighty")
+	require.Error(t, w.Poll())
+	require.Equal(t, 80, w.Current().(*S).Port)
+	require.Zero(t, changes)
+	require.Len(t, errs, 2)
+	require.Equal(t, "port required", errs[0])
+
+	env.set("PORT", "8080")
+	require.NoError(t, w.Poll())
+	require.Equal(t, 8080, w.Current().(*S).Port)
+	require.Equal(t, 1, changes)
+}
+
+func TestWatcherSnapshot(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Host string `env:"HOST=localhost"`
+		Port int    `env:"PORT"`
+	}
+
+	env := &testEnv{}
+	var s S
+	w, err := NewWatcher(&s, env.looker())
+	require.NoError(t, err)
+
+	cfg, result := w.Snapshot()
+	require.Equal(t, &s, cfg)
+	require.Equal(t, SourceDefault, result.Field("Host").Source)
+	require.Equal(t, SourceNone, result.Field("Port").Source)
+
+	env.set("PORT", "80")
+	require.NoError(t, w.Refresh("PORT"))
+	_, result = w.Snapshot()
+	require.Equal(t, SourceDefault, result.Field("Host").Source)
+	require.Equal(t, SourceEnv, result.Field("Port").Source)
+}
+
+func TestWatcherHandlersUnlocked(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port int `env:"PORT"`
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	w, err := NewWatcher(&S{}, env.looker())
+	require.NoError(t, err)
+
+	// Handlers may inspect the Watcher.
+	var port int
+	var stats WatcherStats
+	w.OnChange(func([]FieldChange) {
+		port = w.Current().(*S).Port
+		cur, _ := w.Snapshot()
+		require.Equal(t, port, cur.(*S).Port)
+	})
+	w.OnError(func(error) {
+		stats = w.Stats()
+	})
+
+	env.set("PORT", "8080")
+	require.NoError(t, w.Poll())
+	require.Equal(t, 8080, port)
+
+	env.set("PORT", "x")
+	require.Error(t, w.Refresh("PORT"))
+	require.Equal(t, int64(1), stats.Failures)
+}
+
+func TestNewWatcherCopiesOptions(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Port int `env:"PORT"`
+	}
+
+	env := &testEnv{}
+	env.set("PORT", "80")
+	options := make([]Option, 1, 4)
+	options[0] = env.looker()
+	spare := options[:4]
+	w, err := NewWatcher(&S{}, options...)
+	require.NoError(t, err)
+	require.Nil(t, spare[1])
+
+	env.set("PORT", "8080")
+	require.NoError(t, w.Poll())
+	require.NoError(t, w.Refresh("PORT"))
+	require.Nil(t, spare[1])
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		N    int
+		Self *S
+		Any  interface{}
+	}
+	var s S
+	s.Self, s.Any = &s, &s
+
+	c := clone(reflect.ValueOf(&s)).Interface().(*S)
+	require.NotSame(t, &s, c)
+	require.Same(t, c, c.Self)
+	require.Same(t, c, c.Any)
+	c.N = 1
+	require.Equal(t, 0, s.N)
+}
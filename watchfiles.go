@@ -0,0 +1,101 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFiles calls Poll whenever one of the given files, or a file within
+// one of the given directories, is changed, until Stop is called. It's
+// intended for Watchers whose looker reads values from files, such as a
+// dotenv file, or a directory holding a file per key.
+//
+// Files are watched through their parent directory, so that a file being
+// replaced by a rename is noticed. If a file is a symlink, any change in its
+// directory causes a poll; this handles the symlink swap used to update
+// Kubernetes volumes, where the symlinks themselves never change. Errors
+// from watching the files are passed to the OnError functions.
+func (w *Watcher) WatchFiles(paths ...string) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Map each watched directory to the files of interest within it,
+	// where a nil map means that any change is of interest.
+	dirs := make(map[string]map[string]struct{})
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		fi, err := os.Lstat(path)
+		if err != nil {
+			fsw.Close()
+			return err
+		}
+		if fi.IsDir() || fi.Mode()&os.ModeSymlink != 0 {
+			dir := path
+			if !fi.IsDir() {
+				dir = filepath.Dir(path)
+			}
+			dirs[dir] = nil
+			continue
+		}
+		dir := filepath.Dir(path)
+		files, ok := dirs[dir]
+		if ok && files == nil {
+			continue
+		}
+		if files == nil {
+			files = make(map[string]struct{})
+			dirs[dir] = files
+		}
+		files[path] = struct{}{}
+	}
+	for dir := range dirs {
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return err
+		}
+	}
+
+	relevant := func(name string) bool {
+		files := dirs[filepath.Dir(name)]
+		if files == nil {
+			return true
+		}
+		_, ok := files[filepath.Clean(name)]
+		return ok
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.spawn(func(stop <-chan struct{}) {
+		defer fsw.Close()
+		w.watchEvents(fsw.Events, fsw.Errors, relevant, stop)
+	})
+	return nil
+}
+
+// watchEvents calls Poll for each event naming a relevant file, and the
+// OnError functions with each error, until stop is closed.
+func (w *Watcher) watchEvents(events <-chan fsnotify.Event, errs <-chan error, relevant func(string) bool, stop <-chan struct{}) {
+	for {
+		select {
+		case ev := <-events:
+			if relevant(ev.Name) {
+				_ = w.Poll()
+			}
+		case err := <-errs:
+			if err != nil {
+				w.reportError(err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
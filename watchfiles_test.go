@@ -0,0 +1,106 @@
+// Copyright 2017 Alfred Landrum. All rights reserved.
+// Use of this source code is governed by the license
+// found in the LICENSE.txt file.
+
+package fromenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dirLooker returns a looker that reads the value of each key from the
+// file of the same name in dir.
+func dirLooker(dir string) Option {
+	return Looker(func(k string) (*string, error) {
+		b, err := os.ReadFile(filepath.Join(dir, k))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		v := strings.TrimSpace(string(b))
+		return &v, nil
+	})
+}
+
+func TestWatchFiles(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Token string `env:"TOKEN"`
+	}
+
+	// Mimic a Kubernetes volume, where each key's file is a symlink into
+	// a data directory that's swapped atomically.
+	dir := t.TempDir()
+	writeData := func(name, token string) {
+		data := filepath.Join(dir, name)
+		require.NoError(t, os.Mkdir(data, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(data, "TOKEN"), []byte(token), 0o644))
+		tmp := filepath.Join(dir, "..data_tmp")
+		require.NoError(t, os.Symlink(name, tmp))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+	}
+	writeData("..v1", "t1")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "TOKEN"), filepath.Join(dir, "TOKEN")))
+
+	var s S
+	w, err := NewWatcher(&s, dirLooker(dir))
+	require.NoError(t, err)
+	require.Equal(t, "t1", s.Token)
+
+	changed := make(chan []FieldChange, 1)
+	w.OnChange(func(changes []FieldChange) {
+		changed <- changes
+	})
+
+	require.NoError(t, w.WatchFiles(filepath.Join(dir, "TOKEN")))
+	defer w.Stop()
+
+	writeData("..v2", "t2")
+	select {
+	case changes := <-changed:
+		require.Equal(t, []FieldChange{{Path: "Token", Key: "TOKEN", Old: "t1", New: "t2"}}, changes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	require.Error(t, w.WatchFiles(filepath.Join(dir, "missing")))
+}
+
+func TestWatchFilesErrors(t *testing.T) {
+	t.Parallel()
+
+	type S struct {
+		Token string `env:"TOKEN"`
+	}
+	var s S
+	w, err := NewWatcher(&s, Map(nil))
+	require.NoError(t, err)
+
+	reported := make(chan error, 1)
+	w.OnError(func(err error) {
+		reported <- err
+	})
+
+	errs := make(chan error)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.watchEvents(nil, errs, func(string) bool { return true }, stop)
+	}()
+	errs <- errors.New("queue overflow")
+	require.EqualError(t, <-reported, "queue overflow")
+	close(stop)
+	<-done
+	require.Equal(t, int64(0), w.Stats().Failures)
+}